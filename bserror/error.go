@@ -37,3 +37,43 @@ type NotFoundError struct {
 func (e *NotFoundError) Error() string {
 	return e.Msg
 }
+
+// -------------------------------------------------------- //
+
+type ReviewRejectedError struct {
+	Msg string
+}
+
+func (e *ReviewRejectedError) Error() string {
+	return e.Msg
+}
+
+// -------------------------------------------------------- //
+
+type ConflictError struct {
+	Msg string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Msg
+}
+
+// -------------------------------------------------------- //
+
+type UnauthorizedError struct {
+	Msg string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Msg
+}
+
+// -------------------------------------------------------- //
+
+type ForbiddenError struct {
+	Msg string
+}
+
+func (e *ForbiddenError) Error() string {
+	return e.Msg
+}
@@ -0,0 +1,38 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+// utf8BOM let spreadsheet programs read the file as UTF-8 so Thai text is not garbled
+const utf8BOM = "\xEF\xBB\xBF"
+
+// WriteCSV write sheet as UTF-8 CSV with header row, rows are written as they are
+// read so the whole file is never held in memory, text which spreadsheet would run
+// as formula is quoted with a leading apostrophe
+func WriteCSV(w io.Writer, s Sheet) error {
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(s.headers()); err != nil {
+		return err
+	}
+	record := make([]string, len(s.columns))
+	err := s.each(func(cells []cell) error {
+		for i, c := range cells {
+			record[i] = c.text
+			if !c.number && c.text != "" && strings.ContainsAny(c.text[:1], "=+-@") {
+				record[i] = "'" + c.text
+			}
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,223 @@
+package export
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Export formats
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Content types of export formats
+const (
+	ContentTypeCSV  = "text/csv; charset=utf-8"
+	ContentTypeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+// decimalPlaces is precision of decimal numbers written out
+const decimalPlaces = 4
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// acronyms are header words written in upper case
+var acronyms = map[string]string{"id": "ID", "isbn10": "ISBN10", "isbn13": "ISBN13"}
+
+// Sheet is a table of report rows, columns are exported fields of the row struct
+type Sheet struct {
+	Name    string
+	columns []column
+	rows    reflect.Value
+}
+
+type column struct {
+	header string
+	index  int
+}
+
+type cell struct {
+	text    string
+	number  bool
+	decimal bool
+}
+
+// Sheets split report into tables, a slice of structs is a single table named
+// report, a struct gives a table for each slice field named by its json name
+// followed by a summary table of its other fields
+func Sheets(rpt interface{}) ([]Sheet, error) {
+	v := reflect.Indirect(reflect.ValueOf(rpt))
+	switch {
+	case v.Kind() == reflect.Slice && isRow(v.Type().Elem()):
+		return []Sheet{newSheet("report", v)}, nil
+	case v.Kind() == reflect.Struct:
+		sheets := []Sheet{}
+		summary := []column{}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			name, ok := fieldName(f)
+			if !ok {
+				continue
+			}
+			if f.Type.Kind() == reflect.Slice && isRow(f.Type.Elem()) {
+				sheets = append(sheets, newSheet(name, v.Field(i)))
+			} else if isScalar(f.Type) {
+				summary = append(summary, column{header: header(f, name), index: i})
+			}
+		}
+		if len(summary) > 0 {
+			one := reflect.MakeSlice(reflect.SliceOf(v.Type()), 1, 1)
+			one.Index(0).Set(v)
+			sheets = append(sheets, Sheet{Name: "summary", columns: summary, rows: one})
+		}
+		if len(sheets) > 0 {
+			return sheets, nil
+		}
+	}
+	return nil, fmt.Errorf("report of type %s can not be exported", v.Type())
+}
+
+func newSheet(name string, rows reflect.Value) Sheet {
+	t := rows.Type().Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	columns := []column{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name, ok := fieldName(f); ok && isScalar(f.Type) {
+			columns = append(columns, column{header: header(f, name), index: i})
+		}
+	}
+	return Sheet{Name: name, columns: columns, rows: rows}
+}
+
+// headers return column headers of the sheet
+func (s Sheet) headers() []string {
+	headers := []string{}
+	for _, c := range s.columns {
+		headers = append(headers, c.header)
+	}
+	return headers
+}
+
+// each call fn with cells of every row in order
+func (s Sheet) each(fn func([]cell) error) error {
+	for i := 0; i < s.rows.Len(); i++ {
+		row := reflect.Indirect(s.rows.Index(i))
+		cells := make([]cell, len(s.columns))
+		for j, c := range s.columns {
+			cells[j] = cellOf(row.Field(c.index))
+		}
+		if err := fn(cells); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cellOf(v reflect.Value) cell {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return cell{}
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cell{text: strconv.FormatInt(v.Int(), 10), number: true}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cell{text: strconv.FormatUint(v.Uint(), 10), number: true}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return cell{}
+		}
+		scale := math.Pow(10, decimalPlaces)
+		return cell{text: strconv.FormatFloat(math.Round(f*scale)/scale, 'f', -1, 64), number: true, decimal: true}
+	case reflect.Bool:
+		return cell{text: strconv.FormatBool(v.Bool())}
+	case reflect.String:
+		return cell{text: v.String()}
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return cell{text: t.Format("2006-01-02 15:04:05")}
+	}
+	return cell{text: fmt.Sprint(v.Interface())}
+}
+
+// fieldName return json name of exported field, false when field is hidden from json
+func fieldName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" {
+		return "", false
+	}
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, true
+}
+
+// header return export tag of field or words of its name, total_revenue and
+// TotalRevenue are both Total Revenue
+func header(f reflect.StructField, name string) string {
+	if h := f.Tag.Get("export"); h != "" {
+		return h
+	}
+	words := strings.Split(name, "_")
+	if len(words) == 1 {
+		words = splitCamel(name)
+	}
+	for i, w := range words {
+		if a, ok := acronyms[strings.ToLower(w)]; ok {
+			words[i] = a
+		} else if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func splitCamel(name string) []string {
+	words := []string{}
+	start := 0
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
+
+// isRow return true for struct type, or pointer to it, which can be a sheet row
+func isRow(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// isScalar return true for type written as a single cell
+func isScalar(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.Func, reflect.Chan:
+		return false
+	case reflect.Struct:
+		return t == timeType
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type bookRow struct {
+	BookID string   `json:"book_id"`
+	Ttile  string   `export:"Title"`
+	Amount int      `json:"amount"`
+	Value  *float64 `json:"value"`
+	Hidden string   `json:"-"`
+}
+
+type bookReport struct {
+	AsOf  string    `json:"as_of"`
+	Books []bookRow `json:"books"`
+	Total float64   `json:"total"`
+}
+
+func TestSheetsOfSlice(t *testing.T) {
+	sheets, err := Sheets([]bookRow{{BookID: "1"}})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(sheets))
+	assert.Equal(t, "report", sheets[0].Name)
+	assert.Equal(t, []string{"Book ID", "Title", "Amount", "Value"}, sheets[0].headers())
+}
+
+func TestSheetsOfStruct(t *testing.T) {
+	sheets, err := Sheets(&bookReport{AsOf: "2019-12-31", Books: []bookRow{{BookID: "1"}}, Total: 10})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(sheets))
+	assert.Equal(t, "books", sheets[0].Name, "slice field becomes a sheet")
+	assert.Equal(t, "summary", sheets[1].Name)
+	assert.Equal(t, []string{"As Of", "Total"}, sheets[1].headers())
+}
+
+func TestSheetsOfScalar(t *testing.T) {
+	_, err := Sheets(42)
+
+	assert.NotNil(t, err, "scalar can not be exported")
+}
+
+func TestWriteCSV(t *testing.T) {
+	value := 1353.2900000001
+	sheets, _ := Sheets([]bookRow{
+		{BookID: "1", Ttile: "ภาษาไทย, ง่ายนิดเดียว", Amount: 2, Value: &value},
+		{BookID: "2", Ttile: "=HYPERLINK(\"x\")", Amount: -1},
+	})
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, sheets[0])
+
+	assert.Nil(t, err, "should not get any error")
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, utf8BOM), "csv must start with byte order mark")
+	assert.Equal(t, "Book ID,Title,Amount,Value\n"+
+		"1,\"ภาษาไทย, ง่ายนิดเดียว\",2,1353.29\n"+
+		"2,\"'=HYPERLINK(\"\"x\"\")\",-1,\n", strings.TrimPrefix(out, utf8BOM))
+}
+
+func TestWriteXLSX(t *testing.T) {
+	value := 1353.29
+	sheets, _ := Sheets(&bookReport{AsOf: "2019-12-31", Books: []bookRow{
+		{BookID: "1", Ttile: "ภาษาไทย <&>", Amount: 2, Value: &value},
+	}})
+	var buf bytes.Buffer
+	err := WriteXLSX(&buf, sheets)
+	assert.Nil(t, err, "should not get any error")
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err, "xlsx must be a zip")
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		body, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(body)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			assert.Nil(t, xml.Unmarshal(body, new(interface{})), f.Name+" must be well formed")
+		}
+	}
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="books" sheetId="1" r:id="rId1"/>`)
+	assert.Contains(t, parts["xl/workbook.xml"], `<sheet name="summary" sheetId="2" r:id="rId2"/>`)
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="B2" s="0" t="inlineStr"><is><t xml:space="preserve">ภาษาไทย &lt;&amp;&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `<c r="C2" s="2"><v>2</v></c>`)
+	assert.Contains(t, sheet, `<c r="D2" s="3"><v>1353.29</v></c>`)
+}
+
+func TestColumnName(t *testing.T) {
+	assert.Equal(t, "A", columnName(0))
+	assert.Equal(t, "Z", columnName(25))
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "BA", columnName(52))
+}
@@ -34,6 +34,22 @@ func CustomHTTPErrorHandler(err error, c echo.Context) {
 		code = http.StatusConflict
 		et.Message = e.Error()
 		break
+	case *bserror.ConflictError:
+		code = http.StatusConflict
+		et.Message = e.Error()
+		break
+	case *bserror.UnauthorizedError:
+		code = http.StatusUnauthorized
+		et.Message = e.Error()
+		break
+	case *bserror.ForbiddenError:
+		code = http.StatusForbidden
+		et.Message = e.Error()
+		break
+	case *bserror.ReviewRejectedError:
+		code = http.StatusUnprocessableEntity
+		et.Message = e.Error()
+		break
 	case validator.ValidationErrors:
 		code = http.StatusBadRequest
 		et.Message = e.Error()
@@ -2,8 +2,12 @@ package main
 
 import (
 	"database/sql"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/golang-migrate/migrate"
@@ -16,6 +20,8 @@ import (
 	"github.com/labstack/gommon/log"
 	"github.com/tsongpon/backend-challenge-2019/handler"
 	"github.com/tsongpon/backend-challenge-2019/repository"
+	"github.com/tsongpon/backend-challenge-2019/scheduler"
+	"github.com/tsongpon/backend-challenge-2019/screening"
 	"github.com/tsongpon/backend-challenge-2019/service"
 	v1handler "github.com/tsongpon/backend-challenge-2019/v1/handler"
 )
@@ -29,6 +35,11 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 }
 
 func main() {
+	rebuildSummaries := flag.Bool("rebuild-summaries", false, "rebuild report summary tables from raw sales and reviews, then exit")
+	snapshotBestsellers := flag.Bool("snapshot-bestsellers", false, "rank bestseller lists of last finished week, then exit")
+	normalizeSlugs := flag.Bool("normalize-slugs", false, "derive again invalid category slugs from their names, then exit")
+	flag.Parse()
+
 	log.Info("starting server")
 	dbHost := getEnv("DB_HOST", "localhost")
 	dbUser := getEnv("DB_USER", "root")
@@ -53,7 +64,34 @@ func main() {
 		log.Error("database migration error", err.Error())
 		panic(err.Error())
 	}
-	m.Steps(2)
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		log.Error("database migration error", err.Error())
+		panic(err.Error())
+	}
+
+	if *rebuildSummaries {
+		if err := repository.NewMysqlSummaryRepository(db).RebuildSummaries(); err != nil {
+			panic(err.Error())
+		}
+		log.Info("summary tables rebuilt")
+		return
+	}
+	if *snapshotBestsellers {
+		created, err := newBestsellerService(db).SnapshotLists()
+		if err != nil {
+			panic(err.Error())
+		}
+		log.Info(fmt.Sprintf("%d bestseller lists snapshotted", created))
+		return
+	}
+	if *normalizeSlugs {
+		changed, err := service.NewCategoryService(repository.NewMysqlCategoryRepository(db)).NormalizeSlugs()
+		if err != nil {
+			panic(err.Error())
+		}
+		log.Info(fmt.Sprintf("%d category slugs normalized", changed))
+		return
+	}
 
 	e := echo.New()
 	e.Use(middleware.Logger())
@@ -64,13 +102,39 @@ func main() {
 	e.Validator = &CustomValidator{validator: validator.New()}
 	e.HTTPErrorHandler = handler.CustomHTTPErrorHandler
 
+	saleMysqlRepo := repository.NewMysqlSaleRepository(db)
+
 	bookMysqlRepo := repository.NewMysqlBookRepository(db)
-	bookService := service.NewBookService(bookMysqlRepo)
+	stockMysqlRepo := repository.NewMysqlStockRepository(db)
+	authorMysqlRepo := repository.NewMysqlAuthorRepository(db)
+	authorHandler := v1handler.NewAuthorHandler(service.NewAuthorService(authorMysqlRepo))
+	publisherMysqlRepo := repository.NewMysqlPublisherRepository(db)
+	publisherHandler := v1handler.NewPublisherHandler(service.NewPublisherService(publisherMysqlRepo))
+	categoryMysqlRepo := repository.NewMysqlCategoryRepository(db)
+	categoryHandler := v1handler.NewCategoryHandler(service.NewCategoryService(categoryMysqlRepo))
+	workMysqlRepo := repository.NewMysqlWorkRepository(db)
+	workHandler := v1handler.NewWorkHandler(service.NewWorkService(workMysqlRepo, bookMysqlRepo))
+	seriesMysqlRepo := repository.NewMysqlSeriesRepository(db)
+	seriesHandler := v1handler.NewSeriesHandler(service.NewSeriesService(seriesMysqlRepo, bookMysqlRepo))
+	bookService := service.NewBookService(bookMysqlRepo, authorMysqlRepo,
+		publisherMysqlRepo, categoryMysqlRepo, seriesMysqlRepo, workMysqlRepo)
 	bookHandler := v1handler.NewBookHandler(bookService)
+	tagHandler := v1handler.NewTagHandler(service.NewTagService(repository.NewMysqlTagRepository(db), bookMysqlRepo))
 
 	reviewMysqlRepo := repository.NewMysqlReviewRepository(db)
-	reviewService := service.NewReviewService(reviewMysqlRepo)
-	reviewHandler := v1handler.NewReviewHandler(reviewService)
+	reviewService := service.NewReviewService(reviewMysqlRepo, saleMysqlRepo, newScreeningPipeline(reviewMysqlRepo))
+	replyMysqlRepo := repository.NewMysqlReplyRepository(db)
+	replyService := service.NewReplyService(replyMysqlRepo, reviewMysqlRepo)
+	replyHandler := v1handler.NewReplyHandler(replyService)
+	reviewHandler := v1handler.NewReviewHandler(reviewService, replyService)
+
+	reportMysqlRepo := repository.NewMysqlReportRepository(db)
+	reportService := service.NewReportService(reportMysqlRepo, stockMysqlRepo)
+	reportHandler := v1handler.NewReportHandler(reportService)
+	bestsellerHandler := v1handler.NewBestsellerHandler(newBestsellerService(db))
+	reportRunMysqlRepo := repository.NewMysqlReportRunRepository(db)
+	archiveDir := getEnv("REPORT_ARCHIVE_DIR", "reports")
+	reportRunHandler := v1handler.NewReportRunHandler(service.NewReportRunService(reportRunMysqlRepo, archiveDir))
 
 	e.GET("/ping", func(c echo.Context) error {
 		return c.String(http.StatusOK, "pong")
@@ -85,18 +149,133 @@ func main() {
 	e.PUT("/v1/books/:id/fill", bookHandler.FillBook)
 	e.PUT("/v1/books/:id/sale", bookHandler.SaleBook)
 
+	e.POST("/v1/books/:id/tags", tagHandler.AttachTags)
+	e.DELETE("/v1/books/:id/tags/:tag", tagHandler.DetachTag)
+	e.GET("/v1/tags", tagHandler.QueryTag)
+
+	e.GET("/v1/authors/:id", authorHandler.GetAuthor)
+	e.GET("/v1/authors", authorHandler.QueryAuthor)
+	e.POST("/v1/authors", authorHandler.CreateAuthor)
+	e.PUT("/v1/authors/:id", authorHandler.UpdateAuthor)
+	e.DELETE("/v1/authors/:id", authorHandler.DeleteAuthor)
+
+	e.GET("/v1/publishers/duplicates", publisherHandler.GetDuplicates)
+	e.POST("/v1/publishers/duplicates/merge", publisherHandler.MergeDuplicates)
+	e.GET("/v1/publishers/:id", publisherHandler.GetPublisher)
+	e.GET("/v1/publishers", publisherHandler.QueryPublisher)
+	e.POST("/v1/publishers", publisherHandler.CreatePublisher)
+	e.PUT("/v1/publishers/:id", publisherHandler.UpdatePublisher)
+	e.DELETE("/v1/publishers/:id", publisherHandler.DeletePublisher)
+	e.POST("/v1/publishers/:id/merge", publisherHandler.MergePublishers)
+
+	e.GET("/v1/works/:id", workHandler.GetWork)
+	e.GET("/v1/works", workHandler.QueryWork)
+	e.POST("/v1/works", workHandler.CreateWork)
+	e.PUT("/v1/works/:id", workHandler.UpdateWork)
+	e.DELETE("/v1/works/:id", workHandler.DeleteWork)
+	e.GET("/v1/works/:id/reviews", reviewHandler.GetWorkReview)
+
+	e.GET("/v1/series/:id", seriesHandler.GetSeries)
+	e.GET("/v1/series", seriesHandler.QuerySeries)
+	e.POST("/v1/series", seriesHandler.CreateSeries)
+	e.PUT("/v1/series/:id", seriesHandler.UpdateSeries)
+	e.DELETE("/v1/series/:id", seriesHandler.DeleteSeries)
+	e.GET("/v1/series/:id/books", seriesHandler.GetSeriesBooks)
+
+	e.GET("/v1/categories/:id", categoryHandler.GetCategory)
+	e.GET("/v1/categories", categoryHandler.QueryCategory)
+	e.POST("/v1/categories", categoryHandler.CreateCategory)
+	e.PUT("/v1/categories/:id", categoryHandler.UpdateCategory)
+	e.DELETE("/v1/categories/:id", categoryHandler.DeleteCategory)
+	e.POST("/v1/categories/:id/move", categoryHandler.MoveCategory)
+	e.POST("/v1/categories/:id/merge", categoryHandler.MergeCategories)
+
 	e.GET("/v1/books/:book_id/reviews/:id", reviewHandler.GetReview)
 	e.PUT("/v1/books/:book_id/reviews/:id", reviewHandler.UpdateReview)
 	e.GET("/v1/books/:book_id/reviews", reviewHandler.GetBookReview)
 	e.POST("/v1/books/:book_id/reviews", reviewHandler.CreateReview)
 	e.DELETE("/v1/books/:book_id/reviews/:id", reviewHandler.DeleteReview)
+	e.POST("/v1/books/:book_id/reviews/:id/votes", reviewHandler.VoteReview)
+	e.GET("/v1/books/:book_id/reviews/:id/history", reviewHandler.GetReviewHistory)
+
+	e.GET("/v1/books/:book_id/reviews/:id/replies/:reply_id", replyHandler.GetReply)
+	e.PUT("/v1/books/:book_id/reviews/:id/replies/:reply_id", replyHandler.UpdateReply)
+	e.GET("/v1/books/:book_id/reviews/:id/replies", replyHandler.GetReviewReply)
+	e.POST("/v1/books/:book_id/reviews/:id/replies", replyHandler.CreateReply)
+	e.DELETE("/v1/books/:book_id/reviews/:id/replies/:reply_id", replyHandler.DeleteReply)
+
+	e.GET("/v1/reviews", reviewHandler.GetReviewFeed)
+	e.GET("/v1/reviewers/:id/reviews", reviewHandler.GetReviewerReview)
+
+	e.GET("/v1/reports/bestsallbook", reportHandler.GetBastSallBook)
+	e.GET("/v1/reports/bestsallcategory", reportHandler.GetBastSallCategory)
+	e.GET("/v1/reports/revenue", reportHandler.GetRevenue)
+	e.GET("/v1/reports/inventory-valuation", reportHandler.GetInventoryValuation)
+	e.GET("/v1/reports/inventory-aging", reportHandler.GetInventoryAging)
+	e.GET("/v1/reports/reorder-suggestions", reportHandler.GetReorderSuggestions)
+	e.POST("/v1/reports/reorder-suggestions", reportHandler.CreateDraftRestock)
+	e.GET("/v1/restocks", reportHandler.GetRestocks)
+	e.PUT("/v1/restocks/:id/status", reportHandler.UpdateRestockStatus)
+	e.GET("/v1/reports/sentimentmismatch", reviewHandler.GetSentimentMismatch)
+	e.GET("/v1/reports/reviews", reportHandler.GetReviewAnalytics)
+
+	e.GET("/v1/bestseller-lists/:category", bestsellerHandler.GetBestsellerList)
 
-	e.GET("/v1/reports/bestsallbook", bookHandler.GetBastSallBook)
-	e.GET("/v1/reports/bestsallcategory", bookHandler.GetBastSallCategory)
+	e.GET("/v1/report-runs", reportRunHandler.GetReportRuns)
+	e.GET("/v1/report-runs/:id", reportRunHandler.GetReportRun)
+	e.GET("/v1/report-runs/:id/download", reportRunHandler.DownloadReportRun)
+
+	newScheduler(e, reportRunMysqlRepo, archiveDir).Start()
 
 	e.Logger.Fatal(e.Start(":5000"))
 }
 
+func newScreeningPipeline(reviewRepo *repository.MysqlReviewRepository) *screening.Pipeline {
+	words := screening.DefaultBannedWords
+	if path := getEnv("SCREENING_BANNED_WORDS", ""); path != "" {
+		loaded, err := screening.LoadWordList(path)
+		if err != nil {
+			panic(err.Error())
+		}
+		words = loaded
+	}
+	rateLimit, err := strconv.Atoi(getEnv("SCREENING_RATE_LIMIT", "5"))
+	if err != nil {
+		panic(err.Error())
+	}
+	return screening.NewPipeline(
+		screening.NewBannedWordRule(words),
+		screening.LinkRule{},
+		screening.PhoneRule{},
+		screening.NewDuplicateRule(reviewRepo),
+		screening.NewRateRule(reviewRepo, rateLimit, time.Hour),
+	)
+}
+
+func newBestsellerService(db *sql.DB) *service.BestsellerService {
+	size, err := strconv.Atoi(getEnv("BESTSELLER_LIST_SIZE", strconv.Itoa(service.DefaultBestsellerSize)))
+	if err != nil {
+		panic(err.Error())
+	}
+	return service.NewBestsellerService(repository.NewMysqlBestsellerRepository(db), size)
+}
+
+func newScheduler(reports http.Handler, recorder scheduler.RunRecorder, dir string) *scheduler.Scheduler {
+	jobs := []scheduler.Job{}
+	if path := getEnv("REPORT_SCHEDULE", ""); path != "" {
+		loaded, err := scheduler.LoadJobs(path)
+		if err != nil {
+			panic(err.Error())
+		}
+		jobs = loaded
+	}
+	s, err := scheduler.NewScheduler(jobs, reports, recorder, dir)
+	if err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
 func getEnv(key, fallback string) string {
 	value := os.Getenv(key)
 	if len(value) == 0 {
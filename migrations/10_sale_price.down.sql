@@ -0,0 +1 @@
+ALTER TABLE sale DROP COLUMN format, DROP COLUMN unitprice;
@@ -0,0 +1,6 @@
+alter table sale
+	add format varchar(16) not null default 'paperback' after customer_id,
+	add unitprice decimal(10,2) null after amount;
+
+update sale s join book b on b.id = s.book_id
+	set s.unitprice = b.paperbackprice;
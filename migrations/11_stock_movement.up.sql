@@ -0,0 +1,27 @@
+alter table book
+	add costprice float null after ebookprice;
+
+create table stock_movement
+(
+	id varchar(36) not null
+		primary key,
+	book_id varchar(36) not null,
+	quantity int not null,
+	reason varchar(16) not null,
+	createdtime datetime not null,
+	constraint stock_movement_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index stock_movement_book_id_createdtime_index
+	on stock_movement (book_id, createdtime);
+
+insert into stock_movement (id, book_id, quantity, reason, createdtime)
+	select uuid(), b.id, ifnull(b.currentamount, 0) + ifnull(sum(s.amount), 0), 'opening', b.createdtime
+	from book b left join sale s on s.book_id = b.id and s.format = 'paperback'
+	group by b.id, b.currentamount, b.createdtime;
+
+insert into stock_movement (id, book_id, quantity, reason, createdtime)
+	select uuid(), s.book_id, -s.amount, 'sale', s.createdtime
+	from sale s where s.format = 'paperback';
@@ -0,0 +1,17 @@
+create table restock
+(
+	id varchar(36) not null
+		primary key,
+	book_id varchar(36) not null,
+	quantity int not null,
+	status varchar(16) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null,
+	constraint restock_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index restock_status_index
+	on restock (status);
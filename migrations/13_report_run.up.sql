@@ -0,0 +1,19 @@
+create table report_run
+(
+	id varchar(36) not null
+		primary key,
+	job varchar(100) not null,
+	report varchar(100) not null,
+	format varchar(8) not null,
+	scheduledtime datetime not null,
+	startedtime datetime not null,
+	finishedtime datetime null,
+	status varchar(16) not null,
+	file varchar(255) null,
+	error text null,
+	constraint report_run_job_scheduledtime_uindex
+		unique (job, scheduledtime)
+);
+
+create index report_run_scheduledtime_index
+	on report_run (scheduledtime);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_review_stat;
+DROP TABLE IF EXISTS sale_daily;
@@ -0,0 +1,38 @@
+create table sale_daily
+(
+	saledate date not null,
+	book_id varchar(36) not null,
+	format varchar(16) not null,
+	amount int not null,
+	revenue decimal(14,2) not null,
+	constraint sale_daily_pk
+		primary key (saledate, book_id, format),
+	constraint sale_daily_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index sale_daily_book_id_saledate_index
+	on sale_daily (book_id, saledate);
+
+create table book_review_stat
+(
+	book_id varchar(36) not null
+		primary key,
+	reviewcount int not null,
+	scoresum int not null,
+	verifiedcount int not null,
+	verifiedscoresum int not null,
+	constraint book_review_stat_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+insert into sale_daily (saledate, book_id, format, amount, revenue)
+	select date(createdtime), book_id, format, sum(amount), sum(amount * ifnull(unitprice, 0))
+	from sale group by date(createdtime), book_id, format;
+
+insert into book_review_stat (book_id, reviewcount, scoresum, verifiedcount, verifiedscoresum)
+	select book_id, count(id), sum(score), sum(verifiedpurchase),
+		sum(case when verifiedpurchase = 1 then score else 0 end)
+	from review where book_id is not null group by book_id;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bestseller_entry;
+DROP TABLE IF EXISTS bestseller_list;
@@ -0,0 +1,31 @@
+create table bestseller_list
+(
+	category varchar(100) not null,
+	period date not null,
+	createdtime datetime not null,
+	constraint bestseller_list_pk
+		primary key (category, period)
+);
+
+create table bestseller_entry
+(
+	category varchar(100) not null,
+	period date not null,
+	listrank int not null,
+	book_id varchar(36) not null,
+	amount int not null,
+	previousrank int null,
+	weeksonlist int not null,
+	peakrank int not null,
+	constraint bestseller_entry_pk
+		primary key (category, period, listrank),
+	constraint bestseller_entry_list_fk
+		foreign key (category, period) references bestseller_list (category, period)
+			on delete cascade,
+	constraint bestseller_entry_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index bestseller_entry_category_book_id_period_index
+	on bestseller_entry (category, book_id, period);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS book_author;
+DROP TABLE IF EXISTS author;
@@ -0,0 +1,35 @@
+create table author
+(
+	id varchar(36) not null
+		primary key,
+	name varchar(255) not null,
+	sortname varchar(255) not null,
+	bio text null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null
+);
+
+create index author_sortname_index
+	on author (sortname);
+
+create index author_name_index
+	on author (name);
+
+create table book_author
+(
+	book_id varchar(36) not null,
+	author_id varchar(36) not null,
+	role varchar(16) not null,
+	position int not null,
+	constraint book_author_pk
+		primary key (book_id, author_id, role),
+	constraint book_author_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade,
+	constraint book_author_author_id_fk
+		foreign key (author_id) references author (id)
+);
+
+create index book_author_author_id_index
+	on book_author (author_id);
@@ -0,0 +1,17 @@
+alter table book
+	add publisher varchar(255) null after language;
+
+update book b join publisher p on p.id = b.publisher_id
+	set b.publisher = p.name;
+
+alter table book
+	drop foreign key book_publisher_id_fk;
+
+alter table book
+	modify publisher varchar(255) not null,
+	drop column publisher_id;
+
+create index book_publisher_index
+	on book (publisher);
+
+DROP TABLE IF EXISTS publisher;
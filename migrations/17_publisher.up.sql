@@ -0,0 +1,30 @@
+create table publisher
+(
+	id varchar(36) not null
+		primary key,
+	name varchar(255) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null,
+	constraint publisher_name_uindex
+		unique (name)
+);
+
+insert into publisher (id, name, createdtime, modifiedtime, version)
+	select uuid(), min(trim(publisher)), now(), now(), 1 from book group by trim(publisher);
+
+alter table book
+	add publisher_id varchar(36) null after language;
+
+update book b join publisher p on p.name = trim(b.publisher)
+	set b.publisher_id = p.id;
+
+alter table book
+	modify publisher_id varchar(36) not null,
+	add constraint book_publisher_id_fk
+		foreign key (publisher_id) references publisher (id);
+
+drop index book_publisher_index on book;
+
+alter table book
+	drop column publisher;
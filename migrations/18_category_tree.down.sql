@@ -0,0 +1,16 @@
+alter table book
+	add category varchar(100) not null default '' after isbn13;
+
+update book b
+	join book_category bc on bc.book_id = b.id
+		and bc.position = (select min(position) from book_category where book_id = b.id)
+	join category c on c.id = bc.category_id
+	set b.category = c.name;
+
+alter table book
+	alter category drop default;
+
+DROP TABLE IF EXISTS book_category;
+DROP TABLE IF EXISTS category_name;
+DROP TABLE IF EXISTS category_path;
+DROP TABLE IF EXISTS category;
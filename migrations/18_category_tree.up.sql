@@ -0,0 +1,85 @@
+create table category
+(
+	id varchar(36) not null
+		primary key,
+	parent_id varchar(36) null,
+	slug varchar(128) not null,
+	name varchar(255) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null,
+	constraint category_slug_uindex
+		unique (slug),
+	constraint category_parent_id_fk
+		foreign key (parent_id) references category (id)
+);
+
+-- every ancestor of every category, itself included at depth 0, so subtrees are
+-- read without recursive queries
+create table category_path
+(
+	ancestor_id varchar(36) not null,
+	descendant_id varchar(36) not null,
+	depth int not null,
+	constraint category_path_pk
+		primary key (ancestor_id, descendant_id),
+	constraint category_path_ancestor_id_fk
+		foreign key (ancestor_id) references category (id)
+			on delete cascade,
+	constraint category_path_descendant_id_fk
+		foreign key (descendant_id) references category (id)
+			on delete cascade
+);
+
+create index category_path_descendant_id_index
+	on category_path (descendant_id, depth);
+
+create table category_name
+(
+	category_id varchar(36) not null,
+	locale varchar(16) not null,
+	name varchar(255) not null,
+	constraint category_name_pk
+		primary key (category_id, locale),
+	constraint category_name_category_id_fk
+		foreign key (category_id) references category (id)
+			on delete cascade
+);
+
+create table book_category
+(
+	book_id varchar(36) not null,
+	category_id varchar(36) not null,
+	position int not null,
+	constraint book_category_pk
+		primary key (book_id, category_id),
+	constraint book_category_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade,
+	constraint book_category_category_id_fk
+		foreign key (category_id) references category (id)
+);
+
+create index book_category_category_id_index
+	on book_category (category_id);
+
+-- existing categories become top level categories, slug is derived from name
+insert into category (id, parent_id, slug, name, createdtime, modifiedtime, version)
+	select uuid(), null, slug, min(name), now(), now(), 1
+	from (
+		select trim(category) as name,
+			lower(replace(replace(replace(replace(trim(category), ' & ', '-'), ', ', '-'), '/', '-'), ' ', '-')) as slug
+		from book where trim(category) <> ''
+	) flat
+	group by slug;
+
+insert into category_path (ancestor_id, descendant_id, depth)
+	select id, id, 0 from category;
+
+insert into book_category (book_id, category_id, position)
+	select b.id, c.id, 1 from book b
+		join category c on c.slug =
+			lower(replace(replace(replace(replace(trim(b.category), ' & ', '-'), ', ', '-'), '/', '-'), ' ', '-'));
+
+alter table book
+	drop column category;
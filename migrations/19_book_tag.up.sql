@@ -0,0 +1,14 @@
+create table book_tag
+(
+	book_id varchar(36) not null,
+	tag varchar(64) not null,
+	createdtime datetime not null,
+	constraint book_tag_pk
+		primary key (book_id, tag),
+	constraint book_tag_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index book_tag_tag_index
+	on book_tag (tag);
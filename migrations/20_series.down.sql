@@ -0,0 +1,10 @@
+alter table book
+	drop foreign key book_series_id_fk;
+
+drop index book_series_index on book;
+
+alter table book
+	drop column seriesvolume,
+	drop column series_id;
+
+DROP TABLE IF EXISTS series;
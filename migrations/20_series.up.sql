@@ -0,0 +1,21 @@
+create table series
+(
+	id varchar(36) not null
+		primary key,
+	name varchar(255) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null
+);
+
+create index series_name_index
+	on series (name);
+
+alter table book
+	add series_id varchar(36) null after publisher_id,
+	add seriesvolume decimal(8,2) null after series_id,
+	add constraint book_series_id_fk
+		foreign key (series_id) references series (id);
+
+create index book_series_index
+	on book (series_id, seriesvolume);
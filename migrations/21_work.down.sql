@@ -0,0 +1,9 @@
+alter table book
+	drop foreign key book_work_id_fk;
+
+drop index book_work_index on book;
+
+alter table book
+	drop column work_id;
+
+DROP TABLE IF EXISTS work;
@@ -0,0 +1,22 @@
+-- a work is the book as written, its editions are rows of book like 1st and 2nd
+-- editions, translations or other formats
+create table work
+(
+	id varchar(36) not null
+		primary key,
+	title varchar(255) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null
+);
+
+create index work_title_index
+	on work (title);
+
+alter table book
+	add work_id varchar(36) null after id,
+	add constraint book_work_id_fk
+		foreign key (work_id) references work (id);
+
+create index book_work_index
+	on book (work_id);
@@ -0,0 +1 @@
+update review set sentiment = null where sentiment = 0;
@@ -0,0 +1,19 @@
+create table category_sale_daily
+(
+	saledate date not null,
+	category_id varchar(36) not null,
+	amount int not null,
+	revenue decimal(14,2) not null,
+	constraint category_sale_daily_pk
+		primary key (saledate, category_id),
+	constraint category_sale_daily_category_id_fk
+		foreign key (category_id) references category (id)
+			on delete cascade
+);
+
+insert into category_sale_daily (saledate, category_id, amount, revenue)
+	select d.saledate, bca.ancestor_id, sum(d.amount), sum(d.revenue)
+	from sale_daily d
+		join (select distinct bc.book_id, cp.ancestor_id from book_category bc
+			join category_path cp on cp.descendant_id = bc.category_id) bca on bca.book_id = d.book_id
+	group by d.saledate, bca.ancestor_id;
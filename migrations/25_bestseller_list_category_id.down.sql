@@ -0,0 +1,8 @@
+insert into bestseller_list (category, period, createdtime)
+	select c.slug, l.period, l.createdtime
+	from bestseller_list l join category c on c.id = l.category;
+
+update bestseller_entry e join category c on c.id = e.category
+	set e.category = c.slug;
+
+delete l from bestseller_list l join category c on c.id = l.category;
@@ -0,0 +1,55 @@
+-- lists were kept by category as requested (id, slug or name), key them by category
+-- id so a list is found whichever way the category is given
+create temporary table bestseller_list_key
+(
+	category varchar(100) not null
+		primary key,
+	category_id varchar(36) null
+);
+
+insert into bestseller_list_key (category, category_id)
+	select l.category, coalesce(
+		(select c.id from category c where c.id = l.category),
+		(select c.id from category c where c.slug = l.category),
+		(select min(c.id) from category c where c.name = l.category))
+	from (select distinct category from bestseller_list) l;
+
+delete l from bestseller_list l
+	join bestseller_list_key k on k.category = l.category
+	where k.category_id is null;
+
+-- a category asked for in several ways has several lists of a week, the one
+-- published first is kept
+create temporary table bestseller_list_first as
+	select k.category_id, l.period, min(l.createdtime) as createdtime
+	from bestseller_list l join bestseller_list_key k on k.category = l.category
+	group by k.category_id, l.period;
+
+create temporary table bestseller_list_keep as
+	select min(l.category) as category, l.period
+	from bestseller_list l
+		join bestseller_list_key k on k.category = l.category
+		join bestseller_list_first f on f.category_id = k.category_id and f.period = l.period
+			and f.createdtime = l.createdtime
+	group by k.category_id, l.period;
+
+delete l from bestseller_list l
+	left join bestseller_list_keep p on p.category = l.category and p.period = l.period
+	where p.category is null;
+
+insert into bestseller_list (category, period, createdtime)
+	select k.category_id, l.period, l.createdtime
+	from bestseller_list l join bestseller_list_key k on k.category = l.category
+	where k.category_id <> l.category;
+
+update bestseller_entry e join bestseller_list_key k on k.category = e.category
+	set e.category = k.category_id
+	where k.category_id <> e.category;
+
+delete l from bestseller_list l
+	join bestseller_list_key k on k.category = l.category
+	where k.category_id <> l.category;
+
+drop temporary table bestseller_list_keep;
+drop temporary table bestseller_list_first;
+drop temporary table bestseller_list_key;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS review_verdict;
+DROP INDEX review_fingerprint_index ON review;
+ALTER TABLE review DROP COLUMN fingerprint, DROP COLUMN screeningstatus;
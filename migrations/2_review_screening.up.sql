@@ -0,0 +1,20 @@
+alter table review
+	add fingerprint varchar(40) null,
+	add screeningstatus varchar(20) not null default 'passed';
+
+create index review_fingerprint_index
+	on review (fingerprint);
+
+create table review_verdict
+(
+	review_id varchar(36) not null,
+	rule varchar(50) not null,
+	verdict varchar(20) not null,
+	reason varchar(255) null,
+	createdtime datetime not null,
+	constraint review_verdict_pk
+		primary key (review_id, rule),
+	constraint review_verdict_review_id_fk
+		foreign key (review_id) references review (id)
+			on delete cascade
+);
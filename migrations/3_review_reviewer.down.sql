@@ -0,0 +1,3 @@
+DROP INDEX review_reviewer_id_index ON review;
+DROP INDEX review_book_id_reviewer_id_uindex ON review;
+ALTER TABLE review DROP COLUMN reviewer_id;
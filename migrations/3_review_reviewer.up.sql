@@ -0,0 +1,8 @@
+alter table review
+	add reviewer_id varchar(36) null after book_id;
+
+create unique index review_book_id_reviewer_id_uindex
+	on review (book_id, reviewer_id);
+
+create index review_reviewer_id_index
+	on review (reviewer_id);
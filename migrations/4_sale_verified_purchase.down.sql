@@ -0,0 +1,2 @@
+ALTER TABLE review DROP COLUMN verifiedpurchase;
+DROP TABLE IF EXISTS sale;
@@ -0,0 +1,22 @@
+create table sale
+(
+	id varchar(36) not null,
+	book_id varchar(36) not null,
+	customer_id varchar(36) null,
+	amount int not null,
+	createdtime datetime not null,
+	constraint sale_pk
+		primary key (id),
+	constraint sale_book_id_fk
+		foreign key (book_id) references book (id)
+			on delete cascade
+);
+
+create index sale_book_id_customer_id_index
+	on sale (book_id, customer_id);
+
+create index sale_createdtime_index
+	on sale (createdtime);
+
+alter table review
+	add verifiedpurchase tinyint(1) not null default 0 after reviewer_id;
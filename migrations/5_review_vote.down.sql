@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS review_vote;
+ALTER TABLE review DROP COLUMN helpfulcount, DROP COLUMN unhelpfulcount;
@@ -0,0 +1,17 @@
+alter table review
+	add helpfulcount int not null default 0,
+	add unhelpfulcount int not null default 0;
+
+create table review_vote
+(
+	review_id varchar(36) not null,
+	voter_id varchar(36) not null,
+	helpful tinyint(1) not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	constraint review_vote_pk
+		primary key (review_id, voter_id),
+	constraint review_vote_review_id_fk
+		foreign key (review_id) references review (id)
+			on delete cascade
+);
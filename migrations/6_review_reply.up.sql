@@ -0,0 +1,17 @@
+create table review_reply
+(
+	id varchar(36) not null
+		primary key,
+	review_id varchar(36) not null,
+	author_id varchar(36) not null,
+	description text not null,
+	createdtime datetime not null,
+	modifiedtime datetime not null,
+	version int not null,
+	constraint review_reply_review_id_fk
+		foreign key (review_id) references review (id)
+			on delete cascade
+);
+
+create index review_reply_review_id_index
+	on review_reply (review_id);
@@ -0,0 +1,13 @@
+create table review_revision
+(
+	review_id varchar(36) not null,
+	version int not null,
+	score int not null,
+	description text null,
+	modifiedtime datetime not null,
+	constraint review_revision_pk
+		primary key (review_id, version),
+	constraint review_revision_review_id_fk
+		foreign key (review_id) references review (id)
+			on delete cascade
+);
@@ -0,0 +1 @@
+ALTER TABLE review DROP COLUMN sentiment;
@@ -0,0 +1,2 @@
+alter table review
+	add sentiment double null;
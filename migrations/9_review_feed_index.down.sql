@@ -0,0 +1 @@
+DROP INDEX review_createdtime_id_index ON review;
@@ -0,0 +1,2 @@
+create index review_createdtime_id_index
+	on review (createdtime, id);
@@ -2,34 +2,293 @@ package model
 
 import "time"
 
+// Stock movement reasons
+const (
+	MovementOpening = "opening" //stock on hand when movements started to be recorded
+	MovementInitial = "initial"
+	MovementFill    = "fill"
+	MovementSale    = "sale"
+	MovementAdjust  = "adjust"
+)
+
+// Restock statuses
+const (
+	RestockDraft     = "draft"
+	RestockOrdered   = "ordered"
+	RestockReceived  = "received"
+	RestockCancelled = "cancelled"
+)
+
+// Report run statuses
+const (
+	RunRunning = "running"
+	RunDone    = "done"
+	RunFailed  = "failed"
+)
+
+// Roles an author can have on a book
+const (
+	RoleAuthor      = "author"
+	RoleTranslator  = "translator"
+	RoleIllustrator = "illustrator"
+	RoleEditor      = "editor"
+)
+
+// Book formats a sale can be made in
+const (
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+)
+
 // Book model holding book data
 type Book struct {
-	ID             string
-	Title          string
-	Synopsis       string
-	ISBN10         string
-	ISBN13         string
-	Category       string
-	Language       string
-	Publisher      string
-	Edition        string
-	SoldAmount     int
-	CurrentAmount  int
-	PaperbackPrice *float64
-	EbookPrice     *float64
-	AverageScore   *float64
-	CreatedTime    *time.Time
-	ModifiedTime   *time.Time
-	Version        int //for optimistic locking
+	ID                       string
+	WorkID                   string //empty when the book is not grouped with other editions
+	Title                    string
+	Synopsis                 string
+	ISBN10                   string
+	ISBN13                   string
+	Category                 string         //name of the first category, kept for clients of flat categories
+	Categories               []BookCategory //first is the main category, nil when not loaded or not to be changed
+	Language                 string
+	PublisherID              string
+	Publisher                string      //name of the publisher
+	SeriesID                 string      //empty when the book is not part of a series
+	Series                   string      //name of the series
+	SeriesVolume             *float64    //position in the series, like 2.5 for a side story, nil when unnumbered
+	PreviousInSeries         *SeriesBook //book with the nearest lower volume, only loaded for single book
+	NextInSeries             *SeriesBook //book with the nearest higher volume, only loaded for single book
+	Edition                  string
+	SoldAmount               int
+	CurrentAmount            int
+	PaperbackPrice           *float64
+	EbookPrice               *float64
+	CostPrice                *float64 //unit cost of stock, nil when unknown
+	AverageScore             *float64
+	VerifiedAverageScore     *float64     //average of reviews from customers who bought the book
+	WorkAverageScore         *float64     //average of reviews of every edition of the work
+	WorkVerifiedAverageScore *float64     //verified average of reviews of every edition of the work
+	Authors                  []BookAuthor //in credit order, nil when not loaded or not to be changed
+	Tags                     []string     //in alphabetical order, changed by attaching and detaching tags
+	CreatedTime              *time.Time
+	ModifiedTime             *time.Time
+	Version                  int //for optimistic locking
+}
+
+// Author model holding a person credited on books
+type Author struct {
+	ID           string
+	Name         string
+	SortName     string //name used to sort authors, like "Pike, Rob"
+	Bio          string
+	CreatedTime  *time.Time
+	ModifiedTime *time.Time
+	Version      int //for optimistic locking
+}
+
+// Publisher model holding a publisher of books
+type Publisher struct {
+	ID           string
+	Name         string
+	BookCount    int //books of the publisher
+	CreatedTime  *time.Time
+	ModifiedTime *time.Time
+	Version      int //for optimistic locking
+}
+
+// Work model holding a work whose editions are books, like 1st and 2nd edition or
+// translations of the same book
+type Work struct {
+	ID                   string
+	Title                string
+	EditionCount         int
+	ReviewCount          int      //reviews of all editions
+	AverageScore         *float64 //average of reviews of all editions
+	VerifiedAverageScore *float64 //average of verified reviews of all editions
+	Editions             []Book   //nil when not loaded
+	EditionsTruncated    bool     //true when the work has more editions than loaded
+	CreatedTime          *time.Time
+	ModifiedTime         *time.Time
+	Version              int //for optimistic locking
+}
+
+// Series model holding a series of books like a manga or light novel series
+type Series struct {
+	ID           string
+	Name         string
+	BookCount    int //books in the series
+	CreatedTime  *time.Time
+	ModifiedTime *time.Time
+	Version      int //for optimistic locking
+}
+
+// SeriesBook model holding a book linked from another book of its series
+type SeriesBook struct {
+	ID     string
+	Title  string
+	Volume *float64
+}
+
+// Category model holding a node of the category tree
+type Category struct {
+	ID           string
+	ParentID     string //empty for top level category
+	Slug         string
+	Name         string
+	Names        map[string]string //localized names by locale
+	Path         string            //slugs from top level category down to this one, like "programming/go"
+	Depth        int               //0 for top level category
+	BookCount    int               //books in the category or any category below it
+	CreatedTime  *time.Time
+	ModifiedTime *time.Time
+	Version      int //for optimistic locking
+}
+
+// Tag model holding a tag with number of books it is attached to
+type Tag struct {
+	Name      string
+	BookCount int
+}
+
+// BookCategory model holding a category a book belongs to
+type BookCategory struct {
+	CategoryID string
+	Slug       string
+	Name       string
+}
+
+// BookAuthor model holding an author credited on a book in a role
+type BookAuthor struct {
+	AuthorID string
+	Name     string
+	SortName string
+	Role     string
 }
 
 // Review model holding book's riview data
 type Review struct {
-	ID           string
+	ID               string
+	Score            int
+	Description      string
+	BookID           string
+	ReviewerID       string
+	VerifiedPurchase bool   //reviewer bought the book
+	Fingerprint      string //normalized description hash for duplicate detection
+	ScreeningStatus  string
+	Verdicts         []ScreeningVerdict
+	HelpfulCount     int
+	UnhelpfulCount   int
+	HelpfulnessScore float64  //lower bound of helpful ratio, see service
+	Sentiment        *float64 //sentiment of description from -1 to 1, nil for reviews before scoring
+	Replies          []Reply
+	Book             *BookSummary //set only on cross-book review feed
+	CreatedTime      *time.Time
+	ModifiedTime     *time.Time
+	Version          int //for optimistic locking
+}
+
+// BookSummary model holding book fields shown next to a review
+type BookSummary struct {
+	ID       string
+	Title    string
+	Category string
+}
+
+// ReviewRevision model holding review as it was before an edit
+type ReviewRevision struct {
+	ReviewID     string
+	Version      int
 	Score        int
 	Description  string
+	ModifiedTime *time.Time //when this revision was written
+}
+
+// Reply model holding publisher or staff reply to a review
+type Reply struct {
+	ID           string
+	ReviewID     string
+	AuthorID     string
+	Description  string
+	CreatedTime  *time.Time
+	ModifiedTime *time.Time
+	Version      int //for optimistic locking
+}
+
+// ScreeningVerdict model holding result of one screening rule on a review
+type ScreeningVerdict struct {
+	Rule    string
+	Verdict string
+	Reason  string
+}
+
+// ReviewVote model holding a helpful or not helpful vote on review
+type ReviewVote struct {
+	ReviewID string
+	VoterID  string
+	Helpful  bool
+}
+
+// Sale model holding a sale of book
+type Sale struct {
+	ID          string
+	BookID      string
+	CustomerID  string
+	Format      string
+	Amount      int
+	UnitPrice   *float64 //price of the format at sale time
+	CreatedTime *time.Time
+}
+
+// StockMovement model holding a change of book stock on hand
+type StockMovement struct {
+	ID          string
+	BookID      string
+	Quantity    int //negative when stock goes out
+	Reason      string
+	CreatedTime *time.Time
+}
+
+// Restock model holding quantity of a book to be ordered from its publisher
+type Restock struct {
+	ID           string
 	BookID       string
+	Quantity     int
+	Status       string
 	CreatedTime  *time.Time
 	ModifiedTime *time.Time
 	Version      int //for optimistic locking
-}
\ No newline at end of file
+}
+
+// ReportRun model holding a scheduled report generation and its archived output
+type ReportRun struct {
+	ID            string
+	Job           string
+	Report        string
+	Format        string
+	ScheduledTime *time.Time //unique per job so only one instance runs it
+	StartedTime   *time.Time
+	FinishedTime  *time.Time
+	Status        string
+	File          string //file name of output in directory of the job
+	Error         string
+}
+
+// BestsellerList model holding weekly snapshot of top selling books of a category
+type BestsellerList struct {
+	Category    string
+	Period      *time.Time //Monday the week starts
+	CreatedTime *time.Time
+	Entries     []BestsellerEntry
+}
+
+// BestsellerEntry model holding rank of a book on a bestseller list
+type BestsellerEntry struct {
+	Period       *time.Time
+	Rank         int
+	BookID       string
+	Title        string
+	Amount       int  //units of all formats sold within the week
+	PreviousRank *int //rank on list of previous week, nil when the book was not on it
+	WeeksOnList  int  //weeks on list of the category so far, including this one
+	PeakRank     int
+}
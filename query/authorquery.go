@@ -0,0 +1,7 @@
+package query
+
+type AuthorQuery struct {
+	Limit  int
+	Offset int
+	Name   string //part of name or sort name
+}
@@ -1,8 +1,14 @@
 package query
 
 type BookQuery struct {
-	Limit  int
-	Offset int
-	SortBy string
-	Title  string
+	Limit    int
+	Offset   int
+	SortBy   string
+	Title    string
+	Author   string   //author id or part of author name
+	Category string   //category id, slug or name, books in categories below it included
+	AnyTags  []string //books with at least one of the tags
+	AllTags  []string //books with every one of the tags
+	SeriesID string
+	WorkID   string
 }
@@ -0,0 +1,5 @@
+package query
+
+type CategoryQuery struct {
+	Root string //id of category whose subtree is listed, all categories when empty
+}
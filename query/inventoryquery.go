@@ -0,0 +1,15 @@
+package query
+
+// InventoryAgingQuery define idle period of dead stock and sales window used for rates
+type InventoryAgingQuery struct {
+	Days   int //book with stock and no paperback sale within days is dead stock
+	Window int //days of sales history averaged for daily sales and contribution
+}
+
+// ReorderQuery define forecast method and periods used for reorder suggestions
+type ReorderQuery struct {
+	Method   string //average or smoothing
+	History  int    //complete weeks of sales the forecast is based on
+	Weeks    int    //weeks of demand to cover after restock arrives
+	LeadTime int    //days from order until restock arrives
+}
@@ -0,0 +1,7 @@
+package query
+
+type PublisherQuery struct {
+	Limit  int //0 means no limit
+	Offset int
+	Name   string //part of name
+}
@@ -0,0 +1,19 @@
+package query
+
+import "time"
+
+// ReviewFeedQuery filter reviews across all books, newest first
+type ReviewFeedQuery struct {
+	MinScore        int
+	MaxScore        int
+	From            *time.Time //inclusive
+	To              *time.Time //exclusive
+	Category        string
+	ScreeningStatus string
+	Cursor          string
+	Limit           int
+
+	// position after the last review of previous page, decoded from Cursor
+	AfterTime *time.Time
+	AfterID   string
+}
@@ -0,0 +1,8 @@
+package query
+
+type ReviewQuery struct {
+	BookID           string
+	WorkID           string //reviews of every edition of the work, used instead of BookID
+	VerifiedPurchase *bool
+	SortBy           string
+}
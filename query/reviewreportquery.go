@@ -0,0 +1,12 @@
+package query
+
+import "time"
+
+// ReviewReportQuery define reviews and books shown in review analytics report
+type ReviewReportQuery struct {
+	From       *time.Time //inclusive
+	To         *time.Time //exclusive
+	MinReviews int        //reviews a book needs to be top or worst rated
+	Limit      int        //books in top and worst rated lists
+	Bucket     string     //day, week or month of review volume
+}
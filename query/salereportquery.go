@@ -0,0 +1,19 @@
+package query
+
+import "time"
+
+// SaleReportQuery filter sale events used by sale reports
+type SaleReportQuery struct {
+	From      *time.Time //inclusive
+	To        *time.Time //exclusive
+	Category  string
+	Publisher string
+	Limit     int //0 means no limit
+}
+
+// RevenueReportQuery group revenue of sales by book attribute and time bucket
+type RevenueReportQuery struct {
+	SaleReportQuery
+	GroupBy string //book, category, publisher or language
+	Bucket  string //day, week, month or empty for whole range
+}
@@ -0,0 +1,7 @@
+package query
+
+type SeriesQuery struct {
+	Limit  int
+	Offset int
+	Name   string //part of name
+}
@@ -0,0 +1,7 @@
+package query
+
+type TagQuery struct {
+	Limit    int    //0 means no limit
+	Prefix   string //start of tag name
+	MinCount int    //least number of books a listed tag is attached to
+}
@@ -0,0 +1,7 @@
+package query
+
+type WorkQuery struct {
+	Limit  int
+	Offset int
+	Title  string //part of title
+}
@@ -1,11 +1,164 @@
 package report
 
+import "time"
+
 type BestSallerBook struct {
-	Ttile           string
+	BookID          string
+	Ttile           string `export:"Title"`
 	TotalSaleAmount int
 }
 
+// BestSallerCategory is units sold in a category, books of categories below it included
 type BestSallerCategory struct {
+	CategoryID      string
+	ParentID        string
 	Category        string
 	TotalSaleAmount int
 }
+
+// SentimentMismatch is a review which star score and text sentiment disagree
+type SentimentMismatch struct {
+	BookID    string  `json:"book_id"`
+	Title     string  `json:"title"`
+	ReviewID  string  `json:"review_id"`
+	Score     int     `json:"score"`
+	Sentiment float64 `json:"sentiment"`
+	Gap       float64 `json:"gap"`
+}
+
+// Revenue is gross sales value of a group within a period, at price in effect at sale time
+type Revenue struct {
+	Period           string  `json:"period"` //first day of bucket, empty when not bucketed
+	Group            string  `json:"group"`
+	GroupName        string  `json:"group_name"`
+	PaperbackAmount  int     `json:"paperback_amount"`
+	PaperbackRevenue float64 `json:"paperback_revenue"`
+	EbookAmount      int     `json:"ebook_amount"`
+	EbookRevenue     float64 `json:"ebook_revenue"`
+	TotalRevenue     float64 `json:"total_revenue"`
+}
+
+// InventoryBook is stock on hand of a book valued at unit cost, retail paperback
+// price is used when cost is unknown
+type InventoryBook struct {
+	BookID      string   `json:"book_id"`
+	Title       string   `json:"title"`
+	Category    string   `json:"category"`
+	Publisher   string   `json:"publisher"`
+	Amount      int      `json:"amount"`
+	CostPrice   *float64 `json:"cost_price"`
+	RetailPrice *float64 `json:"retail_price"`
+	UnitCost    float64  `json:"unit_cost"`
+	CostSource  string   `json:"cost_source"` //cost, retail or none
+	Value       float64  `json:"value"`
+}
+
+// InventorySubtotal is stock on hand and value of books sharing a category or publisher
+type InventorySubtotal struct {
+	Name   string  `json:"name"`
+	Amount int     `json:"amount"`
+	Value  float64 `json:"value"`
+}
+
+// InventoryValuation is value of all stock on hand at a point in time
+type InventoryValuation struct {
+	AsOf        string              `json:"as_of"`
+	Books       []InventoryBook     `json:"books"`
+	Categories  []InventorySubtotal `json:"categories"`
+	Publishers  []InventorySubtotal `json:"publishers"`
+	TotalAmount int                 `json:"total_amount"`
+	TotalValue  float64             `json:"total_value"`
+}
+
+// StockAging is sales activity of a book against its stock on hand
+type StockAging struct {
+	BookID            string     `json:"book_id"`
+	Title             string     `json:"title"`
+	Category          string     `json:"category"`
+	CurrentAmount     int        `json:"current_amount"`
+	SoldAmount        int        `json:"sold_amount"`
+	LastSaleTime      *time.Time `json:"last_sale_time"` //last paperback sale, nil when never sold
+	IdleDays          int        `json:"idle_days"`      //days since last paperback sale or since created
+	WindowSoldAmount  int        `json:"window_sold_amount"`
+	WindowRevenue     float64    `json:"window_revenue"`
+	AverageDailySales float64    `json:"average_daily_sales"`
+	DaysOfCover       *float64   `json:"days_of_cover"` //nil when nothing sold within window
+	Contribution      float64    `json:"contribution"`  //share of catalogue revenue within window
+	Class             string     `json:"class"`         //A, B or C
+	Dead              bool       `json:"dead"`
+	CreatedTime       *time.Time `json:"-"`
+}
+
+// InventoryAging is dead stock list and ABC classification of the whole catalogue
+type InventoryAging struct {
+	Days      int          `json:"days"`
+	Window    int          `json:"window"`
+	DeadStock []StockAging `json:"dead_stock"`
+	Books     []StockAging `json:"books"`
+}
+
+// WeeklySale is paperback units of a book sold within week starting Monday,
+// week is nil for book without sales
+type WeeklySale struct {
+	BookID        string
+	Title         string
+	CurrentAmount int
+	Week          *string
+	Amount        int
+}
+
+// ReorderSuggestion is quantity to restock so forecast demand during lead time and
+// following weeks is covered
+type ReorderSuggestion struct {
+	BookID          string  `json:"book_id"`
+	Title           string  `json:"title"`
+	CurrentAmount   int     `json:"current_amount"`
+	PendingRestock  int     `json:"pending_restock"` //quantity in draft restocks
+	WeeklyForecast  float64 `json:"weekly_forecast"`
+	LeadTimeDemand  float64 `json:"lead_time_demand"`
+	CoverDemand     float64 `json:"cover_demand"`
+	SuggestedAmount int     `json:"suggested_amount"`
+}
+
+// ReorderSuggestions is books which need restock with parameters of their forecast
+type ReorderSuggestions struct {
+	Method      string              `json:"method"`
+	History     int                 `json:"history"`
+	Weeks       int                 `json:"weeks"`
+	LeadTime    int                 `json:"lead_time"`
+	Suggestions []ReorderSuggestion `json:"suggestions"`
+}
+
+// BookScore is review count and average score of a book
+type BookScore struct {
+	BookID       string  `json:"book_id"`
+	Title        string  `json:"title"`
+	Category     string  `json:"category"`
+	Publisher    string  `json:"publisher"`
+	ReviewCount  int     `json:"review_count"`
+	AverageScore float64 `json:"average_score"` //0 when not reviewed
+}
+
+// ScoreSubtotal is average score of all reviews of books sharing a category or publisher
+type ScoreSubtotal struct {
+	Name         string  `json:"name"`
+	ReviewCount  int     `json:"review_count"`
+	AverageScore float64 `json:"average_score"`
+}
+
+// ReviewVolume is number of reviews created within a period
+type ReviewVolume struct {
+	Period       string  `json:"period"`
+	ReviewCount  int     `json:"review_count"`
+	AverageScore float64 `json:"average_score"`
+}
+
+// ReviewAnalytics is aggregate view of reviews across the catalogue
+type ReviewAnalytics struct {
+	TopRated   []BookScore     `json:"top_rated"`
+	WorstRated []BookScore     `json:"worst_rated"`
+	Categories []ScoreSubtotal `json:"categories"`
+	Publishers []ScoreSubtotal `json:"publishers"`
+	Volume     []ReviewVolume  `json:"volume"`
+	Unreviewed []BookScore     `json:"unreviewed"`
+}
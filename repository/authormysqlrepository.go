@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// mysqlRowReferenced is mysql error number for foreign key violation on delete
+const mysqlRowReferenced = 1451
+
+const authorColumns = `id, name, sortname, IFNULL(bio, ''), createdtime, modifiedtime, version`
+
+// likeEscaper escape wildcards of LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type MysqlAuthorRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlAuthorRepository create new mysql author repository
+func NewMysqlAuthorRepository(db *sql.DB) *MysqlAuthorRepository {
+	repo := new(MysqlAuthorRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlAuthorRepository) GetAuthor(id string) (*model.Author, error) {
+	sql := `SELECT ` + authorColumns + ` FROM author WHERE id = ?`
+	a, err := scanAuthor(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get author id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("author id %s is not found", id)}
+	}
+	return &a, nil
+}
+
+// QueryAuthor return authors ordered by sort name
+func (r *MysqlAuthorRepository) QueryAuthor(q query.AuthorQuery) ([]model.Author, error) {
+	authors := []model.Author{}
+	where, args := composeAuthorWhere(q)
+	args = append(args, q.Limit, q.Offset)
+	sql := `SELECT ` + authorColumns + ` FROM author` + where + ` ORDER BY sortname, id LIMIT ? OFFSET ?`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query authors error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		a, err := scanAuthor(result)
+		if err != nil {
+			log.Error("query authors error", err.Error())
+			return nil, err
+		}
+		authors = append(authors, a)
+	}
+	return authors, nil
+}
+
+func (r *MysqlAuthorRepository) CountAuthor(q query.AuthorQuery) (int, error) {
+	where, args := composeAuthorWhere(q)
+	sql := "SELECT COUNT(id) as count FROM author" + where
+	var c int
+	if err := r.db.QueryRow(sql, args...).Scan(&c); err != nil {
+		log.Error("count author error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+func (r *MysqlAuthorRepository) CreateAuthor(a model.Author) (*model.Author, error) {
+	sql := `INSERT INTO author (id, name, sortname, bio, createdtime, modifiedtime, version)
+			values(?, ?, ?, ?, ?, ?, ?)`
+	now := time.Now()
+	a.ID = uuid.New().String()
+	a.CreatedTime = &now
+	a.ModifiedTime = &now
+	a.Version = 1
+	_, err := r.db.Exec(sql, a.ID, a.Name, a.SortName, nullString(a.Bio), a.CreatedTime, a.ModifiedTime, a.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("create author %s error, %s", a.Name, err.Error()))
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *MysqlAuthorRepository) UpdateAuthor(a model.Author) (*model.Author, error) {
+	sql := `UPDATE author SET name = ?, sortname = ?, bio = ?, modifiedtime = ?, version = ?
+			WHERE id = ? AND version = ?`
+	res, err := r.db.Exec(sql, a.Name, a.SortName, nullString(a.Bio), time.Now(), a.Version+1, a.ID, a.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("update author id %s error, %s", a.ID, err.Error()))
+		return nil, err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	return &a, nil
+}
+
+// DeleteAuthor delete the author, ConflictError is returned while books credit the author
+func (r *MysqlAuthorRepository) DeleteAuthor(id string) error {
+	_, err := r.db.Exec("DELETE FROM author WHERE id = ?", id)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlRowReferenced {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("author id %s is credited on books", id)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete author id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func composeAuthorWhere(q query.AuthorQuery) (string, []interface{}) {
+	if q.Name == "" {
+		return "", []interface{}{}
+	}
+	pattern := "%" + likeEscaper.Replace(q.Name) + "%"
+	return " WHERE name LIKE ? OR sortname LIKE ?", []interface{}{pattern, pattern}
+}
+
+func scanAuthor(row rowScanner) (model.Author, error) {
+	a := model.Author{}
+	err := row.Scan(&a.ID, &a.Name, &a.SortName, &a.Bio, &a.CreatedTime, &a.ModifiedTime, &a.Version)
+	return a, err
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+const bestsellerEntryColumns = `e.period, e.listrank, e.book_id, b.title, e.amount, e.previousrank,
+				e.weeksonlist, e.peakrank`
+
+type MysqlBestsellerRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlBestsellerRepository create new mysql bestseller list repository
+func NewMysqlBestsellerRepository(db *sql.DB) *MysqlBestsellerRepository {
+	repo := new(MysqlBestsellerRepository)
+	repo.db = db
+	return repo
+}
+
+// GetCategoryID return id of the category given by id, slug or name, lists are kept
+// by category id so they are found whichever way the category is given
+func (r *MysqlBestsellerRepository) GetCategoryID(category string) (string, error) {
+	var id string
+	err := r.db.QueryRow(`SELECT id FROM category WHERE id = ? OR slug = ? OR name = ?
+			ORDER BY id <> ?, slug <> ?, id LIMIT 1`, category, category, category, category, category).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", &bserror.NotFoundError{Msg: fmt.Sprintf("category %s is not found", category)}
+	}
+	if err != nil {
+		log.Error("query category id error, ", err.Error())
+		return "", err
+	}
+	return id, nil
+}
+
+// GetCategoryIDs return id of every category
+func (r *MysqlBestsellerRepository) GetCategoryIDs() ([]string, error) {
+	ids := []string{}
+	result, err := r.db.Query(`SELECT id FROM category ORDER BY id`)
+	if err != nil {
+		log.Error("query category ids error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var id string
+		if err := result.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetFirstSaleDay return day of first sale of books in the category or categories
+// below it, nil when none sold
+func (r *MysqlBestsellerRepository) GetFirstSaleDay(category string) (*time.Time, error) {
+	cond, args := categoryCondition(category)
+	sql := `SELECT MIN(d.saledate) FROM sale_daily d JOIN book b ON b.id = d.book_id WHERE ` + cond
+	var first *time.Time
+	if err := r.db.QueryRow(sql, args...).Scan(&first); err != nil {
+		log.Error("query first sale error, ", err.Error())
+		return nil, err
+	}
+	return first, nil
+}
+
+// GetBestsellerPeriods return periods the category already has list of, latest first
+func (r *MysqlBestsellerRepository) GetBestsellerPeriods(category string) ([]time.Time, error) {
+	periods := []time.Time{}
+	sql := `SELECT period FROM bestseller_list WHERE category = ? ORDER BY period DESC`
+	result, err := r.db.Query(sql, category)
+	if err != nil {
+		log.Error("query bestseller periods error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var p time.Time
+		if err := result.Scan(&p); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// GetCategorySales return books of the category or categories below it by units sold
+// from from until to, most sold first, books without sales are left out
+func (r *MysqlBestsellerRepository) GetCategorySales(category string, from time.Time, to time.Time,
+	limit int) ([]model.BestsellerEntry, error) {
+	entries := []model.BestsellerEntry{}
+	cond, args := categoryCondition(category)
+	args = append(args, from, to)
+	sql := `SELECT b.id, b.title, SUM(d.amount) as totalamount
+			FROM sale_daily d JOIN book b ON b.id = d.book_id
+			WHERE ` + cond + ` AND d.saledate >= ? AND d.saledate < ?
+			GROUP BY b.id, b.title HAVING totalamount > 0
+			ORDER BY totalamount DESC, b.title, b.id` + composeLimit(limit, &args)
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query category sales error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		e := model.BestsellerEntry{}
+		if err := result.Scan(&e.BookID, &e.Title, &e.Amount); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// GetLastBestsellerEntries return latest entry of each given book on lists of the
+// category before given period
+func (r *MysqlBestsellerRepository) GetLastBestsellerEntries(category string, before time.Time,
+	bookIDs []string) ([]model.BestsellerEntry, error) {
+	entries := []model.BestsellerEntry{}
+	if len(bookIDs) == 0 {
+		return entries, nil
+	}
+	args := []interface{}{category, before}
+	for _, id := range bookIDs {
+		args = append(args, id)
+	}
+	args = append(args, category)
+	sql := `SELECT ` + bestsellerEntryColumns + `
+			FROM bestseller_entry e JOIN book b ON b.id = e.book_id
+			JOIN (SELECT book_id, MAX(period) as period FROM bestseller_entry
+				WHERE category = ? AND period < ? AND book_id IN (?` + strings.Repeat(", ?", len(bookIDs)-1) + `)
+				GROUP BY book_id) l ON l.book_id = e.book_id AND l.period = e.period
+			WHERE e.category = ?`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query bestseller entries error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		e, err := scanBestsellerEntry(result)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// GetBestsellerList return list of the category for the period with its entries by rank
+func (r *MysqlBestsellerRepository) GetBestsellerList(category string, period time.Time) (*model.BestsellerList, error) {
+	list := model.BestsellerList{Category: category, Entries: []model.BestsellerEntry{}}
+	sql := `SELECT period, createdtime FROM bestseller_list WHERE category = ? AND period = ?`
+	if err := r.db.QueryRow(sql, category, period).Scan(&list.Period, &list.CreatedTime); err != nil {
+		msg := fmt.Sprintf("bestseller list of %s for %s is not found", category, period.Format("2006-01-02"))
+		return nil, &bserror.NotFoundError{Msg: msg}
+	}
+
+	sql = `SELECT ` + bestsellerEntryColumns + `
+			FROM bestseller_entry e JOIN book b ON b.id = e.book_id
+			WHERE e.category = ? AND e.period = ? ORDER BY e.listrank`
+	result, err := r.db.Query(sql, category, period)
+	if err != nil {
+		log.Error("query bestseller entries error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		e, err := scanBestsellerEntry(result)
+		if err != nil {
+			return nil, err
+		}
+		list.Entries = append(list.Entries, e)
+	}
+	return &list, nil
+}
+
+// CreateBestsellerList save the list with its entries in one transaction,
+// ConflictError is returned when the category already has list for the period
+func (r *MysqlBestsellerRepository) CreateBestsellerList(list model.BestsellerList) (*model.BestsellerList, error) {
+	now := time.Now()
+	list.CreatedTime = &now
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO bestseller_list (category, period, createdtime) values(?, ?, ?)`,
+		list.Category, list.Period, list.CreatedTime)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		tx.Rollback()
+		msg := fmt.Sprintf("bestseller list of %s for %s already exists", list.Category, list.Period.Format("2006-01-02"))
+		return nil, &bserror.ConflictError{Msg: msg}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("create bestseller list of %s error, %s", list.Category, err.Error()))
+		tx.Rollback()
+		return nil, err
+	}
+	for _, e := range list.Entries {
+		_, err := tx.Exec(`INSERT INTO bestseller_entry (category, period, listrank, book_id, amount, previousrank,
+				weeksonlist, peakrank) values(?, ?, ?, ?, ?, ?, ?, ?)`,
+			list.Category, list.Period, e.Rank, e.BookID, e.Amount, e.PreviousRank, e.WeeksOnList, e.PeakRank)
+		if err != nil {
+			log.Error(fmt.Sprintf("create bestseller entry of book id %s error, %s", e.BookID, err.Error()))
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("create bestseller list of %s error, %s", list.Category, err.Error()))
+		return nil, err
+	}
+	return &list, nil
+}
+
+func scanBestsellerEntry(row rowScanner) (model.BestsellerEntry, error) {
+	e := model.BestsellerEntry{}
+	err := row.Scan(&e.Period, &e.Rank, &e.BookID, &e.Title, &e.Amount, &e.PreviousRank, &e.WeeksOnList, &e.PeakRank)
+	return e, err
+}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestGetBestsellerCategoryID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`^SELECT id FROM category WHERE id = \? OR slug = \? OR name = \?(.+)`).
+		WithArgs("programming", "programming", "programming", "programming", "programming").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("cat-1"))
+	mock.ExpectQuery(`^SELECT id FROM category (.+)`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := NewMysqlBestsellerRepository(db)
+	id, err := repo.GetCategoryID("programming")
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "cat-1", id)
+
+	_, err = repo.GetCategoryID("cooking")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetLastBestsellerEntries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	before := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	period := before.AddDate(0, 0, -7)
+	rows := sqlmock.NewRows([]string{"period", "listrank", "book_id", "title", "amount", "previousrank",
+		"weeksonlist", "peakrank"}).
+		AddRow(period, 3, "a", "Go in Action", 12, nil, 1, 3)
+	mock.ExpectQuery(`^SELECT (.+) FROM bestseller_entry e JOIN book b (.+) `+
+		`WHERE category = \? AND period < \? AND book_id IN \(\?, \?\) GROUP BY book_id(.+)`).
+		WithArgs("Programming", before, "a", "b", "Programming").WillReturnRows(rows)
+
+	repo := NewMysqlBestsellerRepository(db)
+	res, err := repo.GetLastBestsellerEntries("Programming", before, []string{"a", "b"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(res))
+	assert.Equal(t, 3, res[0].Rank)
+	assert.Nil(t, res[0].PreviousRank)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetMissingBestsellerList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	period := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`^SELECT period, createdtime FROM bestseller_list (.+)`).
+		WithArgs("Programming", period).WillReturnRows(sqlmock.NewRows([]string{"period", "createdtime"}))
+
+	repo := NewMysqlBestsellerRepository(db)
+	res, err := repo.GetBestsellerList("Programming", period)
+
+	assert.Nil(t, res)
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateBestsellerList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	period := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	previous := 2
+	list := model.BestsellerList{Category: "Programming", Period: &period, Entries: []model.BestsellerEntry{
+		{Rank: 1, BookID: "a", Amount: 20, PreviousRank: &previous, WeeksOnList: 3, PeakRank: 1},
+	}}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO bestseller_list (.+)").
+		WithArgs("Programming", period, anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO bestseller_entry (.+)").
+		WithArgs("Programming", period, 1, "a", 20, &previous, 3, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlBestsellerRepository(db)
+	created, err := repo.CreateBestsellerList(list)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotNil(t, created.CreatedTime, "created time must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDuplicatedBestsellerList(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	period := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO bestseller_list (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlBestsellerRepository(db)
+	created, err := repo.CreateBestsellerList(model.BestsellerList{Category: "Programming", Period: &period})
+
+	assert.Nil(t, created)
+	assert.IsType(t, &bserror.ConflictError{}, err, "should get conflict error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -3,16 +3,51 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"github.com/labstack/gommon/log"
 	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
 	"github.com/tsongpon/backend-challenge-2019/query"
-	"github.com/tsongpon/backend-challenge-2019/report"
 )
 
+// averageScoreColumns compute average scores of book b from its review summary rs
+const averageScoreColumns = `rs.scoresum / NULLIF(rs.reviewcount, 0) as averagescore,
+				rs.verifiedscoresum / NULLIF(rs.verifiedcount, 0) as verifiedaveragescore`
+
+// workScoreColumns compute average scores of every edition of the work of book b,
+// null when the book has no work
+const workScoreColumns = `(SELECT SUM(ws.scoresum) / NULLIF(SUM(ws.reviewcount), 0)
+					FROM book e JOIN book_review_stat ws ON ws.book_id = e.id WHERE e.work_id = b.work_id),
+				(SELECT SUM(ws.verifiedscoresum) / NULLIF(SUM(ws.verifiedcount), 0)
+					FROM book e JOIN book_review_stat ws ON ws.book_id = e.id WHERE e.work_id = b.work_id)`
+
+// bookSortColumns map sort names books can be ordered by to columns, other names
+// are rejected as they would be put into the query as is
+var bookSortColumns = map[string]string{
+	"id":             "b.id",
+	"title":          "b.title",
+	"isbn10":         "b.isbn10",
+	"isbn13":         "b.isbn13",
+	"language":       "b.language",
+	"edition":        "b.edition",
+	"soldamount":     "b.soldamount",
+	"currentamount":  "b.currentamount",
+	"paperbackprice": "b.paperbackprice",
+	"ebookprice":     "b.ebookprice",
+	"costprice":      "b.costprice",
+	"createdtime":    "b.createdtime",
+	"modifiedtime":   "b.modifiedtime",
+	"version":        "b.version",
+	"publisher":      "p.name",
+	"category":       "c.name",
+	"series":         "b.seriesvolume IS NULL, b.seriesvolume, b.title",
+}
+
 type MysqlBookRepository struct {
 	db *sql.DB
 }
@@ -27,133 +62,332 @@ func NewMysqlBookRepository(db *sql.DB) *MysqlBookRepository {
 // GetBook return book by given ID
 func (r *MysqlBookRepository) GetBook(id string) (*model.Book, error) {
 	sql := `SELECT 
-				b.id, title, synopsis, isbn10, isbn13, language, category, publisher, 
-				edition, soldamount, currentamount, paperbackprice, ebookprice, 
-				b.createdtime, b.modifiedtime, b.version, avg(r.score) as averagescore
-			FROM book b left join review r on b.id = r.book_id 
-			WHERE b.id = ? GROUP BY b.id`
+				b.id, IFNULL(b.work_id, ''), title, synopsis, isbn10, isbn13, language, b.publisher_id, p.name,
+				IFNULL(b.series_id, ''), IFNULL(s.name, ''), b.seriesvolume,
+				edition, soldamount, currentamount, paperbackprice, ebookprice, costprice,
+				b.createdtime, b.modifiedtime, b.version, ` + averageScoreColumns + `, ` + workScoreColumns + `
+			FROM book b join publisher p on p.id = b.publisher_id
+				left join series s on s.id = b.series_id
+				left join book_review_stat rs on b.id = rs.book_id 
+			WHERE b.id = ?`
 	var b model.Book
-	err := r.db.QueryRow(sql, id).Scan(&b.ID, &b.Title, &b.Synopsis, &b.ISBN10, &b.ISBN13, &b.Language,
-		&b.Category, &b.Publisher, &b.Edition, &b.SoldAmount, &b.CurrentAmount, &b.PaperbackPrice,
-		&b.EbookPrice, &b.CreatedTime, &b.ModifiedTime, &b.Version, &b.AverageScore)
+	err := r.db.QueryRow(sql, id).Scan(&b.ID, &b.WorkID, &b.Title, &b.Synopsis, &b.ISBN10, &b.ISBN13, &b.Language,
+		&b.PublisherID, &b.Publisher, &b.SeriesID, &b.Series, &b.SeriesVolume, &b.Edition, &b.SoldAmount, &b.CurrentAmount, &b.PaperbackPrice,
+		&b.EbookPrice, &b.CostPrice, &b.CreatedTime, &b.ModifiedTime, &b.Version, &b.AverageScore, &b.VerifiedAverageScore,
+		&b.WorkAverageScore, &b.WorkVerifiedAverageScore)
 	if err != nil {
 		log.Error(fmt.Sprintf("get book id %s error, %s", id, err.Error()))
 		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("book id %s is not found", id)}
 	}
+	authors, err := r.bookAuthors([]string{b.ID})
+	if err != nil {
+		return nil, err
+	}
+	b.Authors = authors[b.ID]
+	categories, err := r.bookCategories([]string{b.ID})
+	if err != nil {
+		return nil, err
+	}
+	setCategories(&b, categories[b.ID])
+	tags, err := r.bookTags([]string{b.ID})
+	if err != nil {
+		return nil, err
+	}
+	b.Tags = tags[b.ID]
+	if err := r.seriesNeighbours(&b); err != nil {
+		return nil, err
+	}
 	return &b, nil
 }
 
-// CreateBook create new book in database
+// CreateBook create new book in database with its authors, categories and the stock
+// movement of its initial amount, in the same transaction
 func (r *MysqlBookRepository) CreateBook(b model.Book) (*model.Book, error) {
 	sql := `INSERT INTO book (
-			id, title, synopsis, isbn10, isbn13, language, publisher, category, edition, 
-			soldamount, currentamount, paperbackprice, ebookprice, createdtime, modifiedtime, version
+			id, work_id, title, synopsis, isbn10, isbn13, language, publisher_id, series_id, seriesvolume, edition, 
+			soldamount, currentamount, paperbackprice, ebookprice, costprice, createdtime, modifiedtime, version
 		) 
-		values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	stmt, err := r.db.Prepare(sql)
+		values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Error("prepare sql statement error, ", err.Error())
+		log.Error("begin transaction error, ", err.Error())
 		return nil, err
 	}
-	defer stmt.Close()
 	now := time.Now()
 	b.ID = uuid.New().String()
 	b.CreatedTime = &now
 	b.ModifiedTime = &now
-	_, err = stmt.Exec(b.ID, b.Title, b.Synopsis, b.ISBN10, b.ISBN13, b.Language, b.Publisher, b.Category, b.Edition,
-		b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CreatedTime, b.ModifiedTime, 1)
+	_, err = tx.Exec(sql, b.ID, nullString(b.WorkID), b.Title, b.Synopsis, b.ISBN10, b.ISBN13, b.Language, b.PublisherID,
+		nullString(b.SeriesID), b.SeriesVolume, b.Edition,
+		b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CostPrice, b.CreatedTime, b.ModifiedTime, 1)
 
 	if err != nil {
+		log.Error("create book id ", b.ID, "error, ", err.Error())
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertBookAuthors(tx, b.ID, b.Authors); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := insertBookCategories(tx, b.ID, b.Categories); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if b.CurrentAmount != 0 {
+		m := model.StockMovement{BookID: b.ID, Quantity: b.CurrentAmount, Reason: model.MovementInitial}
+		if _, err := createMovement(tx, m); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
 		log.Error("create book id ", b.ID, "error, ", err.Error())
 		return nil, err
 	}
 	return &b, nil
 }
 
+// UpdateBook update the book when it is still at given version, authors and
+// categories are replaced when given and change of current amount is recorded as
+// stock adjustment, all in the same transaction
 func (r *MysqlBookRepository) UpdateBook(b model.Book) (*model.Book, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	var currentAmount int
+	err = tx.QueryRow(`SELECT currentamount FROM book WHERE id = ? AND version = ? FOR UPDATE`, b.ID, b.Version).
+		Scan(&currentAmount)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("lock book id %s error, %s", b.ID, err.Error()))
+		tx.Rollback()
+		return nil, err
+	}
+	if err := updateBook(tx, b); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if b.Authors != nil {
+		if err := setBookAuthors(tx, b.ID, b.Authors); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if b.Categories != nil {
+		if err := setBookCategories(tx, b.ID, b.Categories); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if b.CurrentAmount != currentAmount {
+		m := model.StockMovement{BookID: b.ID, Quantity: b.CurrentAmount - currentAmount, Reason: model.MovementAdjust}
+		if _, err := createMovement(tx, m); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("update book id %s error, %s", b.ID, err.Error()))
+		return nil, err
+	}
+	return &b, nil
+}
+
+// FillBook add amount to stock on hand of the book and record the fill movement
+// in the same transaction, restocks are received only by their status
+func (r *MysqlBookRepository) FillBook(id string, amount int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	if err := fillBook(tx, id, amount); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("fill book id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// fillBook add amount to stock on hand of the book with its fill movement
+func fillBook(tx *sql.Tx, id string, amount int) error {
+	res, err := tx.Exec(`UPDATE book SET currentamount = currentamount + ?, modifiedtime = ?, version = version + 1
+			WHERE id = ?`, amount, time.Now(), id)
+	if err != nil {
+		log.Error(fmt.Sprintf("fill book id %s error, %s", id, err.Error()))
+		return err
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		return &bserror.NotFoundError{Msg: fmt.Sprintf("book id %s is not found", id)}
+	}
+	if amount == 0 {
+		return nil
+	}
+	m := model.StockMovement{BookID: id, Quantity: amount, Reason: model.MovementFill}
+	_, err = createMovement(tx, m)
+	return err
+}
+
+// SaleBook update stock and sold amount of the book and record the sale, with
+// its stock movement when paperback, all in the same transaction
+func (r *MysqlBookRepository) SaleBook(b model.Book, s model.Sale) (*model.Sale, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	if err := updateBook(tx, b); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if s.Format == model.FormatPaperback {
+		m := model.StockMovement{BookID: b.ID, Quantity: -s.Amount, Reason: model.MovementSale}
+		if _, err := createMovement(tx, m); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	created, err := createSale(tx, s)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("sale book id %s error, %s", b.ID, err.Error()))
+		return nil, err
+	}
+	return created, nil
+}
+
+// updateBook update the book when it is still at given version
+func updateBook(ex execer, b model.Book) error {
 	sql := `UPDATE book SET 
+				work_id = ?,
 				title = ?,
 				synopsis = ?,
 				isbn10 = ?,
 				isbn13 = ?,
 				language = ?,
-				publisher = ?,
-				category = ?,
+				publisher_id = ?,
+				series_id = ?,
+				seriesvolume = ?,
 				edition = ?,
 				soldamount = ?,
 				currentamount = ?,
 				paperbackprice = ?,
 				ebookprice = ?,
+				costprice = ?,
 				modifiedtime = ?,
 				version = ?
 			WHERE id = ? AND version = ?
 			`
-	stmt, err := r.db.Prepare(sql)
-	if err != nil {
-		log.Error("prepare sql statement error", err.Error())
-		return nil, err
-	}
-	defer stmt.Close()
-
 	nextVer := b.Version + 1
-	res, err := stmt.Exec(b.Title, b.Synopsis, b.ISBN10, b.ISBN13, b.Language, b.Publisher, b.Category,
-		b.Edition, b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, time.Now(), nextVer,
+	res, err := ex.Exec(sql, nullString(b.WorkID), b.Title, b.Synopsis, b.ISBN10, b.ISBN13, b.Language, b.PublisherID,
+		nullString(b.SeriesID), b.SeriesVolume, b.Edition, b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CostPrice, time.Now(), nextVer,
 		b.ID, b.Version)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("update book id %s error, %s", b.ID, err.Error()))
-		return nil, err
+		return err
 	}
 	count, _ := res.RowsAffected()
 	if count == 0 {
-		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+		return &bserror.DataVersionError{Msg: "data conflict"}
 	}
+	return nil
+}
 
-	return &b, nil
+// bookSortNames return sort names accepted by QueryBook in alphabetical order
+func bookSortNames() []string {
+	names := []string{}
+	for name := range bookSortColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 func (r *MysqlBookRepository) QueryBook(q query.BookQuery) ([]model.Book, error) {
 	books := []model.Book{}
 	sql := `SELECT 
-				b.id, title, synopsis, isbn10, isbn13, language, publisher, category,
-				edition, soldamount, currentamount, paperbackprice, ebookprice, 
-				b.createdtime, b.modifiedtime, b.version, avg(r.score) as averagescore
-			FROM book b left join review r on b.id = r.book_id `
-	orderBy := "createdtime"
+				b.id, IFNULL(b.work_id, ''), title, synopsis, isbn10, isbn13, language, b.publisher_id, p.name,
+				IFNULL(b.series_id, ''), IFNULL(s.name, ''), b.seriesvolume, edition,
+				soldamount, currentamount, paperbackprice, ebookprice, costprice,
+				b.createdtime, b.modifiedtime, b.version, ` + averageScoreColumns + `, ` + workScoreColumns + `
+			FROM book b join publisher p on p.id = b.publisher_id
+				left join series s on s.id = b.series_id
+				left join book_review_stat rs on b.id = rs.book_id` + primaryCategoryJoin
+	orderBy := "b.createdtime"
 	if q.SortBy != "" {
-		orderBy = q.SortBy
-	}
-	if q.SortBy != "" {
-		orderBy = q.SortBy
+		column, ok := bookSortColumns[q.SortBy]
+		if !ok {
+			return nil, &bserror.BadParameterError{Msg: "sort must be one of " + strings.Join(bookSortNames(), ", ")}
+		}
+		orderBy = column
 	}
 	order := " ORDER BY " + orderBy
-	groupBy := " GROUP BY b.id"
 	pagination := " LIMIT ? OFFSET ?"
-	sql = sql + composeWhere(q) + groupBy + order + pagination
-	result, err := r.db.Query(sql, q.Limit, q.Offset)
+	where, args := composeWhere(q)
+	sql = sql + where + order + pagination
+	result, err := r.db.Query(sql, append(args, q.Limit, q.Offset)...)
 	if err != nil {
 		log.Error("query books error", err.Error())
 		return nil, err
 	}
+	defer result.Close()
 
 	for result.Next() {
 		b := model.Book{}
-		err := result.Scan(&b.ID, &b.Title, &b.Synopsis, &b.ISBN10, &b.ISBN13, &b.Language,
-			&b.Publisher, &b.Category, &b.Edition, &b.SoldAmount, &b.CurrentAmount, &b.PaperbackPrice,
-			&b.EbookPrice, &b.CreatedTime, &b.ModifiedTime, &b.Version, &b.AverageScore)
+		err := result.Scan(&b.ID, &b.WorkID, &b.Title, &b.Synopsis, &b.ISBN10, &b.ISBN13, &b.Language,
+			&b.PublisherID, &b.Publisher, &b.SeriesID, &b.Series, &b.SeriesVolume, &b.Edition, &b.SoldAmount, &b.CurrentAmount, &b.PaperbackPrice,
+			&b.EbookPrice, &b.CostPrice, &b.CreatedTime, &b.ModifiedTime, &b.Version, &b.AverageScore, &b.VerifiedAverageScore,
+			&b.WorkAverageScore, &b.WorkVerifiedAverageScore)
 		if err != nil {
 			log.Error("query books error", err.Error())
 			return nil, err
 		}
 		books = append(books, b)
 	}
+	result.Close()
+
+	ids := []string{}
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	authors, err := r.bookAuthors(ids)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := r.bookCategories(ids)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := r.bookTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range books {
+		books[i].Authors = authors[books[i].ID]
+		setCategories(&books[i], categories[books[i].ID])
+		books[i].Tags = tags[books[i].ID]
+	}
 	return books, nil
 }
 
 func (r *MysqlBookRepository) CountBook(q query.BookQuery) (int, error) {
-	sql := "SELECT COUNT(id) as count FROM book" + composeWhere(q)
+	where, args := composeWhere(q)
+	sql := "SELECT COUNT(b.id) as count FROM book b" + where
 	var c int
-	err := r.db.QueryRow(sql).Scan(&c)
+	err := r.db.QueryRow(sql, args...).Scan(&c)
 	if err != nil {
 		log.Error("count book error, ", err.Error())
 		return c, err
@@ -178,48 +412,265 @@ func (r *MysqlBookRepository) DeleteBook(id string) error {
 	return nil
 }
 
-func (r *MysqlBookRepository) GetBestSaller() ([]report.BestSallerBook, error) {
-	rpts := []report.BestSallerBook{}
-	sql := "SELECT title, MAX(soldamount) as totalamount FROM book GROUP BY title ORDER BY totalamount DESC"
-	result, err := r.db.Query(sql)
+// setBookAuthors replace authors credited on the book, in given order
+func setBookAuthors(tx *sql.Tx, bookID string, authors []model.BookAuthor) error {
+	if _, err := tx.Exec("DELETE FROM book_author WHERE book_id = ?", bookID); err != nil {
+		log.Error(fmt.Sprintf("clear authors of book id %s error, %s", bookID, err.Error()))
+		return err
+	}
+	return insertBookAuthors(tx, bookID, authors)
+}
+
+// insertBookAuthors credit authors on the book, in given order
+func insertBookAuthors(tx *sql.Tx, bookID string, authors []model.BookAuthor) error {
+	for i, a := range authors {
+		_, err := tx.Exec(`INSERT INTO book_author (book_id, author_id, role, position) values(?, ?, ?, ?)`,
+			bookID, a.AuthorID, a.Role, i+1)
+		if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+			return &bserror.NotFoundError{Msg: fmt.Sprintf("author id %s is not found", a.AuthorID)}
+		}
+		if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+			msg := fmt.Sprintf("author id %s is credited as %s more than once", a.AuthorID, a.Role)
+			return &bserror.BadParameterError{Msg: msg}
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("add author of book id %s error, %s", bookID, err.Error()))
+			return err
+		}
+	}
+	return nil
+}
+
+// bookAuthors return authors credited on each of given books, in credit order
+func (r *MysqlBookRepository) bookAuthors(bookIDs []string) (map[string][]model.BookAuthor, error) {
+	authors := map[string][]model.BookAuthor{}
+	for _, id := range bookIDs {
+		authors[id] = []model.BookAuthor{}
+	}
+	if len(bookIDs) == 0 {
+		return authors, nil
+	}
+	args := []interface{}{}
+	for _, id := range bookIDs {
+		args = append(args, id)
+	}
+	sql := `SELECT ba.book_id, a.id, a.name, a.sortname, ba.role
+			FROM book_author ba JOIN author a ON a.id = ba.author_id
+			WHERE ba.book_id IN (?` + strings.Repeat(", ?", len(bookIDs)-1) + `)
+			ORDER BY ba.book_id, ba.position`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query book authors error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var bookID string
+		a := model.BookAuthor{}
+		if err := result.Scan(&bookID, &a.AuthorID, &a.Name, &a.SortName, &a.Role); err != nil {
+			log.Error("query book authors error", err.Error())
+			return nil, err
+		}
+		authors[bookID] = append(authors[bookID], a)
+	}
+	return authors, nil
+}
+
+// setBookCategories replace categories of the book and move its daily sales from
+// old categories to new ones
+func setBookCategories(tx *sql.Tx, bookID string, categories []model.BookCategory) error {
+	if err := addBookCategorySales(tx, bookID, -1); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM book_category WHERE book_id = ?", bookID); err != nil {
+		log.Error(fmt.Sprintf("clear categories of book id %s error, %s", bookID, err.Error()))
+		return err
+	}
+	if err := insertBookCategories(tx, bookID, categories); err != nil {
+		return err
+	}
+	return addBookCategorySales(tx, bookID, 1)
+}
+
+// insertBookCategories add categories to the book, first one is its main category
+func insertBookCategories(tx *sql.Tx, bookID string, categories []model.BookCategory) error {
+	for i, c := range categories {
+		_, err := tx.Exec(`INSERT INTO book_category (book_id, category_id, position) values(?, ?, ?)`,
+			bookID, c.CategoryID, i+1)
+		if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+			return &bserror.NotFoundError{Msg: fmt.Sprintf("category id %s is not found", c.CategoryID)}
+		}
+		if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+			return &bserror.BadParameterError{Msg: fmt.Sprintf("category id %s is given more than once", c.CategoryID)}
+		}
+		if err != nil {
+			log.Error(fmt.Sprintf("add category of book id %s error, %s", bookID, err.Error()))
+			return err
+		}
+	}
+	return nil
+}
+
+// bookCategories return categories of each of given books, main category first
+func (r *MysqlBookRepository) bookCategories(bookIDs []string) (map[string][]model.BookCategory, error) {
+	categories := map[string][]model.BookCategory{}
+	for _, id := range bookIDs {
+		categories[id] = []model.BookCategory{}
+	}
+	if len(bookIDs) == 0 {
+		return categories, nil
+	}
+	args := []interface{}{}
+	for _, id := range bookIDs {
+		args = append(args, id)
+	}
+	sql := `SELECT bc.book_id, c.id, c.slug, c.name
+			FROM book_category bc JOIN category c ON c.id = bc.category_id
+			WHERE bc.book_id IN (?` + strings.Repeat(", ?", len(bookIDs)-1) + `)
+			ORDER BY bc.book_id, bc.position`
+	result, err := r.db.Query(sql, args...)
 	if err != nil {
-		log.Error("query report error", err.Error())
+		log.Error("query book categories error", err.Error())
 		return nil, err
 	}
+	defer result.Close()
 
 	for result.Next() {
-		each := report.BestSallerBook{}
-		if err := result.Scan(&each.Ttile, &each.TotalSaleAmount); err != nil {
+		var bookID string
+		c := model.BookCategory{}
+		if err := result.Scan(&bookID, &c.CategoryID, &c.Slug, &c.Name); err != nil {
+			log.Error("query book categories error", err.Error())
 			return nil, err
 		}
-		rpts = append(rpts, each)
+		categories[bookID] = append(categories[bookID], c)
 	}
-	return rpts, nil
+	return categories, nil
 }
 
-func (r *MysqlBookRepository) GetBestSallerByCategory() ([]report.BestSallerCategory, error) {
-	rpts := []report.BestSallerCategory{}
-	sql := "SELECT category, MAX(soldamount) as totalamount FROM book GROUP BY category ORDER BY totalamount DESC"
-	result, err := r.db.Query(sql)
+// bookTags return tags of each of given books, in alphabetical order
+func (r *MysqlBookRepository) bookTags(bookIDs []string) (map[string][]string, error) {
+	tags := map[string][]string{}
+	for _, id := range bookIDs {
+		tags[id] = []string{}
+	}
+	if len(bookIDs) == 0 {
+		return tags, nil
+	}
+	args := []interface{}{}
+	for _, id := range bookIDs {
+		args = append(args, id)
+	}
+	sql := `SELECT book_id, tag FROM book_tag
+			WHERE book_id IN (?` + strings.Repeat(", ?", len(bookIDs)-1) + `)
+			ORDER BY book_id, tag`
+	result, err := r.db.Query(sql, args...)
 	if err != nil {
-		log.Error("query report error", err.Error())
+		log.Error("query book tags error", err.Error())
 		return nil, err
 	}
+	defer result.Close()
 
 	for result.Next() {
-		each := report.BestSallerCategory{}
-		if err := result.Scan(&each.Category, &each.TotalSaleAmount); err != nil {
+		var bookID, tag string
+		if err := result.Scan(&bookID, &tag); err != nil {
+			log.Error("query book tags error", err.Error())
 			return nil, err
 		}
-		rpts = append(rpts, each)
+		tags[bookID] = append(tags[bookID], tag)
 	}
-	return rpts, nil
+	return tags, nil
 }
 
-func composeWhere(q query.BookQuery) string {
-	where := ""
+// seriesNeighbours link the book to books with the nearest lower and higher volume
+// in its series, unnumbered books have no neighbours
+func (r *MysqlBookRepository) seriesNeighbours(b *model.Book) error {
+	if b.SeriesID == "" || b.SeriesVolume == nil {
+		return nil
+	}
+	previous, err := r.seriesBook(`WHERE series_id = ? AND seriesvolume < ? ORDER BY seriesvolume DESC, title`,
+		b.SeriesID, *b.SeriesVolume)
+	if err != nil {
+		return err
+	}
+	next, err := r.seriesBook(`WHERE series_id = ? AND seriesvolume > ? ORDER BY seriesvolume, title`,
+		b.SeriesID, *b.SeriesVolume)
+	if err != nil {
+		return err
+	}
+	b.PreviousInSeries = previous
+	b.NextInSeries = next
+	return nil
+}
+
+// seriesBook return first book matching given clauses, nil when there is none
+func (r *MysqlBookRepository) seriesBook(clauses string, args ...interface{}) (*model.SeriesBook, error) {
+	sb := model.SeriesBook{}
+	err := r.db.QueryRow(`SELECT id, title, seriesvolume FROM book `+clauses+` LIMIT 1`, args...).
+		Scan(&sb.ID, &sb.Title, &sb.Volume)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error("query series neighbour error", err.Error())
+		return nil, err
+	}
+	return &sb, nil
+}
+
+// setCategories set categories of the book and its flat category, name of main category
+func setCategories(b *model.Book, categories []model.BookCategory) {
+	b.Categories = categories
+	if len(categories) > 0 {
+		b.Category = categories[0].Name
+	}
+}
+
+// composeWhere filter book b
+func composeWhere(q query.BookQuery) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
 	if q.Title != "" {
-		where = " WHERE title = '" + q.Title + "'"
+		conds = append(conds, "b.title = ?")
+		args = append(args, q.Title)
+	}
+	if q.Author != "" {
+		conds = append(conds, `EXISTS (SELECT 1 FROM book_author ba JOIN author a ON a.id = ba.author_id
+				WHERE ba.book_id = b.id AND (a.id = ? OR a.name LIKE ?))`)
+		args = append(args, q.Author, "%"+likeEscaper.Replace(q.Author)+"%")
+	}
+	if q.Category != "" {
+		cond, categoryArgs := categoryCondition(q.Category)
+		conds = append(conds, cond)
+		args = append(args, categoryArgs...)
+	}
+	if q.WorkID != "" {
+		conds = append(conds, "b.work_id = ?")
+		args = append(args, q.WorkID)
+	}
+	if q.SeriesID != "" {
+		conds = append(conds, "b.series_id = ?")
+		args = append(args, q.SeriesID)
+	}
+	if len(q.AnyTags) > 0 {
+		conds = append(conds, `EXISTS (SELECT 1 FROM book_tag bt WHERE bt.book_id = b.id AND bt.tag IN `+
+			tagList(q.AnyTags, &args)+`)`)
+	}
+	if len(q.AllTags) > 0 {
+		conds = append(conds, `(SELECT COUNT(bt.tag) FROM book_tag bt WHERE bt.book_id = b.id AND bt.tag IN `+
+			tagList(q.AllTags, &args)+`) = ?`)
+		args = append(args, len(q.AllTags))
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// tagList return placeholders of given tags for IN clause, tags are appended to args
+func tagList(tags []string, args *[]interface{}) string {
+	for _, tag := range tags {
+		*args = append(*args, tag)
 	}
-	return where
+	return "(?" + strings.Repeat(", ?", len(tags)-1) + ")"
 }
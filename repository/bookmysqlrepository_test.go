@@ -2,12 +2,14 @@ package repository
 
 import (
 	"database/sql/driver"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
 	"github.com/tsongpon/backend-challenge-2019/query"
 )
@@ -34,42 +36,75 @@ func TestGetBook(t *testing.T) {
 	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
 	rows := sqlmock.NewRows([]string{
 		"id",
+		"work_id",
 		"title",
 		"synopsis",
 		"isbn10",
 		"isbn13",
-		"category",
 		"language",
+		"publisher_id",
 		"publisher",
+		"series_id",
+		"series",
+		"seriesvolume",
 		"edition",
 		"soldamount",
 		"currentamount",
 		"paperbackprice",
 		"ebookprice",
+		"costprice",
 		"createdtime",
 		"modifiedtime",
 		"version",
-		"averagescore"}).
+		"averagescore",
+		"verifiedaveragescore",
+		"workaveragescore",
+		"workverifiedaveragescore"}).
 		AddRow(
 			"a432eee1-be54-44e6-a5ef-8a0455306f4f",
+			"5b6c7d8e-9f0a-4b1c-8d2e-3f4a5b6c7d8e",
 			"Java Concurrency in Practice",
 			"Threads are a fundamental part of the Java platform",
 			"0321349601",
 			"978-0321349606",
-			"Programming",
 			"English",
+			"9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 			"Addison-Wesley Professional",
+			"7e1c0f4a-2b5d-4c8e-9f3a-6d0b1e2c3a4f",
+			"Java in Practice",
+			2.0,
 			"1nd Edition, Kindle Edition",
 			0,
 			100,
 			1353.29,
 			1210.5,
+			900.0,
 			time.Now(),
 			time.Now(),
 			1,
+			4.5,
+			5.0,
+			4.25,
 			4.5)
-	mock.ExpectQuery("^SELECT (.+) FROM book b left join review r on b.id = r.book_id (.+)").
+	mock.ExpectQuery(`^SELECT (.+) FROM book b join publisher p on p.id = b.publisher_id\s+left join series s on s.id = b.series_id\s+left join book_review_stat rs on b.id = rs.book_id (.+)`).
 		WithArgs(bookID).WillReturnRows(rows)
+	mock.ExpectQuery(`^SELECT (.+) FROM book_author ba JOIN author a (.+) WHERE ba.book_id IN \(\?\) (.+)`).
+		WithArgs(bookID).WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "sortname", "role"}).
+		AddRow(bookID, "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "Brian Goetz", "Goetz, Brian", "author"))
+	mock.ExpectQuery(`^SELECT (.+) FROM book_category bc JOIN category c (.+) WHERE bc.book_id IN \(\?\) (.+)`).
+		WithArgs(bookID).WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "slug", "name"}).
+		AddRow(bookID, "7d0b8e55-3c4a-4a57-9a36-1f6f0e6b2d10", "java", "Java").
+		AddRow(bookID, "3b9c1f0e-2d5a-4c1e-8f0a-6e4d2c1b0a99", "concurrency", "Concurrency"))
+	mock.ExpectQuery(`^SELECT book_id, tag FROM book_tag\s+WHERE book_id IN \(\?\) (.+)`).
+		WithArgs(bookID).WillReturnRows(sqlmock.NewRows([]string{"book_id", "tag"}).
+		AddRow(bookID, "staff-pick"))
+	mock.ExpectQuery(`^SELECT id, title, seriesvolume FROM book WHERE series_id = \? AND seriesvolume < \? (.+)`).
+		WithArgs("7e1c0f4a-2b5d-4c8e-9f3a-6d0b1e2c3a4f", 2.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "seriesvolume"}).
+			AddRow("0c9d8e7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f", "Java Basics in Practice", 1.5))
+	mock.ExpectQuery(`^SELECT id, title, seriesvolume FROM book WHERE series_id = \? AND seriesvolume > \? (.+)`).
+		WithArgs("7e1c0f4a-2b5d-4c8e-9f3a-6d0b1e2c3a4f", 2.0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "seriesvolume"}))
 
 	repo := NewMysqlBookRepository(db)
 	res, err := repo.GetBook(bookID)
@@ -78,6 +113,13 @@ func TestGetBook(t *testing.T) {
 	}
 	assert.Equal(t, bookID, res.ID, "bookID must be "+bookID)
 	assert.Equal(t, "Java Concurrency in Practice", res.Title, "should book title Java Concurrency in Practice")
+	assert.Equal(t, 5.0, *res.VerifiedAverageScore, "verified average score must be returned")
+	assert.Equal(t, 900.0, *res.CostPrice, "cost price must be returned")
+	assert.Equal(t, "Brian Goetz", res.Authors[0].Name, "authors must be returned")
+	assert.Equal(t, []string{"staff-pick"}, res.Tags, "tags must be returned")
+	assert.Equal(t, 1.5, *res.PreviousInSeries.Volume, "previous book in series must be returned")
+	assert.Nil(t, res.NextInSeries, "last book in series has no next book")
+	assert.Equal(t, 4.25, *res.WorkAverageScore, "average score of the work must be returned")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -94,12 +136,14 @@ func TestCreateBook(t *testing.T) {
 
 	paperbackPrice := 1353.29
 	ebookPrice := 1100.00
+	costPrice := 900.00
 	b := model.Book{
 		Title:          "The Go Programming",
 		Synopsis:       "All you nee to known about golang",
 		ISBN10:         "0321349601",
 		ISBN13:         "978-0321349606",
 		Language:       "Thai",
+		PublisherID:    "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 		Publisher:      "Addison-Wesley Professional",
 		Category:       "Programming",
 		Edition:        "1nd Edition, Kindle Edition",
@@ -107,14 +151,20 @@ func TestCreateBook(t *testing.T) {
 		CurrentAmount:  10,
 		PaperbackPrice: &paperbackPrice,
 		EbookPrice:     &ebookPrice,
+		CostPrice:      &costPrice,
 		Version:        1,
 	}
 
-	mock.ExpectPrepare("INSERT INTO book (.+) ").ExpectExec().
-		WithArgs(anyString{}, b.Title, b.Synopsis, b.ISBN10, b.ISBN13,
-			b.Language, b.Publisher, b.Category, b.Edition,
-			b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice,
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO book (.+) ").
+		WithArgs(anyString{}, nil, b.Title, b.Synopsis, b.ISBN10, b.ISBN13,
+			b.Language, b.PublisherID, nil, nil, b.Edition,
+			b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CostPrice,
 			anyTime{}, anyTime{}, b.Version).WillReturnResult((sqlmock.NewResult(0, 1)))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, anyString{}, 10, model.MovementInitial, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	repo := NewMysqlBookRepository(db)
 	created, err := repo.CreateBook(b)
@@ -142,6 +192,7 @@ func TestUpdateBook(t *testing.T) {
 	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
 	paperbackPrice := 1353.29
 	ebookPrice := 1100.00
+	costPrice := 900.00
 	b := model.Book{
 		ID:             bookID,
 		Title:          "The Go Programming",
@@ -149,6 +200,7 @@ func TestUpdateBook(t *testing.T) {
 		ISBN10:         "0321349601",
 		ISBN13:         "978-0321349606",
 		Language:       "Thai",
+		PublisherID:    "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 		Publisher:      "Addison-Wesley Professional",
 		Category:       "Programming",
 		Edition:        "1nd Edition, Kindle Edition",
@@ -156,16 +208,21 @@ func TestUpdateBook(t *testing.T) {
 		CurrentAmount:  10,
 		PaperbackPrice: &paperbackPrice,
 		EbookPrice:     &ebookPrice,
+		CostPrice:      &costPrice,
 		CreatedTime:    &createdTime,
 		ModifiedTime:   &modifiedTime,
 		Version:        modelVersion,
 	}
 
-	mock.ExpectPrepare("UPDATE book (.+) ").ExpectExec().
-		WithArgs(b.Title, b.Synopsis, b.ISBN10, b.ISBN13,
-			b.Language, b.Publisher, b.Category, b.Edition,
-			b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice,
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currentamount FROM book WHERE id = \? AND version = \? FOR UPDATE`).
+		WithArgs(bookID, modelVersion).WillReturnRows(sqlmock.NewRows([]string{"currentamount"}).AddRow(10))
+	mock.ExpectExec("UPDATE book (.+) ").
+		WithArgs(nil, b.Title, b.Synopsis, b.ISBN10, b.ISBN13,
+			b.Language, b.PublisherID, nil, nil, b.Edition,
+			b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CostPrice,
 			anyTime{}, modelVersion+1, b.ID, modelVersion).WillReturnResult((sqlmock.NewResult(1, 1)))
+	mock.ExpectCommit()
 
 	repo := NewMysqlBookRepository(db)
 	updated, err := repo.UpdateBook(b)
@@ -179,6 +236,193 @@ func TestUpdateBook(t *testing.T) {
 	}
 }
 
+func TestUpdateBookStockAdjustment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	b := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Title: "The Go Programming", CurrentAmount: 7, Version: 1}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currentamount FROM book (.+) FOR UPDATE`).
+		WithArgs(b.ID, 1).WillReturnRows(sqlmock.NewRows([]string{"currentamount"}).AddRow(10))
+	mock.ExpectExec("UPDATE book (.+) ").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, b.ID, -3, model.MovementAdjust, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlBookRepository(db)
+	_, err = repo.UpdateBook(b)
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateBookVersionConflict(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	b := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Title: "The Go Programming", CurrentAmount: 7, Version: 1}
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currentamount FROM book (.+) FOR UPDATE`).
+		WithArgs(b.ID, 1).WillReturnRows(sqlmock.NewRows([]string{"currentamount"}))
+	mock.ExpectRollback()
+
+	repo := NewMysqlBookRepository(db)
+	_, err = repo.UpdateBook(b)
+
+	assert.IsType(t, &bserror.DataVersionError{}, err, "stale version must be reported")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestFillBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE book SET currentamount = currentamount \+ \?(.+)WHERE id = \?`).
+		WithArgs(2, anyTime{}, bookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, bookID, 2, model.MovementFill, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlBookRepository(db)
+	err = repo.FillBook(bookID, 2)
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaleBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	price := 1353.29
+	b := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", PublisherID: "aw", SoldAmount: 2,
+		CurrentAmount: 8, PaperbackPrice: &price, Version: 1}
+	s := model.Sale{
+		BookID:     b.ID,
+		CustomerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		Format:     model.FormatPaperback,
+		Amount:     2,
+		UnitPrice:  &price,
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE book (.+) ").
+		WithArgs(nil, b.Title, b.Synopsis, b.ISBN10, b.ISBN13, b.Language, b.PublisherID, nil, nil, b.Edition,
+			b.SoldAmount, b.CurrentAmount, b.PaperbackPrice, b.EbookPrice, b.CostPrice, anyTime{}, 2, b.ID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, b.ID, -2, model.MovementSale, anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sale (.+)").
+		WithArgs(anyString{}, s.BookID, s.CustomerID, s.Format, s.Amount, price, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sale_daily (.+) ON DUPLICATE KEY UPDATE (.+)").
+		WithArgs(anyTime{}, s.BookID, s.Format, s.Amount, 2*price).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO category_sale_daily (.+) SELECT DATE\(\?\), a.ancestor_id, \?, \? (.+) WHERE bc.book_id = \?`).
+		WithArgs(anyTime{}, s.Amount, 2*price, s.BookID).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("UPDATE review SET verifiedpurchase = 1 (.+)").
+		WithArgs(s.BookID, s.CustomerID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_review_stat (.+)").
+		WithArgs(s.BookID, s.BookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlBookRepository(db)
+	created, err := repo.SaleBook(b, s)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotEqual(t, "", created.ID, "new id must be generated")
+	assert.NotNil(t, created.CreatedTime, "created time must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaleEbookWithoutCustomer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	b := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", SoldAmount: 1, Version: 1}
+	s := model.Sale{BookID: b.ID, Format: model.FormatEbook, Amount: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE book (.+) ").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sale (.+)").
+		WithArgs(anyString{}, s.BookID, nil, s.Format, s.Amount, nil, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sale_daily (.+)").
+		WithArgs(anyTime{}, s.BookID, s.Format, s.Amount, 0.0).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO category_sale_daily (.+)").
+		WithArgs(anyTime{}, s.Amount, 0.0, s.BookID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repo := NewMysqlBookRepository(db)
+	_, err = repo.SaleBook(b, s)
+
+	assert.Nil(t, err, "ebook sale should not move stock")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSaleBookRollbackWhenSaleFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	b := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", SoldAmount: 1, CurrentAmount: 9, Version: 1}
+	s := model.Sale{BookID: b.ID, Format: model.FormatPaperback, Amount: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE book (.+) ").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO sale (.+)").WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	repo := NewMysqlBookRepository(db)
+	created, err := repo.SaleBook(b, s)
+
+	assert.Nil(t, created, "should not return sale")
+	assert.NotNil(t, err, "stock must not change without the sale")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestQueryBook(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -188,47 +432,73 @@ func TestQueryBook(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{
 		"id",
+		"work_id",
 		"title",
 		"synopsis",
 		"isbn10",
 		"isbn13",
-		"category",
 		"language",
+		"publisher_id",
 		"publisher",
+		"series_id",
+		"series",
+		"seriesvolume",
 		"edition",
 		"soldamount",
 		"currentamount",
 		"paperbackprice",
 		"ebookprice",
+		"costprice",
 		"createdtime",
 		"modifiedtime",
 		"version",
-		"averagescore"}).
+		"averagescore",
+		"verifiedaveragescore",
+		"workaveragescore",
+		"workverifiedaveragescore"}).
 		AddRow(
 			"a432eee1-be54-44e6-a5ef-8a0455306f4f",
+			"",
 			"Java Concurrency in Practice",
 			"Threads are a fundamental part of the Java platform",
 			"0321349601",
 			"978-0321349606",
-			"Programming",
 			"English",
+			"9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 			"Addison-Wesley Professional",
+			"",
+			"",
+			nil,
 			"1nd Edition, Kindle Edition",
 			0,
 			100,
 			1353.29,
 			1210.5,
+			900.0,
 			time.Now(),
 			time.Now(),
 			1,
-			4.5)
-	mock.ExpectQuery(`^SELECT (.+) FROM book b left join review r on b.id = r.book_id 
-	WHERE title = 'Java Concurrency in Practice' (.+) ORDER BY category (.+)`).
-		WithArgs(5, 0).WillReturnRows(rows)
+			4.5,
+			5.0,
+			nil,
+			nil)
+	mock.ExpectQuery(`^SELECT (.+) FROM book b join publisher p on p.id = b.publisher_id\s+left join series s on s.id = b.series_id\s+left join book_review_stat rs on b.id = rs.book_id 
+	(.+)WHERE b.title = \? AND EXISTS \(SELECT 1 FROM book_author (.+)\) ORDER BY c.name (.+)`).
+		WithArgs("Java Concurrency in Practice", "Goetz", "%Goetz%", 5, 0).WillReturnRows(rows)
+	mock.ExpectQuery(`^SELECT (.+) FROM book_author ba (.+)`).
+		WithArgs("a432eee1-be54-44e6-a5ef-8a0455306f4f").
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "sortname", "role"}))
+	mock.ExpectQuery(`^SELECT (.+) FROM book_category bc (.+)`).
+		WithArgs("a432eee1-be54-44e6-a5ef-8a0455306f4f").
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "slug", "name"}))
+	mock.ExpectQuery(`^SELECT book_id, tag FROM book_tag (.+)`).
+		WithArgs("a432eee1-be54-44e6-a5ef-8a0455306f4f").
+		WillReturnRows(sqlmock.NewRows([]string{"book_id", "tag"}))
 
 	q := query.BookQuery{Limit: 5,
 		Offset: 0,
 		Title:  "Java Concurrency in Practice",
+		Author: "Goetz",
 		SortBy: "category",
 	}
 	repo := NewMysqlBookRepository(db)
@@ -237,6 +507,25 @@ func TestQueryBook(t *testing.T) {
 	assert.Nil(t, err, "Should not get any error")
 	assert.Equal(t, 1, len(books), "should have only one book return")
 	assert.Equal(t, "a432eee1-be54-44e6-a5ef-8a0455306f4f", books[0].ID, "returned book not correct")
+	assert.Equal(t, 0, len(books[0].Authors), "book without authors has empty authors")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestQueryBookWithUnknownSort(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewMysqlBookRepository(db)
+	_, err = repo.QueryBook(query.BookQuery{Limit: 5, SortBy: "(SELECT SLEEP(10))"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "unknown sort must be rejected")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -253,8 +542,8 @@ func TestCountBook(t *testing.T) {
 
 	rows := sqlmock.NewRows([]string{"count"}).
 		AddRow(1)
-	mock.ExpectQuery(`^SELECT COUNT(.+) FROM book WHERE title = 'Java Concurrency in Practice'`).
-		WillReturnRows(rows)
+	mock.ExpectQuery(`^SELECT COUNT(.+) FROM book b WHERE b.title = \?`).
+		WithArgs("Java Concurrency in Practice").WillReturnRows(rows)
 
 	q := query.BookQuery{Limit: 5,
 		Offset: 0,
@@ -274,6 +563,36 @@ func TestCountBook(t *testing.T) {
 	}
 }
 
+func TestCountBookByTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"count"}).
+		AddRow(2)
+	mock.ExpectQuery(`^SELECT COUNT(.+) FROM book b WHERE EXISTS \(SELECT 1 FROM book_tag bt (.+) AND bt.tag IN \(\?, \?\)\) `+
+		`AND \(SELECT COUNT\(bt.tag\) FROM book_tag bt (.+) AND bt.tag IN \(\?\)\) = \?`).
+		WithArgs("staff-pick", "summer-reading", "paperback-sale", 1).WillReturnRows(rows)
+
+	q := query.BookQuery{
+		AnyTags: []string{"staff-pick", "summer-reading"},
+		AllTags: []string{"paperback-sale"},
+	}
+
+	repo := NewMysqlBookRepository(db)
+	c, err := repo.CountBook(q)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, c, "expected 2 from count")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
 func TestDeleteBook(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -296,22 +615,29 @@ func TestDeleteBook(t *testing.T) {
 	}
 }
 
-func TestGetBestSaller(t *testing.T) {
+func TestCreateBookWithMissingAuthor(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"title", "totalamount"}).
-		AddRow("Java in action", 100).
-		AddRow("Nodejs is the best", 1)
-	mock.ExpectQuery("^SELECT (.+) FROM book (.+) ").WillReturnRows(rows)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO book (.+)").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_author (.+)").
+		WithArgs(anyString{}, "pike", "author", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_author (.+)").
+		WithArgs(anyString{}, "missing", "translator", 2).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
 
 	repo := NewMysqlBookRepository(db)
-	res, err := repo.GetBestSaller()
+	_, err = repo.CreateBook(model.Book{Title: "The Go Programming", CurrentAmount: 10, Authors: []model.BookAuthor{
+		{AuthorID: "pike", Role: "author"},
+		{AuthorID: "missing", Role: "translator"},
+	}})
 
-	assert.Nil(t, err, "should not get any error")
-	assert.Equal(t, "Java in action", res[0].Ttile, "Incorrect book title returned")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "book must not be created with missing author")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -319,23 +645,37 @@ func TestGetBestSaller(t *testing.T) {
 	}
 }
 
-func TestGetBestSallerByCategory(t *testing.T) {
+func TestUpdateBookAuthorsAndCategories(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
 	}
 	defer db.Close()
-	rows := sqlmock.NewRows([]string{"category", "totalamount"}).
-		AddRow("Programming", 100).
-		AddRow("Big Data", 1)
-	mock.ExpectQuery("^SELECT category, (.+) FROM book (.+)").WillReturnRows(rows)
+
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT currentamount FROM book (.+) FOR UPDATE`).
+		WithArgs(bookID, 1).WillReturnRows(sqlmock.NewRows([]string{"currentamount"}).AddRow(10))
+	mock.ExpectExec("UPDATE book (.+) ").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM book_author WHERE book_id = \?`).
+		WithArgs(bookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_author (.+)").
+		WithArgs(bookID, "pike", "author", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO category_sale_daily (.+) SELECT d.saledate, a.ancestor_id, \? \* SUM\(d.amount\)(.+)`).
+		WithArgs(-1, -1, bookID, bookID).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM book_category WHERE book_id = \?`).
+		WithArgs(bookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO book_category (.+)`).
+		WithArgs(bookID, "golang", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO category_sale_daily (.+)`).
+		WithArgs(1, 1, bookID, bookID).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
 
 	repo := NewMysqlBookRepository(db)
-	res, err := repo.GetBestSallerByCategory()
+	_, err = repo.UpdateBook(model.Book{ID: bookID, Title: "The Go Programming", CurrentAmount: 10, Version: 1,
+		Authors: []model.BookAuthor{{AuthorID: "pike", Role: "author"}}, Categories: []model.BookCategory{{CategoryID: "golang"}}})
 
 	assert.Nil(t, err, "should not get any error")
-	assert.Equal(t, "Programming", res[0].Category, "first top saller category is Programming")
-	assert.Equal(t, "Big Data", res[1].Category, "second top saller category is Big Data")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// categorySelect select categories c with path and depth from their ancestors a
+// and count of books in their subtree, must be followed by categoryGroupBy
+const categorySelect = `SELECT c.id, IFNULL(c.parent_id, ''), c.slug, c.name,
+				GROUP_CONCAT(a.slug ORDER BY p.depth DESC SEPARATOR '/') as path, MAX(p.depth),
+				(SELECT COUNT(DISTINCT bc.book_id) FROM category_path s
+					JOIN book_category bc ON bc.category_id = s.descendant_id WHERE s.ancestor_id = c.id),
+				c.createdtime, c.modifiedtime, c.version
+			FROM category c JOIN category_path p ON p.descendant_id = c.id JOIN category a ON a.id = p.ancestor_id`
+
+const categoryGroupBy = ` GROUP BY c.id, c.parent_id, c.slug, c.name, c.createdtime, c.modifiedtime, c.version`
+
+// primaryCategoryJoin join first category c of book b, c is null for books without category
+const primaryCategoryJoin = ` LEFT JOIN book_category pc ON pc.book_id = b.id
+				AND pc.position = (SELECT MIN(position) FROM book_category WHERE book_id = b.id)
+			LEFT JOIN category c ON c.id = pc.category_id`
+
+// categoryCondition filter books b in the category given by id, slug or name, or
+// in any category below it
+func categoryCondition(category string) (string, []interface{}) {
+	return `EXISTS (SELECT 1 FROM book_category fc JOIN category_path fp ON fp.descendant_id = fc.category_id
+				JOIN category fa ON fa.id = fp.ancestor_id
+				WHERE fc.book_id = b.id AND (fa.id = ? OR fa.slug = ? OR fa.name = ?))`,
+		[]interface{}{category, category, category}
+}
+
+type MysqlCategoryRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlCategoryRepository create new mysql category repository
+func NewMysqlCategoryRepository(db *sql.DB) *MysqlCategoryRepository {
+	repo := new(MysqlCategoryRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlCategoryRepository) GetCategory(id string) (*model.Category, error) {
+	categories, err := r.queryCategories(` WHERE c.id = ?`+categoryGroupBy, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("category id %s is not found", id)}
+	}
+	return &categories[0], nil
+}
+
+// FindCategory return category of given id, slug or name, names are not unique so
+// first of them by path is returned
+func (r *MysqlCategoryRepository) FindCategory(key string) (*model.Category, error) {
+	categories, err := r.queryCategories(` WHERE c.id = ? OR c.slug = ? OR c.name = ?`+categoryGroupBy+
+		` ORDER BY c.id = ? DESC, c.slug = ? DESC, path LIMIT 1`, key, key, key, key, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) == 0 {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("category %s is not found", key)}
+	}
+	return &categories[0], nil
+}
+
+// QueryCategory return categories in tree order, each category followed by those below it
+func (r *MysqlCategoryRepository) QueryCategory(q query.CategoryQuery) ([]model.Category, error) {
+	if q.Root == "" {
+		return r.queryCategories(categoryGroupBy + ` ORDER BY path`)
+	}
+	return r.queryCategories(` WHERE c.id IN (SELECT descendant_id FROM category_path WHERE ancestor_id = ?)`+
+		categoryGroupBy+` ORDER BY path`, q.Root)
+}
+
+func (r *MysqlCategoryRepository) queryCategories(clauses string, args ...interface{}) ([]model.Category, error) {
+	categories := []model.Category{}
+	result, err := r.db.Query(categorySelect+clauses, args...)
+	if err != nil {
+		log.Error("query categories error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		c := model.Category{}
+		err := result.Scan(&c.ID, &c.ParentID, &c.Slug, &c.Name, &c.Path, &c.Depth, &c.BookCount,
+			&c.CreatedTime, &c.ModifiedTime, &c.Version)
+		if err != nil {
+			log.Error("query categories error", err.Error())
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	result.Close()
+
+	ids := []string{}
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	names, err := r.categoryNames(ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		categories[i].Names = names[categories[i].ID]
+	}
+	return categories, nil
+}
+
+// categoryNames return localized names of each of given categories by locale
+func (r *MysqlCategoryRepository) categoryNames(categoryIDs []string) (map[string]map[string]string, error) {
+	names := map[string]map[string]string{}
+	for _, id := range categoryIDs {
+		names[id] = map[string]string{}
+	}
+	if len(categoryIDs) == 0 {
+		return names, nil
+	}
+	args := []interface{}{}
+	for _, id := range categoryIDs {
+		args = append(args, id)
+	}
+	sql := `SELECT category_id, locale, name FROM category_name
+			WHERE category_id IN (?` + strings.Repeat(", ?", len(categoryIDs)-1) + `)`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query category names error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var id, locale, name string
+		if err := result.Scan(&id, &locale, &name); err != nil {
+			log.Error("query category names error", err.Error())
+			return nil, err
+		}
+		names[id][locale] = name
+	}
+	return names, nil
+}
+
+// CreateCategory create category below its parent, ConflictError is returned when
+// slug is taken
+func (r *MysqlCategoryRepository) CreateCategory(c model.Category) (*model.Category, error) {
+	now := time.Now()
+	c.ID = uuid.New().String()
+	c.CreatedTime = &now
+	c.ModifiedTime = &now
+	c.Version = 1
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO category (id, parent_id, slug, name, createdtime, modifiedtime, version)
+			values(?, ?, ?, ?, ?, ?, ?)`,
+		c.ID, nullString(c.ParentID), c.Slug, c.Name, c.CreatedTime, c.ModifiedTime, c.Version)
+	if err != nil {
+		tx.Rollback()
+		return nil, categoryWriteError(c, err)
+	}
+	_, err = tx.Exec(`INSERT INTO category_path (ancestor_id, descendant_id, depth)
+			SELECT ancestor_id, ?, depth + 1 FROM category_path WHERE descendant_id = ?
+			UNION ALL SELECT ?, ?, 0`, c.ID, c.ParentID, c.ID, c.ID)
+	if err != nil {
+		log.Error(fmt.Sprintf("create path of category %s error, %s", c.Slug, err.Error()))
+		tx.Rollback()
+		return nil, err
+	}
+	if err := setCategoryNames(tx, c.ID, c.Names); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// UpdateCategory change slug and names of category, localized names are replaced
+// unless nil, ConflictError is returned when slug is taken
+func (r *MysqlCategoryRepository) UpdateCategory(c model.Category) (*model.Category, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	res, err := tx.Exec(`UPDATE category SET slug = ?, name = ?, modifiedtime = ?, version = ?
+			WHERE id = ? AND version = ?`, c.Slug, c.Name, time.Now(), c.Version+1, c.ID, c.Version)
+	if err != nil {
+		tx.Rollback()
+		return nil, categoryWriteError(c, err)
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		tx.Rollback()
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	if c.Names != nil {
+		if _, err := tx.Exec(`DELETE FROM category_name WHERE category_id = ?`, c.ID); err != nil {
+			log.Error(fmt.Sprintf("clear names of category id %s error, %s", c.ID, err.Error()))
+			tx.Rollback()
+			return nil, err
+		}
+		if err := setCategoryNames(tx, c.ID, c.Names); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// DeleteCategory delete category, ConflictError is returned while it has
+// categories below it or books
+func (r *MysqlCategoryRepository) DeleteCategory(id string) error {
+	_, err := r.db.Exec("DELETE FROM category WHERE id = ?", id)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlRowReferenced {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("category id %s has categories below it or books", id)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete category id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// MoveCategory move category with categories below it under parent, to top level
+// when parentID is empty, daily category sales follow the new tree. Caller must make
+// sure parent is not below the category
+func (r *MysqlCategoryRepository) MoveCategory(id string, parentID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	if err := moveCategory(tx, id, parentID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := rebuildCategorySaleDaily(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// MergeCategories move books, localized names missing on target and categories
+// below merged categories to target category then delete merged categories, in one
+// transaction. Caller must make sure target is not below any merged category
+func (r *MysqlCategoryRepository) MergeCategories(targetID string, mergedIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	for _, id := range mergedIDs {
+		if err := mergeCategory(tx, targetID, id); err != nil {
+			log.Error(fmt.Sprintf("merge category id %s into %s error, %s", id, targetID, err.Error()))
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := rebuildCategorySaleDaily(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func mergeCategory(tx *sql.Tx, targetID string, id string) error {
+	children := []string{}
+	result, err := tx.Query(`SELECT id FROM category WHERE parent_id = ?`, id)
+	if err != nil {
+		return err
+	}
+	for result.Next() {
+		var child string
+		if err := result.Scan(&child); err != nil {
+			result.Close()
+			return err
+		}
+		children = append(children, child)
+	}
+	result.Close()
+	for _, child := range children {
+		if err := moveCategory(tx, child, targetID); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT IGNORE INTO category_name (category_id, locale, name)
+			SELECT ?, locale, name FROM category_name WHERE category_id = ?`, targetID, id)
+	if err != nil {
+		return err
+	}
+	// books in both categories keep the better position of the two
+	_, err = tx.Exec(`UPDATE book_category t JOIN book_category s ON s.book_id = t.book_id AND s.category_id = ?
+			SET t.position = LEAST(t.position, s.position) WHERE t.category_id = ?`, id, targetID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT IGNORE INTO book_category (book_id, category_id, position)
+			SELECT book_id, ?, position FROM book_category WHERE category_id = ?`, targetID, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM book_category WHERE category_id = ?`, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM category WHERE id = ?`, id)
+	return err
+}
+
+// moveCategory hang the category and its subtree under parent, paths from former
+// ancestors into the subtree are replaced by paths from ancestors of parent
+func moveCategory(ex execer, id string, parentID string) error {
+	_, err := ex.Exec(`DELETE a FROM category_path a
+			JOIN category_path d ON d.descendant_id = a.descendant_id
+			LEFT JOIN category_path x ON x.ancestor_id = d.ancestor_id AND x.descendant_id = a.ancestor_id
+			WHERE d.ancestor_id = ? AND x.ancestor_id IS NULL`, id)
+	if err != nil {
+		log.Error(fmt.Sprintf("detach category id %s error, %s", id, err.Error()))
+		return err
+	}
+	if parentID != "" {
+		_, err := ex.Exec(`INSERT INTO category_path (ancestor_id, descendant_id, depth)
+				SELECT super.ancestor_id, sub.descendant_id, super.depth + sub.depth + 1
+				FROM category_path super JOIN category_path sub
+				WHERE super.descendant_id = ? AND sub.ancestor_id = ?`, parentID, id)
+		if err != nil {
+			log.Error(fmt.Sprintf("attach category id %s error, %s", id, err.Error()))
+			return err
+		}
+	}
+	_, err = ex.Exec(`UPDATE category SET parent_id = ?, modifiedtime = ?, version = version + 1 WHERE id = ?`,
+		nullString(parentID), time.Now(), id)
+	if err != nil {
+		log.Error(fmt.Sprintf("move category id %s error, %s", id, err.Error()))
+	}
+	return err
+}
+
+func setCategoryNames(ex execer, id string, names map[string]string) error {
+	for locale, name := range names {
+		_, err := ex.Exec(`INSERT INTO category_name (category_id, locale, name) values(?, ?, ?)`, id, locale, name)
+		if err != nil {
+			log.Error(fmt.Sprintf("add %s name of category id %s error, %s", locale, id, err.Error()))
+			return err
+		}
+	}
+	return nil
+}
+
+// categoryWriteError turn mysql error of writing category into service error
+func categoryWriteError(c model.Category, err error) error {
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("category slug %s already exists", c.Slug)}
+	}
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+		return &bserror.NotFoundError{Msg: fmt.Sprintf("category id %s is not found", c.ParentID)}
+	}
+	log.Error(fmt.Sprintf("write category %s error, %s", c.Slug, err.Error()))
+	return err
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestMoveCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE a FROM category_path a (.+) WHERE d.ancestor_id = \? AND x.ancestor_id IS NULL`).
+		WithArgs("golang").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO category_path (.+) WHERE super.descendant_id = \? AND sub.ancestor_id = \?`).
+		WithArgs("programming", "golang").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE category SET parent_id = \?, (.+) WHERE id = \?`).
+		WithArgs("programming", anyTime{}, "golang").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM category_sale_daily").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("INSERT INTO category_sale_daily (.+)").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	repo := NewMysqlCategoryRepository(db)
+	err = repo.MoveCategory("golang", "programming")
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMergeCategories(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id FROM category WHERE parent_id = \?`).
+		WithArgs("coding").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("golang"))
+	mock.ExpectExec(`DELETE a FROM category_path a (.+)`).
+		WithArgs("golang").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO category_path (.+)`).
+		WithArgs("programming", "golang").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE category SET parent_id = \?, (.+) WHERE id = \?`).
+		WithArgs("programming", anyTime{}, "golang").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT IGNORE INTO category_name (.+)`).
+		WithArgs("programming", "coding").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE book_category t JOIN book_category s (.+) SET t.position = LEAST\(t.position, s.position\)`).
+		WithArgs("coding", "programming").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT IGNORE INTO book_category (.+)`).
+		WithArgs("programming", "coding").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM book_category WHERE category_id = \?`).
+		WithArgs("coding").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`DELETE FROM category WHERE id = \?`).
+		WithArgs("coding").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM category_sale_daily").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("INSERT INTO category_sale_daily (.+)").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	repo := NewMysqlCategoryRepository(db)
+	err = repo.MergeCategories("programming", []string{"coding"})
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDuplicatedCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO category (.+)`).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlCategoryRepository(db)
+	_, err = repo.CreateCategory(model.Category{Slug: "programming", Name: "Programming"})
+
+	assert.IsType(t, &bserror.ConflictError{}, err, "slug must be unique")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+const publisherColumns = `p.id, p.name, (SELECT COUNT(b.id) FROM book b WHERE b.publisher_id = p.id),
+				p.createdtime, p.modifiedtime, p.version`
+
+type MysqlPublisherRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlPublisherRepository create new mysql publisher repository
+func NewMysqlPublisherRepository(db *sql.DB) *MysqlPublisherRepository {
+	repo := new(MysqlPublisherRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlPublisherRepository) GetPublisher(id string) (*model.Publisher, error) {
+	sql := `SELECT ` + publisherColumns + ` FROM publisher p WHERE p.id = ?`
+	p, err := scanPublisher(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get publisher id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("publisher id %s is not found", id)}
+	}
+	return &p, nil
+}
+
+// GetPublisherByName return publisher of given name, compared case insensitive
+func (r *MysqlPublisherRepository) GetPublisherByName(name string) (*model.Publisher, error) {
+	sql := `SELECT ` + publisherColumns + ` FROM publisher p WHERE p.name = ?`
+	p, err := scanPublisher(r.db.QueryRow(sql, name))
+	if err != nil {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("publisher %s is not found", name)}
+	}
+	return &p, nil
+}
+
+// QueryPublisher return publishers ordered by name
+func (r *MysqlPublisherRepository) QueryPublisher(q query.PublisherQuery) ([]model.Publisher, error) {
+	publishers := []model.Publisher{}
+	where, args := composePublisherWhere(q)
+	sql := `SELECT ` + publisherColumns + ` FROM publisher p` + where + ` ORDER BY p.name, p.id` +
+		composeLimit(q.Limit, &args)
+	if q.Limit > 0 {
+		sql += " OFFSET ?"
+		args = append(args, q.Offset)
+	}
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query publishers error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		p, err := scanPublisher(result)
+		if err != nil {
+			log.Error("query publishers error", err.Error())
+			return nil, err
+		}
+		publishers = append(publishers, p)
+	}
+	return publishers, nil
+}
+
+func (r *MysqlPublisherRepository) CountPublisher(q query.PublisherQuery) (int, error) {
+	where, args := composePublisherWhere(q)
+	sql := "SELECT COUNT(p.id) as count FROM publisher p" + where
+	var c int
+	if err := r.db.QueryRow(sql, args...).Scan(&c); err != nil {
+		log.Error("count publisher error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+// CreatePublisher create publisher, ConflictError is returned when name is taken
+func (r *MysqlPublisherRepository) CreatePublisher(p model.Publisher) (*model.Publisher, error) {
+	sql := `INSERT INTO publisher (id, name, createdtime, modifiedtime, version) values(?, ?, ?, ?, ?)`
+	now := time.Now()
+	p.ID = uuid.New().String()
+	p.CreatedTime = &now
+	p.ModifiedTime = &now
+	p.Version = 1
+	_, err := r.db.Exec(sql, p.ID, p.Name, p.CreatedTime, p.ModifiedTime, p.Version)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return nil, &bserror.ConflictError{Msg: fmt.Sprintf("publisher %s already exists", p.Name)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("create publisher %s error, %s", p.Name, err.Error()))
+		return nil, err
+	}
+	return &p, nil
+}
+
+// UpdatePublisher rename publisher, ConflictError is returned when name is taken
+func (r *MysqlPublisherRepository) UpdatePublisher(p model.Publisher) (*model.Publisher, error) {
+	sql := `UPDATE publisher SET name = ?, modifiedtime = ?, version = ? WHERE id = ? AND version = ?`
+	res, err := r.db.Exec(sql, p.Name, time.Now(), p.Version+1, p.ID, p.Version)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return nil, &bserror.ConflictError{Msg: fmt.Sprintf("publisher %s already exists", p.Name)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("update publisher id %s error, %s", p.ID, err.Error()))
+		return nil, err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	return &p, nil
+}
+
+// DeletePublisher delete publisher, ConflictError is returned while it has books
+func (r *MysqlPublisherRepository) DeletePublisher(id string) error {
+	_, err := r.db.Exec("DELETE FROM publisher WHERE id = ?", id)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlRowReferenced {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("publisher id %s has books", id)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete publisher id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// MergePublishers move books of merged publishers to target publisher then delete
+// merged publishers, in one transaction
+func (r *MysqlPublisherRepository) MergePublishers(targetID string, mergedIDs []string) error {
+	if len(mergedIDs) == 0 {
+		return nil
+	}
+	in := "(?" + strings.Repeat(", ?", len(mergedIDs)-1) + ")"
+	args := []interface{}{}
+	for _, id := range mergedIDs {
+		args = append(args, id)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	_, err = tx.Exec(`UPDATE book SET publisher_id = ?, modifiedtime = ?, version = version + 1
+			WHERE publisher_id IN `+in, append([]interface{}{targetID, time.Now()}, args...)...)
+	if err != nil {
+		log.Error(fmt.Sprintf("move books to publisher id %s error, %s", targetID, err.Error()))
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM publisher WHERE id IN `+in, args...); err != nil {
+		log.Error(fmt.Sprintf("delete merged publishers error, %s", err.Error()))
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func composePublisherWhere(q query.PublisherQuery) (string, []interface{}) {
+	if q.Name == "" {
+		return "", []interface{}{}
+	}
+	return " WHERE p.name LIKE ?", []interface{}{"%" + likeEscaper.Replace(q.Name) + "%"}
+}
+
+func scanPublisher(row rowScanner) (model.Publisher, error) {
+	p := model.Publisher{}
+	err := row.Scan(&p.ID, &p.Name, &p.BookCount, &p.CreatedTime, &p.ModifiedTime, &p.Version)
+	return p, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestMergePublishers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE book SET publisher_id = \?, (.+) WHERE publisher_id IN \(\?, \?\)`).
+		WithArgs("oreilly", anyTime{}, "oreilly-media", "oreilly-inc").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec(`DELETE FROM publisher WHERE id IN \(\?, \?\)`).
+		WithArgs("oreilly-media", "oreilly-inc").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	repo := NewMysqlPublisherRepository(db)
+	err = repo.MergePublishers("oreilly", []string{"oreilly-media", "oreilly-inc"})
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDuplicatedPublisher(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("INSERT INTO publisher (.+)").
+		WithArgs(anyString{}, "O'Reilly", anyTime{}, anyTime{}, 1).
+		WillReturnError(&mysql.MySQLError{Number: mysqlDuplicateEntry})
+
+	repo := NewMysqlPublisherRepository(db)
+	_, err = repo.CreatePublisher(model.Publisher{Name: "O'Reilly"})
+
+	assert.IsType(t, &bserror.ConflictError{}, err, "should get conflict error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+const replyColumns = `id, review_id, author_id, description, createdtime, modifiedtime, version`
+
+type MysqlReplyRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlReplyRepository create new mysql review reply repository
+func NewMysqlReplyRepository(db *sql.DB) *MysqlReplyRepository {
+	repo := new(MysqlReplyRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlReplyRepository) CreateReply(reply model.Reply) (*model.Reply, error) {
+	sql := `INSERT INTO review_reply (
+		id, review_id, author_id, description, createdtime, modifiedtime, version
+	) values(?, ?, ?, ?, ?, ?, ?)`
+	stmt, err := r.db.Prepare(sql)
+	if err != nil {
+		log.Error("prepare sql statement error, ", err.Error())
+		return nil, err
+	}
+	defer stmt.Close()
+	now := time.Now()
+	reply.ID = uuid.New().String()
+	reply.CreatedTime = &now
+	reply.ModifiedTime = &now
+	reply.Version = 1
+	_, err = stmt.Exec(reply.ID, reply.ReviewID, reply.AuthorID, reply.Description, reply.CreatedTime,
+		reply.ModifiedTime, reply.Version)
+	if err != nil {
+		log.Error("create reply id ", reply.ID, "error, ", err.Error())
+		return nil, err
+	}
+	return &reply, nil
+}
+
+func (r *MysqlReplyRepository) UpdateReply(reply model.Reply) (*model.Reply, error) {
+	sql := `UPDATE review_reply SET
+				description = ?,
+				modifiedtime = ?,
+				version = ?
+			WHERE id = ? AND version = ?
+			`
+	stmt, err := r.db.Prepare(sql)
+	if err != nil {
+		log.Error("prepare sql statement error", err.Error())
+		return nil, err
+	}
+	defer stmt.Close()
+
+	nextVer := reply.Version + 1
+	res, err := stmt.Exec(reply.Description, time.Now(), nextVer, reply.ID, reply.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("update reply id %s error, %s", reply.ID, err.Error()))
+		return nil, err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	return &reply, nil
+}
+
+func (r *MysqlReplyRepository) GetReply(id string) (*model.Reply, error) {
+	sql := `SELECT ` + replyColumns + ` FROM review_reply WHERE id = ?`
+	reply, err := scanReply(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get reply id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("reply id %s is not found", id)}
+	}
+	return &reply, nil
+}
+
+// GetReplyByReviews return replies of all given reviews in one query, oldest first
+func (r *MysqlReplyRepository) GetReplyByReviews(reviewIDs []string) ([]model.Reply, error) {
+	replies := []model.Reply{}
+	if len(reviewIDs) == 0 {
+		return replies, nil
+	}
+	args := []interface{}{}
+	for _, id := range reviewIDs {
+		args = append(args, id)
+	}
+	sql := `SELECT ` + replyColumns + ` FROM review_reply
+			WHERE review_id IN (?` + strings.Repeat(", ?", len(reviewIDs)-1) + `) ORDER BY createdtime`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query reply error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		reply, err := scanReply(result)
+		if err != nil {
+			log.Error("query reply error", err.Error())
+			return nil, err
+		}
+		replies = append(replies, reply)
+	}
+	return replies, nil
+}
+
+func (r *MysqlReplyRepository) DeleteReply(id string) error {
+	sql := "DELETE FROM review_reply WHERE id = ?"
+	stmt, err := r.db.Prepare(sql)
+	if err != nil {
+		log.Error(fmt.Sprintf("delete reply id %s error, %s", id, err.Error()))
+		return err
+	}
+	defer stmt.Close()
+
+	_, err = stmt.Exec(id)
+	if err != nil {
+		log.Error(fmt.Sprintf("delete reply id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func scanReply(row rowScanner) (model.Reply, error) {
+	reply := model.Reply{}
+	err := row.Scan(&reply.ID, &reply.ReviewID, &reply.AuthorID, &reply.Description,
+		&reply.CreatedTime, &reply.ModifiedTime, &reply.Version)
+	return reply, err
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestCreateReply(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reply := model.Reply{
+		ReviewID:    "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		AuthorID:    "staff-1",
+		Description: "Thank you for your review",
+	}
+	mock.ExpectPrepare("INSERT INTO review_reply (.+) ").ExpectExec().
+		WithArgs(anyString{}, reply.ReviewID, reply.AuthorID, reply.Description, anyTime{}, anyTime{}, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewMysqlReplyRepository(db)
+	created, err := repo.CreateReply(reply)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotEqual(t, "", created.ID, "new id must be generated")
+	assert.Equal(t, 1, created.Version, "version must start at 1")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateReplyWithOldVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reply := model.Reply{
+		ID:          "3285919c-1db4-42b8-b8a6-3cd8771dfa52",
+		Description: "Thank you",
+		Version:     1,
+	}
+	mock.ExpectPrepare("UPDATE review_reply SET (.+)").ExpectExec().
+		WithArgs(reply.Description, anyTime{}, 2, reply.ID, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewMysqlReplyRepository(db)
+	updated, err := repo.UpdateReply(reply)
+
+	assert.Nil(t, updated, "should not return reply")
+	assert.IsType(t, &bserror.DataVersionError{}, err, "should get data version error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReplyByReviews(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"review_id",
+		"author_id",
+		"description",
+		"createdtime",
+		"modifiedtime",
+		"version"}).
+		AddRow("r1", "review-1", "staff-1", "Thank you", time.Now(), time.Now(), 1).
+		AddRow("r2", "review-2", "staff-1", "Sorry to hear that", time.Now(), time.Now(), 2)
+	mock.ExpectQuery(`^SELECT (.+) FROM review_reply WHERE review_id IN \(\?, \?\)`).
+		WithArgs("review-1", "review-2").WillReturnRows(rows)
+
+	repo := NewMysqlReplyRepository(db)
+	replies, err := repo.GetReplyByReviews([]string{"review-1", "review-2"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(replies), "should get 2 replies")
+	assert.Equal(t, "review-2", replies[1].ReviewID)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReplyByNoReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewMysqlReplyRepository(db)
+	replies, err := repo.GetReplyByReviews([]string{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 0, len(replies), "should not get any reply")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
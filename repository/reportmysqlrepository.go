@@ -0,0 +1,354 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
+)
+
+// revenueGroups map group by name to key and name columns of book b, its publisher p
+// and main category c
+var revenueGroups = map[string][2]string{
+	"book":      {"b.id", "b.title"},
+	"category":  {"IFNULL(c.id, '')", "IFNULL(c.name, '')"},
+	"publisher": {"p.id", "p.name"},
+	"language":  {"b.language", "b.language"},
+}
+
+// timeBuckets map bucket name to expression of first day of bucket of time column {t},
+// weeks start on Monday
+var timeBuckets = map[string]string{
+	"":      "''",
+	"day":   "DATE_FORMAT({t}, '%Y-%m-%d')",
+	"week":  "DATE_FORMAT(DATE_SUB({t}, INTERVAL WEEKDAY({t}) DAY), '%Y-%m-%d')",
+	"month": "DATE_FORMAT({t}, '%Y-%m-01')",
+}
+
+type MysqlReportRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlReportRepository create new mysql report repository
+func NewMysqlReportRepository(db *sql.DB) *MysqlReportRepository {
+	repo := new(MysqlReportRepository)
+	repo.db = db
+	return repo
+}
+
+// GetBestSaller return books by total sold amount of sales within query range, read
+// from daily sale summary
+func (r *MysqlReportRepository) GetBestSaller(q query.SaleReportQuery) ([]report.BestSallerBook, error) {
+	rpts := []report.BestSallerBook{}
+	where, args := composeSaleWhere(q)
+	sql := `SELECT b.id, b.title, SUM(d.amount) as totalamount
+			FROM sale_daily d JOIN book b ON b.id = d.book_id JOIN publisher p ON p.id = b.publisher_id` + where + `
+			GROUP BY b.id, b.title ORDER BY totalamount DESC, b.title` + composeLimit(q.Limit, &args)
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.BestSallerBook{}
+		if err := result.Scan(&each.BookID, &each.Ttile, &each.TotalSaleAmount); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetBestSallerByCategory return categories by total sold amount of books in them or
+// in categories below them, book in several categories of a subtree is counted once.
+// Read from daily category sale summary unless sales are filtered by book attribute
+func (r *MysqlReportRepository) GetBestSallerByCategory(q query.SaleReportQuery) ([]report.BestSallerCategory, error) {
+	rpts := []report.BestSallerCategory{}
+	where, args := composeSaleWhere(q)
+	sql := `SELECT ca.id, IFNULL(ca.parent_id, ''), ca.name, SUM(d.amount) as totalamount
+			FROM category_sale_daily d JOIN category ca ON ca.id = d.category_id` + where + `
+			GROUP BY ca.id, ca.parent_id, ca.name HAVING totalamount > 0
+			ORDER BY totalamount DESC, ca.name` + composeLimit(q.Limit, &args)
+	if q.Category != "" || q.Publisher != "" {
+		sql = `SELECT ca.id, IFNULL(ca.parent_id, ''), ca.name, SUM(d.amount) as totalamount
+			FROM sale_daily d JOIN book b ON b.id = d.book_id JOIN publisher p ON p.id = b.publisher_id
+			JOIN (SELECT DISTINCT bc.book_id, cp.ancestor_id FROM book_category bc
+				JOIN category_path cp ON cp.descendant_id = bc.category_id) bca ON bca.book_id = b.id
+			JOIN category ca ON ca.id = bca.ancestor_id` + where + `
+			GROUP BY ca.id, ca.parent_id, ca.name ORDER BY totalamount DESC, ca.name` + composeLimit(q.Limit, &args)
+	}
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.BestSallerCategory{}
+		if err := result.Scan(&each.CategoryID, &each.ParentID, &each.Category, &each.TotalSaleAmount); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetRevenue return units and gross value of sales split by format, grouped by
+// book attribute and time bucket, group and bucket must be validated by caller
+func (r *MysqlReportRepository) GetRevenue(q query.RevenueReportQuery) ([]report.Revenue, error) {
+	group, ok := revenueGroups[q.GroupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown revenue group %q", q.GroupBy)
+	}
+	bucket, ok := bucketExpr(q.Bucket, "d.saledate")
+	if !ok {
+		return nil, fmt.Errorf("unknown revenue bucket %q", q.Bucket)
+	}
+	rpts := []report.Revenue{}
+	where, args := composeSaleWhere(q.SaleReportQuery)
+	sql := `SELECT ` + bucket + ` as period, ` + group[0] + ` as groupkey, ` + group[1] + ` as groupname,
+			SUM(CASE WHEN d.format = 'paperback' THEN d.amount ELSE 0 END),
+			SUM(CASE WHEN d.format = 'paperback' THEN d.revenue ELSE 0 END),
+			SUM(CASE WHEN d.format = 'ebook' THEN d.amount ELSE 0 END),
+			SUM(CASE WHEN d.format = 'ebook' THEN d.revenue ELSE 0 END)
+			FROM sale_daily d JOIN book b ON b.id = d.book_id JOIN publisher p ON p.id = b.publisher_id` +
+		primaryCategoryJoin + where + `
+			GROUP BY period, groupkey, groupname ORDER BY period, groupname` + composeLimit(q.Limit, &args)
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.Revenue{}
+		if err := result.Scan(&each.Period, &each.Group, &each.GroupName, &each.PaperbackAmount,
+			&each.PaperbackRevenue, &each.EbookAmount, &each.EbookRevenue); err != nil {
+			return nil, err
+		}
+		each.TotalRevenue = each.PaperbackRevenue + each.EbookRevenue
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetInventory return books with stock on hand, from current amount when asOf is nil
+// otherwise by summing stock movements made before asOf
+func (r *MysqlReportRepository) GetInventory(asOf *time.Time) ([]report.InventoryBook, error) {
+	amount := "IFNULL(b.currentamount, 0)"
+	join := ""
+	args := []interface{}{}
+	if asOf != nil {
+		amount = "IFNULL(SUM(m.quantity), 0)"
+		join = " LEFT JOIN stock_movement m ON m.book_id = b.id AND m.createdtime < ?"
+		args = append(args, *asOf)
+	}
+	rpts := []report.InventoryBook{}
+	sql := `SELECT b.id, b.title, IFNULL(c.name, ''), p.name, ` + amount + ` as amount, b.costprice, b.paperbackprice
+			FROM book b JOIN publisher p ON p.id = b.publisher_id` + primaryCategoryJoin + join + `
+			GROUP BY b.id, b.title, c.name, p.name, b.currentamount, b.costprice, b.paperbackprice
+			HAVING amount <> 0 ORDER BY c.name, b.title`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.InventoryBook{}
+		if err := result.Scan(&each.BookID, &each.Title, &each.Category, &each.Publisher, &each.Amount,
+			&each.CostPrice, &each.RetailPrice); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetStockAging return every book with its last paperback sale day, paperback units
+// and revenue of all formats sold since day of given time
+func (r *MysqlReportRepository) GetStockAging(since time.Time) ([]report.StockAging, error) {
+	rpts := []report.StockAging{}
+	sql := `SELECT b.id, b.title, IFNULL(c.name, ''), IFNULL(b.currentamount, 0), IFNULL(b.soldamount, 0), b.createdtime,
+			MAX(CASE WHEN d.format = 'paperback' THEN d.saledate END) as lastsale,
+			IFNULL(SUM(CASE WHEN d.format = 'paperback' AND d.saledate >= DATE(?) THEN d.amount END), 0),
+			IFNULL(SUM(CASE WHEN d.saledate >= DATE(?) THEN d.revenue END), 0)
+			FROM book b` + primaryCategoryJoin + `
+			LEFT JOIN sale_daily d ON d.book_id = b.id
+			GROUP BY b.id, b.title, c.name, b.currentamount, b.soldamount, b.createdtime`
+	result, err := r.db.Query(sql, since, since)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.StockAging{}
+		if err := result.Scan(&each.BookID, &each.Title, &each.Category, &each.CurrentAmount, &each.SoldAmount,
+			&each.CreatedTime, &each.LastSaleTime, &each.WindowSoldAmount, &each.WindowRevenue); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetWeeklySales return paperback units sold per book and week within from and to,
+// every book is returned even without sales
+func (r *MysqlReportRepository) GetWeeklySales(from time.Time, to time.Time) ([]report.WeeklySale, error) {
+	rpts := []report.WeeklySale{}
+	week, _ := bucketExpr("week", "d.saledate")
+	sql := `SELECT b.id, b.title, IFNULL(b.currentamount, 0), ` + week + ` as week,
+			IFNULL(SUM(d.amount), 0)
+			FROM book b LEFT JOIN sale_daily d ON d.book_id = b.id AND d.format = 'paperback'
+				AND d.saledate >= ? AND d.saledate < ?
+			GROUP BY b.id, b.title, b.currentamount, week ORDER BY b.id, week`
+	result, err := r.db.Query(sql, from, to)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.WeeklySale{}
+		if err := result.Scan(&each.BookID, &each.Title, &each.CurrentAmount, &each.Week, &each.Amount); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetBookReviewStats return review count and average score of every book, counting
+// reviews created within query range, read from review summary when range is open
+func (r *MysqlReportRepository) GetBookReviewStats(q query.ReviewReportQuery) ([]report.BookScore, error) {
+	conds, args := composeReviewRange(q)
+	sql := `SELECT b.id, b.title, IFNULL(c.name, ''), p.name, IFNULL(rs.reviewcount, 0),
+			IFNULL(rs.scoresum / NULLIF(rs.reviewcount, 0), 0)
+			FROM book b JOIN publisher p ON p.id = b.publisher_id` + primaryCategoryJoin + `
+			LEFT JOIN book_review_stat rs ON rs.book_id = b.id ORDER BY b.title`
+	if len(conds) > 0 {
+		sql = `SELECT b.id, b.title, IFNULL(c.name, ''), p.name, COUNT(r.id), IFNULL(AVG(r.score), 0)
+			FROM book b JOIN publisher p ON p.id = b.publisher_id` + primaryCategoryJoin + `
+			LEFT JOIN review r ON r.book_id = b.id AND ` + strings.Join(conds, " AND ") + `
+			GROUP BY b.id, b.title, c.name, p.name ORDER BY b.title`
+	}
+	rpts := []report.BookScore{}
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.BookScore{}
+		if err := result.Scan(&each.BookID, &each.Title, &each.Category, &each.Publisher, &each.ReviewCount,
+			&each.AverageScore); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// GetReviewVolume return review count and average score per time bucket within query
+// range, bucket must be validated by caller
+func (r *MysqlReportRepository) GetReviewVolume(q query.ReviewReportQuery) ([]report.ReviewVolume, error) {
+	bucket, ok := bucketExpr(q.Bucket, "r.createdtime")
+	if !ok {
+		return nil, fmt.Errorf("unknown review bucket %q", q.Bucket)
+	}
+	conds, args := composeReviewRange(q)
+	where := ""
+	if len(conds) > 0 {
+		where = " WHERE " + strings.Join(conds, " AND ")
+	}
+	rpts := []report.ReviewVolume{}
+	sql := `SELECT ` + bucket + ` as period, COUNT(r.id), AVG(r.score)
+			FROM review r` + where + `
+			GROUP BY period ORDER BY period`
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.ReviewVolume{}
+		if err := result.Scan(&each.Period, &each.ReviewCount, &each.AverageScore); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+// bucketExpr return expression of first day of bucket of the time column
+func bucketExpr(bucket string, column string) (string, bool) {
+	expr, ok := timeBuckets[bucket]
+	return strings.Replace(expr, "{t}", column, -1), ok
+}
+
+// composeReviewRange return conditions on review r created within query range
+func composeReviewRange(q query.ReviewReportQuery) ([]string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if q.From != nil {
+		conds = append(conds, "r.createdtime >= ?")
+		args = append(args, *q.From)
+	}
+	if q.To != nil {
+		conds = append(conds, "r.createdtime < ?")
+		args = append(args, *q.To)
+	}
+	return conds, args
+}
+
+// composeSaleWhere filter daily sale summary d joined with book b and publisher p, range is
+// compared by day, category filter includes categories below it
+func composeSaleWhere(q query.SaleReportQuery) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if q.From != nil {
+		conds = append(conds, "d.saledate >= DATE(?)")
+		args = append(args, *q.From)
+	}
+	if q.To != nil {
+		conds = append(conds, "d.saledate < DATE(?)")
+		args = append(args, *q.To)
+	}
+	if q.Category != "" {
+		cond, categoryArgs := categoryCondition(q.Category)
+		conds = append(conds, cond)
+		args = append(args, categoryArgs...)
+	}
+	if q.Publisher != "" {
+		conds = append(conds, "p.name = ?")
+		args = append(args, q.Publisher)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func composeLimit(limit int, args *[]interface{}) string {
+	if limit <= 0 {
+		return ""
+	}
+	*args = append(*args, limit)
+	return " LIMIT ?"
+}
@@ -0,0 +1,325 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+func TestGetBestSaller(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "title", "totalamount"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "Java in action", 100).
+		AddRow("3285919c-1db4-42b8-b8a6-3cd8771dfa52", "Nodejs is the best", 1)
+	mock.ExpectQuery("^SELECT (.+) FROM sale_daily d JOIN book b (.+) GROUP BY b.id, b.title (.+)").WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBestSaller(query.SaleReportQuery{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "Java in action", res[0].Ttile, "Incorrect book title returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBestSallerWithinRange(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	from := time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 8, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "title", "totalamount"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "Java in action", 10)
+	mock.ExpectQuery(`^SELECT (.+) FROM sale_daily d JOIN book b (.+) WHERE d.saledate >= DATE\(\?\) AND d.saledate < DATE\(\?\) `+
+		`AND EXISTS \(SELECT 1 FROM book_category fc (.+)\) AND p.name = \? (.+) LIMIT \?`).
+		WithArgs(from, to, "Programming", "Programming", "Programming", "O'Reilly", 10).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBestSaller(query.SaleReportQuery{
+		From: &from, To: &to, Category: "Programming", Publisher: "O'Reilly", Limit: 10,
+	})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 10, res[0].TotalSaleAmount, "Incorrect sold amount returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBestSallerByCategory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "parent_id", "name", "totalamount"}).
+		AddRow("computing", "", "Computing", 101).
+		AddRow("programming", "computing", "Programming", 100)
+	mock.ExpectQuery(`^SELECT ca.id, (.+), SUM\(d.amount\) (.+) FROM category_sale_daily d JOIN category ca (.+) ` +
+		`GROUP BY ca.id, ca.parent_id, ca.name (.+)`).
+		WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBestSallerByCategory(query.SaleReportQuery{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "Computing", res[0].Category, "parent category include sales of categories below it")
+	assert.Equal(t, "computing", res[1].ParentID, "parent of category must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBestSallerByCategoryOfPublisher(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "parent_id", "name", "totalamount"}).
+		AddRow("programming", "", "Programming", 12)
+	mock.ExpectQuery(`^SELECT ca.id, (.+) FROM sale_daily d JOIN book b (.+) ` +
+		`JOIN category_path cp (.+) WHERE p.name = \? GROUP BY ca.id, ca.parent_id, ca.name (.+)`).
+		WithArgs("Manning").WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBestSallerByCategory(query.SaleReportQuery{Publisher: "Manning"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 12, res[0].TotalSaleAmount, "sales of publisher are read from daily book sales")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRevenue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"period", "groupkey", "groupname", "pb", "pbrevenue", "eb", "ebrevenue"}).
+		AddRow("2019-12-01", "Programming", "Programming", 2, 200.5, 1, 50.25).
+		AddRow("2020-01-01", "Programming", "Programming", 1, 100.25, 0, 0)
+	mock.ExpectQuery(`^SELECT DATE_FORMAT\(d.saledate, '%Y-%m-01'\) as period, IFNULL\(c.id, ''\) as groupkey, (.+) ` +
+		`FROM sale_daily d JOIN book b (.+) GROUP BY period, groupkey, groupname`).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetRevenue(query.RevenueReportQuery{GroupBy: "category", Bucket: "month"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(res), "should get revenue of 2 months")
+	assert.Equal(t, 250.75, res[0].TotalRevenue, "total must be sum of paperback and ebook")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRevenueWithUnknownBucket(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	repo := NewMysqlReportRepository(db)
+	_, err = repo.GetRevenue(query.RevenueReportQuery{GroupBy: "book", Bucket: "; DROP TABLE sale"})
+
+	assert.NotNil(t, err, "should not build query from unknown bucket")
+}
+
+func TestGetInventory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "publisher", "amount", "costprice", "paperbackprice"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "Programming", "Addison-Wesley", 10, 900.0, 1353.29)
+	mock.ExpectQuery(`^SELECT (.+), IFNULL\(b.currentamount, 0\) as amount, (.+) FROM book b JOIN publisher p ON p.id = b.publisher_id (.+) GROUP BY (.+) HAVING amount <> 0`).
+		WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetInventory(nil)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 10, res[0].Amount, "amount must be current amount")
+	assert.Equal(t, 900.0, *res[0].CostPrice, "cost price must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetInventoryAsOf(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	asOf := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "publisher", "amount", "costprice", "paperbackprice"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "Programming", "Addison-Wesley", 4, nil, 1353.29)
+	mock.ExpectQuery(`^SELECT (.+), IFNULL\(SUM\(m.quantity\), 0\) as amount, (.+) ` +
+		`FROM book b JOIN publisher p ON p.id = b.publisher_id (.+) LEFT JOIN stock_movement m ON m.book_id = b.id AND m.createdtime < \? (.+)`).
+		WithArgs(asOf).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetInventory(&asOf)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 4, res[0].Amount, "amount must be replayed from movements")
+	assert.Nil(t, res[0].CostPrice, "unknown cost price must be nil")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetStockAging(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	since := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "currentamount", "soldamount", "createdtime",
+		"lastsale", "windowamount", "windowrevenue"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "Programming", 10, 4, created,
+			nil, 0, 0.0)
+	mock.ExpectQuery(`^SELECT (.+) FROM book b (.+) LEFT JOIN sale_daily d ON d.book_id = b.id GROUP BY (.+)`).
+		WithArgs(since, since).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetStockAging(since)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(res), "should get every book")
+	assert.Nil(t, res[0].LastSaleTime, "book never sold has no last sale")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetWeeklySales(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	from := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 30, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "title", "currentamount", "week", "amount"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", 10, "2019-12-09", 3).
+		AddRow("b2c3d4e5-be54-44e6-a5ef-8a0455306f4f", "NodeJS is the best", 0, nil, 0)
+	mock.ExpectQuery(`^SELECT (.+) as week, (.+) FROM book b LEFT JOIN sale_daily d (.+) GROUP BY (.+)`).
+		WithArgs(from, to).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetWeeklySales(from, to)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "2019-12-09", *res[0].Week)
+	assert.Nil(t, res[1].Week, "book without sales has no week")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBookReviewStats(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "publisher", "count", "average"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "Programming", "Addison-Wesley", 3, 4.5)
+	mock.ExpectQuery(`^SELECT (.+) FROM book b JOIN publisher p ON p.id = b.publisher_id (.+) LEFT JOIN review r ON r.book_id = b.id AND r.createdtime >= \? GROUP BY (.+)`).
+		WithArgs(from).WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBookReviewStats(query.ReviewReportQuery{From: &from})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 3, res[0].ReviewCount)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetBookReviewStatsFromSummary(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"id", "title", "category", "publisher", "count", "average"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "Programming", "Addison-Wesley", 3, 4.5)
+	mock.ExpectQuery(`^SELECT (.+) FROM book b JOIN publisher p ON p.id = b.publisher_id (.+) LEFT JOIN book_review_stat rs ON rs.book_id = b.id ORDER BY b.title`).
+		WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetBookReviewStats(query.ReviewReportQuery{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 4.5, res[0].AverageScore)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReviewVolume(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	rows := sqlmock.NewRows([]string{"period", "count", "average"}).
+		AddRow("2019-12-01", 2, 3.5).
+		AddRow("2020-01-01", 5, 4.2)
+	mock.ExpectQuery(`^SELECT DATE_FORMAT\(r.createdtime, '%Y-%m-01'\) as period, (.+) FROM review r GROUP BY period`).
+		WillReturnRows(rows)
+
+	repo := NewMysqlReportRepository(db)
+	res, err := repo.GetReviewVolume(query.ReviewReportQuery{Bucket: "month"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(res), "should get volume of 2 months")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,173 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+const reportRunColumns = `id, job, report, format, scheduledtime, startedtime, finishedtime, status, file, error`
+
+type MysqlReportRunRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlReportRunRepository create new mysql report run repository
+func NewMysqlReportRunRepository(db *sql.DB) *MysqlReportRunRepository {
+	repo := new(MysqlReportRunRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlReportRunRepository) GetRun(id string) (*model.ReportRun, error) {
+	sql := `SELECT ` + reportRunColumns + ` FROM report_run WHERE id = ?`
+	run, err := scanReportRun(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get report run id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("report run id %s is not found", id)}
+	}
+	return &run, nil
+}
+
+// GetRuns return latest runs first, of all jobs when job is empty
+func (r *MysqlReportRunRepository) GetRuns(job string, limit int) ([]model.ReportRun, error) {
+	runs := []model.ReportRun{}
+	where := ""
+	args := []interface{}{}
+	if job != "" {
+		where = " WHERE job = ?"
+		args = append(args, job)
+	}
+	sql := `SELECT ` + reportRunColumns + ` FROM report_run` + where +
+		` ORDER BY scheduledtime DESC, job` + composeLimit(limit, &args)
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query report runs error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		run, err := scanReportRun(result)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// CreateRun claim the scheduled time of the job, ConflictError is returned when
+// the job already ran at that time, possibly by another instance
+func (r *MysqlReportRunRepository) CreateRun(run model.ReportRun) (*model.ReportRun, error) {
+	now := time.Now()
+	run.ID = uuid.New().String()
+	run.StartedTime = &now
+	sql := `INSERT INTO report_run (id, job, report, format, scheduledtime, startedtime, status)
+			values(?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(sql, run.ID, run.Job, run.Report, run.Format, run.ScheduledTime, run.StartedTime, run.Status)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return r.reclaimRun(run)
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("create run of job %s error, %s", run.Job, err.Error()))
+		return nil, err
+	}
+	return &run, nil
+}
+
+// reclaimRun claim again scheduled time of the job when its run failed, so it can
+// be retried. ConflictError is returned when the run is running or done
+func (r *MysqlReportRunRepository) reclaimRun(run model.ReportRun) (*model.ReportRun, error) {
+	sql := `UPDATE report_run SET startedtime = ?, finishedtime = NULL, status = ?, file = NULL, error = NULL
+			WHERE job = ? AND scheduledtime = ? AND status = ?`
+	res, err := r.db.Exec(sql, run.StartedTime, run.Status, run.Job, run.ScheduledTime, model.RunFailed)
+	if err != nil {
+		log.Error(fmt.Sprintf("reclaim run of job %s error, %s", run.Job, err.Error()))
+		return nil, err
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		msg := fmt.Sprintf("job %s already ran at %s", run.Job, run.ScheduledTime.Format(time.RFC3339))
+		return nil, &bserror.ConflictError{Msg: msg}
+	}
+	err = r.db.QueryRow(`SELECT id FROM report_run WHERE job = ? AND scheduledtime = ?`, run.Job, run.ScheduledTime).
+		Scan(&run.ID)
+	if err != nil {
+		log.Error(fmt.Sprintf("reclaim run of job %s error, %s", run.Job, err.Error()))
+		return nil, err
+	}
+	return &run, nil
+}
+
+// UpdateRun record outcome of the run
+func (r *MysqlReportRunRepository) UpdateRun(run model.ReportRun) error {
+	sql := `UPDATE report_run SET finishedtime = ?, status = ?, file = ?, error = ? WHERE id = ?`
+	_, err := r.db.Exec(sql, run.FinishedTime, run.Status, nullString(run.File), nullString(run.Error), run.ID)
+	if err != nil {
+		log.Error(fmt.Sprintf("update report run id %s error, %s", run.ID, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// FailStaleRuns mark runs still running since before given time as failed and
+// return them, they are left by instances which stopped during the run
+func (r *MysqlReportRunRepository) FailStaleRuns(before time.Time) ([]model.ReportRun, error) {
+	sql := `SELECT ` + reportRunColumns + ` FROM report_run WHERE status = ? AND startedtime < ?`
+	result, err := r.db.Query(sql, model.RunRunning, before)
+	if err != nil {
+		log.Error("query stale report runs error", err.Error())
+		return nil, err
+	}
+	stale := []model.ReportRun{}
+	for result.Next() {
+		run, err := scanReportRun(result)
+		if err != nil {
+			result.Close()
+			return nil, err
+		}
+		stale = append(stale, run)
+	}
+	result.Close()
+
+	failed := []model.ReportRun{}
+	now := time.Now()
+	for _, run := range stale {
+		run.FinishedTime = &now
+		run.Status = model.RunFailed
+		run.Error = "run did not finish"
+		res, err := r.db.Exec(`UPDATE report_run SET finishedtime = ?, status = ?, error = ? WHERE id = ? AND status = ?`,
+			run.FinishedTime, run.Status, run.Error, run.ID, model.RunRunning)
+		if err != nil {
+			log.Error(fmt.Sprintf("fail stale report run id %s error, %s", run.ID, err.Error()))
+			return nil, err
+		}
+		if count, _ := res.RowsAffected(); count > 0 {
+			failed = append(failed, run)
+		}
+	}
+	return failed, nil
+}
+
+func scanReportRun(row rowScanner) (model.ReportRun, error) {
+	run := model.ReportRun{}
+	var file, msg sql.NullString
+	err := row.Scan(&run.ID, &run.Job, &run.Report, &run.Format, &run.ScheduledTime, &run.StartedTime,
+		&run.FinishedTime, &run.Status, &file, &msg)
+	run.File = file.String
+	run.Error = msg.String
+	return run, err
+}
+
+func nullString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
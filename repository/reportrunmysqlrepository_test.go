@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestCreateRun(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	scheduled := time.Date(2020, 1, 15, 1, 0, 0, 0, time.UTC)
+	run := model.ReportRun{Job: "daily-bestsellers", Report: "bestsallbook", Format: "csv",
+		ScheduledTime: &scheduled, Status: model.RunRunning}
+	mock.ExpectExec("INSERT INTO report_run (.+)").
+		WithArgs(anyString{}, run.Job, run.Report, run.Format, scheduled, anyTime{}, run.Status).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewMysqlReportRunRepository(db)
+	created, err := repo.CreateRun(run)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotEqual(t, "", created.ID, "new id must be generated")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateRunAlreadyClaimed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	scheduled := time.Date(2020, 1, 15, 1, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO report_run (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectExec(`UPDATE report_run SET startedtime = \?, (.+) WHERE job = \? AND scheduledtime = \? AND status = \?`).
+		WithArgs(anyTime{}, model.RunRunning, "daily-bestsellers", scheduled, model.RunFailed).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewMysqlReportRunRepository(db)
+	_, err = repo.CreateRun(model.ReportRun{Job: "daily-bestsellers", ScheduledTime: &scheduled, Status: model.RunRunning})
+
+	assert.IsType(t, &bserror.ConflictError{}, err, "should get conflict error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateRunRetryFailed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	scheduled := time.Date(2020, 1, 15, 1, 0, 0, 0, time.UTC)
+	mock.ExpectExec("INSERT INTO report_run (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectExec(`UPDATE report_run SET startedtime = \?, (.+)`).
+		WithArgs(anyTime{}, model.RunRunning, "daily-bestsellers", scheduled, model.RunFailed).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id FROM report_run WHERE job = \? AND scheduledtime = \?`).
+		WithArgs("daily-bestsellers", scheduled).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11"))
+
+	repo := NewMysqlReportRunRepository(db)
+	run, err := repo.CreateRun(model.ReportRun{Job: "daily-bestsellers", ScheduledTime: &scheduled, Status: model.RunRunning})
+
+	assert.Nil(t, err, "failed run can be claimed again")
+	assert.Equal(t, "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", run.ID, "failed run keeps its id")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestFailStaleRuns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	before := time.Date(2020, 1, 15, 1, 0, 0, 0, time.UTC)
+	scheduled := before.Add(-time.Hour)
+	rows := sqlmock.NewRows([]string{"id", "job", "report", "format", "scheduledtime", "startedtime", "finishedtime",
+		"status", "file", "error"}).
+		AddRow("first", "daily-bestsellers", "bestsallbook", "csv", scheduled, scheduled, nil, "running", nil, nil).
+		AddRow("second", "daily-revenue", "revenue", "json", scheduled, scheduled, nil, "running", nil, nil)
+	mock.ExpectQuery(`SELECT (.+) FROM report_run WHERE status = \? AND startedtime < \?`).
+		WithArgs(model.RunRunning, before).WillReturnRows(rows)
+	mock.ExpectExec(`UPDATE report_run SET finishedtime = \?, status = \?, error = \? WHERE id = \? AND status = \?`).
+		WithArgs(anyTime{}, model.RunFailed, anyString{}, "first", model.RunRunning).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE report_run SET finishedtime = \?, (.+)`).
+		WithArgs(anyTime{}, model.RunFailed, anyString{}, "second", model.RunRunning).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewMysqlReportRunRepository(db)
+	failed, err := repo.FailStaleRuns(before)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(failed), "run failed by another instance is left to it")
+	assert.Equal(t, "first", failed[0].ID)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRuns(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "job", "report", "format", "scheduledtime", "startedtime",
+		"finishedtime", "status", "file", "error"}).
+		AddRow("5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", "daily-bestsellers", "bestsallbook", "csv", now, now,
+			now, "done", "daily-bestsellers/daily-bestsellers-20200115T0100.csv", nil)
+	mock.ExpectQuery("^SELECT (.+) FROM report_run WHERE job = (.+) ORDER BY scheduledtime DESC, job LIMIT").
+		WithArgs("daily-bestsellers", 50).WillReturnRows(rows)
+
+	repo := NewMysqlReportRunRepository(db)
+	res, err := repo.GetRuns("daily-bestsellers", 50)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "daily-bestsellers/daily-bestsellers-20200115T0100.csv", res[0].File)
+	assert.Equal(t, "", res[0].Error)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"time"
+
 	"github.com/tsongpon/backend-challenge-2019/model"
 	"github.com/tsongpon/backend-challenge-2019/query"
 	"github.com/tsongpon/backend-challenge-2019/report"
@@ -11,18 +13,146 @@ type BookRepository interface {
 	GetBook(string) (*model.Book, error)
 	CreateBook(model.Book) (*model.Book, error)
 	UpdateBook(model.Book) (*model.Book, error)
+	FillBook(id string, amount int) error
 	QueryBook(query.BookQuery) ([]model.Book, error)
 	CountBook(query.BookQuery) (int, error)
 	DeleteBook(string) error
-	GetBestSaller() ([]report.BestSallerBook, error)
-	GetBestSallerByCategory() ([]report.BestSallerCategory, error)
+	SaleBook(model.Book, model.Sale) (*model.Sale, error)
+}
+
+// AuthorRepository define interface for author repository
+type AuthorRepository interface {
+	GetAuthor(string) (*model.Author, error)
+	QueryAuthor(query.AuthorQuery) ([]model.Author, error)
+	CountAuthor(query.AuthorQuery) (int, error)
+	CreateAuthor(model.Author) (*model.Author, error)
+	UpdateAuthor(model.Author) (*model.Author, error)
+	DeleteAuthor(string) error
+}
+
+// CategoryRepository define interface for category tree repository
+type CategoryRepository interface {
+	GetCategory(string) (*model.Category, error)
+	FindCategory(string) (*model.Category, error)
+	QueryCategory(query.CategoryQuery) ([]model.Category, error)
+	CreateCategory(model.Category) (*model.Category, error)
+	UpdateCategory(model.Category) (*model.Category, error)
+	DeleteCategory(string) error
+	MoveCategory(id string, parentID string) error
+	MergeCategories(targetID string, mergedIDs []string) error
+}
+
+// WorkRepository define interface for repository of works grouping editions
+type WorkRepository interface {
+	GetWork(string) (*model.Work, error)
+	QueryWork(query.WorkQuery) ([]model.Work, error)
+	CountWork(query.WorkQuery) (int, error)
+	CreateWork(model.Work) (*model.Work, error)
+	UpdateWork(model.Work) (*model.Work, error)
+	DeleteWork(string) error
+}
+
+// SeriesRepository define interface for book series repository
+type SeriesRepository interface {
+	GetSeries(string) (*model.Series, error)
+	QuerySeries(query.SeriesQuery) ([]model.Series, error)
+	CountSeries(query.SeriesQuery) (int, error)
+	CreateSeries(model.Series) (*model.Series, error)
+	UpdateSeries(model.Series) (*model.Series, error)
+	DeleteSeries(string) error
+}
+
+// TagRepository define interface for book tag repository
+type TagRepository interface {
+	AddBookTags(bookID string, tags []string) error
+	RemoveBookTag(bookID string, tag string) error
+	QueryTag(query.TagQuery) ([]model.Tag, error)
+}
+
+// PublisherRepository define interface for publisher repository
+type PublisherRepository interface {
+	GetPublisher(string) (*model.Publisher, error)
+	GetPublisherByName(string) (*model.Publisher, error)
+	QueryPublisher(query.PublisherQuery) ([]model.Publisher, error)
+	CountPublisher(query.PublisherQuery) (int, error)
+	CreatePublisher(model.Publisher) (*model.Publisher, error)
+	UpdatePublisher(model.Publisher) (*model.Publisher, error)
+	DeletePublisher(string) error
+	MergePublishers(targetID string, mergedIDs []string) error
 }
 
 // ReviewRepository define interface for review repository
 type ReviewRepository interface {
 	GetReview(string) (*model.Review, error)
-	GetReviewByBook(string) ([]model.Review, error)
+	GetReviewByBook(query.ReviewQuery) ([]model.Review, error)
+	GetReviewByReviewer(string) ([]model.Review, error)
+	GetReviewFeed(query.ReviewFeedQuery) ([]model.Review, error)
 	CreateReview(model.Review) (*model.Review, error)
 	UpdateReview(model.Review) (*model.Review, error)
+	GetReviewHistory(string) ([]model.ReviewRevision, error)
 	DeleteReview(string) error
+	CountReviewByFingerprint(fingerprint string, excludeID string) (int, error)
+	CountReviewByReviewer(reviewerID string, since time.Time) (int, error)
+	GetVerdicts(string) ([]model.ScreeningVerdict, error)
+	VoteReview(model.ReviewVote) error
+	GetSentimentMismatches(threshold float64) ([]report.SentimentMismatch, error)
+}
+
+// ReplyRepository define interface for review reply repository
+type ReplyRepository interface {
+	GetReply(string) (*model.Reply, error)
+	GetReplyByReviews([]string) ([]model.Reply, error)
+	CreateReply(model.Reply) (*model.Reply, error)
+	UpdateReply(model.Reply) (*model.Reply, error)
+	DeleteReply(string) error
+}
+
+// SaleRepository define interface for sale repository
+type SaleRepository interface {
+	HasPurchased(customerID string, bookID string) (bool, error)
+}
+
+// StockRepository define interface for stock movement repository
+type StockRepository interface {
+	CreateRestock(model.Restock) (*model.Restock, error)
+	GetRestock(string) (*model.Restock, error)
+	GetRestocks(status string) ([]model.Restock, error)
+	UpdateRestockStatus(model.Restock) (*model.Restock, error)
+}
+
+// ReportRunRepository define interface for scheduled report run repository
+type ReportRunRepository interface {
+	GetRun(string) (*model.ReportRun, error)
+	GetRuns(job string, limit int) ([]model.ReportRun, error)
+	CreateRun(model.ReportRun) (*model.ReportRun, error)
+	UpdateRun(model.ReportRun) error
+}
+
+// BestsellerRepository define interface for bestseller list repository
+type BestsellerRepository interface {
+	GetCategoryID(category string) (string, error)
+	GetCategoryIDs() ([]string, error)
+	GetFirstSaleDay(category string) (*time.Time, error)
+	GetBestsellerPeriods(category string) ([]time.Time, error)
+	GetCategorySales(category string, from time.Time, to time.Time, limit int) ([]model.BestsellerEntry, error)
+	GetLastBestsellerEntries(category string, before time.Time, bookIDs []string) ([]model.BestsellerEntry, error)
+	GetBestsellerList(category string, period time.Time) (*model.BestsellerList, error)
+	CreateBestsellerList(list model.BestsellerList) (*model.BestsellerList, error)
+}
+
+// SummaryRepository define interface for repository of summary tables reports read from
+type SummaryRepository interface {
+	RebuildSummaries() error
+}
+
+// ReportRepository define interface for report repository
+type ReportRepository interface {
+	GetBestSaller(query.SaleReportQuery) ([]report.BestSallerBook, error)
+	GetBestSallerByCategory(query.SaleReportQuery) ([]report.BestSallerCategory, error)
+	GetRevenue(query.RevenueReportQuery) ([]report.Revenue, error)
+	GetInventory(asOf *time.Time) ([]report.InventoryBook, error)
+	GetStockAging(since time.Time) ([]report.StockAging, error)
+	GetWeeklySales(from time.Time, to time.Time) ([]report.WeeklySale, error)
+	GetBookReviewStats(query.ReviewReportQuery) ([]report.BookScore, error)
+	GetReviewVolume(query.ReviewReportQuery) ([]report.ReviewVolume, error)
 }
@@ -3,14 +3,29 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/google/uuid"
 	"github.com/labstack/gommon/log"
 	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
 )
 
+// mysqlDuplicateEntry is mysql error number for unique constraint violation
+const mysqlDuplicateEntry = 1062
+
+// mysqlNoReferencedRow is mysql error number for foreign key violation on insert
+const mysqlNoReferencedRow = 1452
+
+const reviewColumns = `review.id, review.score, review.description, review.book_id,
+				IFNULL(review.reviewer_id, ''), review.verifiedpurchase, review.screeningstatus,
+				review.helpfulcount, review.unhelpfulcount, review.sentiment, review.createdtime,
+				review.modifiedtime, review.version`
+
 type MysqlReviewRepository struct {
 	db *sql.DB
 }
@@ -21,69 +36,145 @@ func NewMysqlReviewRepository(db *sql.DB) *MysqlReviewRepository {
 	return repo
 }
 
+// CreateReview insert the review with its screening verdicts and refresh review
+// summary of its book in the same transaction
 func (r *MysqlReviewRepository) CreateReview(review model.Review) (*model.Review, error) {
 	sql := `INSERT INTO review (
-		id, score, description, book_id, createdtime, modifiedtime, version
-	) values(?, ?, ?, ?, ?, ?, ?)`
-	stmt, err := r.db.Prepare(sql)
+		id, score, description, book_id, reviewer_id, verifiedpurchase, fingerprint, screeningstatus,
+		sentiment, createdtime, modifiedtime, version
+	) values(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	tx, err := r.db.Begin()
 	if err != nil {
-		log.Error("prepare sql statement error, ", err.Error())
+		log.Error("begin transaction error, ", err.Error())
 		return nil, err
 	}
-	defer stmt.Close()
 	now := time.Now()
 	review.ID = uuid.New().String()
 	review.CreatedTime = &now
 	review.ModifiedTime = &now
-	_, err = stmt.Exec(review.ID, review.Score, review.Description,
-		review.BookID, review.CreatedTime, review.ModifiedTime, 1)
+	_, err = tx.Exec(sql, review.ID, review.Score, review.Description, review.BookID, review.ReviewerID,
+		review.VerifiedPurchase, review.Fingerprint, review.ScreeningStatus, review.Sentiment,
+		review.CreatedTime, review.ModifiedTime, 1)
+	if err != nil {
+		tx.Rollback()
+	}
 
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		msg := fmt.Sprintf("reviewer %s already reviewed book id %s", review.ReviewerID, review.BookID)
+		return nil, &bserror.ConflictError{Msg: msg}
+	}
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("book id %s is not found", review.BookID)}
+	}
 	if err != nil {
 		log.Error("create book id ", review.ID, "error, ", err.Error())
 		return nil, err
 	}
+	if err := saveVerdicts(tx, review.ID, review.Verdicts); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := refreshReviewStat(tx, review.BookID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("create review of book id %s error, %s", review.BookID, err.Error()))
+		return nil, err
+	}
 	return &review, nil
 }
 
+// UpdateReview keep current revision of the review in history, then update it with
+// its screening verdicts and refresh review summary of its book, all in the same
+// transaction
 func (r *MysqlReviewRepository) UpdateReview(review model.Review) (*model.Review, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	_, err = tx.Exec(`INSERT INTO review_revision (review_id, version, score, description, modifiedtime)
+			SELECT id, version, score, description, modifiedtime FROM review WHERE id = ? AND version = ?`,
+		review.ID, review.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("save revision of review id %s error, %s", review.ID, err.Error()))
+		tx.Rollback()
+	}
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	sql := `UPDATE review SET 
 				score = ?,
 				description = ?,
-				book_id =?,
+				fingerprint = ?,
+				screeningstatus = ?,
+				sentiment = ?,
 				modifiedtime = ?,
 				version = ?
 			WHERE id = ? AND version = ?
 			`
-	stmt, err := r.db.Prepare(sql)
-	if err != nil {
-		log.Error("prepare sql statement error", err.Error())
-		return nil, err
-	}
-	defer stmt.Close()
-
 	nextVer := review.Version + 1
-	res, err := stmt.Exec(review.Score, review.Description, review.BookID, time.Now(), nextVer, review.ID, review.Version)
+	res, err := tx.Exec(sql, review.Score, review.Description, review.Fingerprint,
+		review.ScreeningStatus, review.Sentiment, time.Now(), nextVer, review.ID, review.Version)
 
 	if err != nil {
 		log.Error(fmt.Sprintf("update review id %s error, %s", review.ID, err.Error()))
+		tx.Rollback()
 		return nil, err
 	}
 	count, _ := res.RowsAffected()
 	if count == 0 {
+		tx.Rollback()
 		return nil, &bserror.DataVersionError{Msg: "data conflict"}
 	}
-
+	if err := saveVerdicts(tx, review.ID, review.Verdicts); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := refreshReviewStat(tx, review.BookID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("update review id %s error, %s", review.ID, err.Error()))
+		return nil, err
+	}
 	return &review, nil
 }
 
+// GetReviewHistory return prior revisions of given review, latest first
+func (r *MysqlReviewRepository) GetReviewHistory(reviewID string) ([]model.ReviewRevision, error) {
+	revisions := []model.ReviewRevision{}
+	sql := `SELECT review_id, version, score, description, modifiedtime FROM review_revision 
+			WHERE review_id = ? ORDER BY version DESC`
+	result, err := r.db.Query(sql, reviewID)
+	if err != nil {
+		log.Error("query review revision error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		rev := model.ReviewRevision{}
+		if err := result.Scan(&rev.ReviewID, &rev.Version, &rev.Score, &rev.Description, &rev.ModifiedTime); err != nil {
+			log.Error("query review revision error", err.Error())
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, nil
+}
+
 func (r *MysqlReviewRepository) GetReview(id string) (*model.Review, error) {
-	sql := `SELECT 
-				id, score, description, book_id, createdtime, modifiedtime, version
+	sql := `SELECT ` + reviewColumns + `
 			FROM review 
 			WHERE id = ?`
-	var review model.Review
-	err := r.db.QueryRow(sql, id).Scan(&review.ID, &review.Score, &review.Description,
-		&review.BookID, &review.CreatedTime, &review.ModifiedTime, &review.Version)
+	review, err := scanReview(r.db.QueryRow(sql, id))
 	if err != nil {
 		log.Error(fmt.Sprintf("get review id %s error, %s", id, err.Error()))
 		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("review id %s is not found", id)}
@@ -91,22 +182,123 @@ func (r *MysqlReviewRepository) GetReview(id string) (*model.Review, error) {
 	return &review, nil
 }
 
-func (r *MysqlReviewRepository) GetReviewByBook(bookID string) ([]model.Review, error) {
-	reviews := []model.Review{}
-	sql := `SELECT 
-				id, score, description, book_id, createdtime, modifiedtime, version
+// GetReviewByBook return reviews of the book, or of every edition of the work with
+// summary of their edition when work id is given, newest first
+func (r *MysqlReviewRepository) GetReviewByBook(q query.ReviewQuery) ([]model.Review, error) {
+	if q.WorkID != "" {
+		sql := `SELECT ` + reviewColumns + `, b.title, IFNULL(c.name, '')
+			FROM review JOIN book b ON b.id = review.book_id` + primaryCategoryJoin + `
+			WHERE b.work_id = ?`
+		args := []interface{}{q.WorkID}
+		if q.VerifiedPurchase != nil {
+			sql = sql + " AND review.verifiedpurchase = ?"
+			args = append(args, *q.VerifiedPurchase)
+		}
+		return r.queryReviewsWithBook(sql+" ORDER BY review.createdtime DESC, review.id", args...)
+	}
+	sql := `SELECT ` + reviewColumns + `
 			FROM review 
 			WHERE book_id = ?`
-	result, err := r.db.Query(sql, bookID)
+	args := []interface{}{q.BookID}
+	if q.VerifiedPurchase != nil {
+		sql = sql + " AND verifiedpurchase = ?"
+		args = append(args, *q.VerifiedPurchase)
+	}
+	return r.queryReviews(sql+" ORDER BY createdtime DESC, id", args...)
+}
+
+func (r *MysqlReviewRepository) GetReviewByReviewer(reviewerID string) ([]model.Review, error) {
+	sql := `SELECT ` + reviewColumns + `
+			FROM review 
+			WHERE reviewer_id = ? ORDER BY createdtime DESC`
+	return r.queryReviews(sql, reviewerID)
+}
+
+// GetReviewFeed return reviews across all books with summary of their book,
+// newest first, paged by position of the last review of previous page
+func (r *MysqlReviewRepository) GetReviewFeed(q query.ReviewFeedQuery) ([]model.Review, error) {
+	sql := `SELECT ` + reviewColumns + `, b.title, IFNULL(c.name, '')
+			FROM review JOIN book b ON b.id = review.book_id` + primaryCategoryJoin
+	where, args := composeFeedWhere(q)
+	sql = sql + where + " ORDER BY review.createdtime DESC, review.id DESC LIMIT ?"
+	args = append(args, q.Limit)
+	return r.queryReviewsWithBook(sql, args...)
+}
+
+// queryReviewsWithBook query reviews followed by title and category of their book
+func (r *MysqlReviewRepository) queryReviewsWithBook(sql string, args ...interface{}) ([]model.Review, error) {
+	reviews := []model.Review{}
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query reviews with book error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		rev := model.Review{Book: &model.BookSummary{}}
+		err := result.Scan(&rev.ID, &rev.Score, &rev.Description, &rev.BookID, &rev.ReviewerID,
+			&rev.VerifiedPurchase, &rev.ScreeningStatus, &rev.HelpfulCount, &rev.UnhelpfulCount,
+			&rev.Sentiment, &rev.CreatedTime, &rev.ModifiedTime, &rev.Version, &rev.Book.Title, &rev.Book.Category)
+		if err != nil {
+			log.Error("query reviews with book error", err.Error())
+			return nil, err
+		}
+		rev.Book.ID = rev.BookID
+		reviews = append(reviews, rev)
+	}
+	return reviews, nil
+}
+
+func composeFeedWhere(q query.ReviewFeedQuery) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if q.MinScore > 0 {
+		conds = append(conds, "review.score >= ?")
+		args = append(args, q.MinScore)
+	}
+	if q.MaxScore > 0 {
+		conds = append(conds, "review.score <= ?")
+		args = append(args, q.MaxScore)
+	}
+	if q.From != nil {
+		conds = append(conds, "review.createdtime >= ?")
+		args = append(args, *q.From)
+	}
+	if q.To != nil {
+		conds = append(conds, "review.createdtime < ?")
+		args = append(args, *q.To)
+	}
+	if q.Category != "" {
+		cond, categoryArgs := categoryCondition(q.Category)
+		conds = append(conds, cond)
+		args = append(args, categoryArgs...)
+	}
+	if q.ScreeningStatus != "" {
+		conds = append(conds, "review.screeningstatus = ?")
+		args = append(args, q.ScreeningStatus)
+	}
+	if q.AfterTime != nil {
+		conds = append(conds, "(review.createdtime < ? OR (review.createdtime = ? AND review.id < ?))")
+		args = append(args, *q.AfterTime, *q.AfterTime, q.AfterID)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *MysqlReviewRepository) queryReviews(sql string, args ...interface{}) ([]model.Review, error) {
+	reviews := []model.Review{}
+	result, err := r.db.Query(sql, args...)
 	if err != nil {
 		log.Error("query review error", err.Error())
 		return nil, err
 	}
+	defer result.Close()
 
 	for result.Next() {
-		rev := model.Review{}
-		err := result.Scan(&rev.ID, &rev.Score, &rev.Description, &rev.BookID,
-			&rev.CreatedTime, &rev.ModifiedTime, &rev.Version)
+		rev, err := scanReview(result)
 		if err != nil {
 			log.Error("query reviews error", err.Error())
 			return nil, err
@@ -116,19 +308,184 @@ func (r *MysqlReviewRepository) GetReviewByBook(bookID string) ([]model.Review,
 	return reviews, nil
 }
 
+// DeleteReview delete the review and refresh review summary of its book in the
+// same transaction
 func (r *MysqlReviewRepository) DeleteReview(id string) error {
-	sql := "DELETE FROM review WHERE id = ?"
-	stmt, err := r.db.Prepare(sql)
+	tx, err := r.db.Begin()
 	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	var bookID string
+	err = tx.QueryRow("SELECT IFNULL(book_id, '') FROM review WHERE id = ?", id).Scan(&bookID)
+	if err == sql.ErrNoRows {
+		tx.Rollback()
+		return nil
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete review id %s error, %s", id, err.Error()))
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM review WHERE id = ?", id); err != nil {
 		log.Error(fmt.Sprintf("delete review id %s error, %s", id, err.Error()))
+		tx.Rollback()
 		return err
 	}
-	defer stmt.Close()
+	if err := refreshReviewStat(tx, bookID); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
 
-	_, err = stmt.Exec(id)
+func (r *MysqlReviewRepository) CountReviewByFingerprint(fingerprint string, excludeID string) (int, error) {
+	sql := "SELECT COUNT(id) as count FROM review WHERE fingerprint = ? AND id <> ?"
+	var c int
+	err := r.db.QueryRow(sql, fingerprint, excludeID).Scan(&c)
 	if err != nil {
-		log.Error(fmt.Sprintf("delete review id %s error, %s", id, err.Error()))
+		log.Error("count review by fingerprint error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+// CountReviewByReviewer count reviews the reviewer posted since given time
+func (r *MysqlReviewRepository) CountReviewByReviewer(reviewerID string, since time.Time) (int, error) {
+	sql := "SELECT COUNT(id) as count FROM review WHERE reviewer_id = ? AND createdtime >= ?"
+	var c int
+	err := r.db.QueryRow(sql, reviewerID, since).Scan(&c)
+	if err != nil {
+		log.Error("count review by reviewer error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+func (r *MysqlReviewRepository) GetVerdicts(reviewID string) ([]model.ScreeningVerdict, error) {
+	verdicts := []model.ScreeningVerdict{}
+	sql := `SELECT rule, verdict, reason FROM review_verdict WHERE review_id = ? ORDER BY rule`
+	result, err := r.db.Query(sql, reviewID)
+	if err != nil {
+		log.Error("query review verdict error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		v := model.ScreeningVerdict{}
+		if err := result.Scan(&v.Rule, &v.Verdict, &v.Reason); err != nil {
+			log.Error("query review verdict error", err.Error())
+			return nil, err
+		}
+		verdicts = append(verdicts, v)
+	}
+	return verdicts, nil
+}
+
+// saveVerdicts replace screening verdicts of given review within transaction
+func saveVerdicts(tx *sql.Tx, reviewID string, verdicts []model.ScreeningVerdict) error {
+	if _, err := tx.Exec("DELETE FROM review_verdict WHERE review_id = ?", reviewID); err != nil {
+		log.Error(fmt.Sprintf("delete verdicts of review id %s error, %s", reviewID, err.Error()))
 		return err
 	}
+	now := time.Now()
+	for _, v := range verdicts {
+		_, err := tx.Exec(`INSERT INTO review_verdict (review_id, rule, verdict, reason, createdtime) 
+			values(?, ?, ?, ?, ?)`, reviewID, v.Rule, v.Verdict, v.Reason, now)
+		if err != nil {
+			log.Error(fmt.Sprintf("save verdicts of review id %s error, %s", reviewID, err.Error()))
+			return err
+		}
+	}
 	return nil
 }
+
+// VoteReview record vote of a voter and keep helpful counts on review in sync,
+// voting again replace previous vote of the same voter
+func (r *MysqlReviewRepository) VoteReview(vote model.ReviewVote) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	now := time.Now()
+	var previous bool
+	err = tx.QueryRow(`SELECT helpful FROM review_vote WHERE review_id = ? AND voter_id = ? FOR UPDATE`,
+		vote.ReviewID, vote.VoterID).Scan(&previous)
+	if err == nil && previous == vote.Helpful {
+		return tx.Commit()
+	}
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(`INSERT INTO review_vote (review_id, voter_id, helpful, createdtime, modifiedtime) 
+			values(?, ?, ?, ?, ?)`, vote.ReviewID, vote.VoterID, vote.Helpful, now, now)
+		if err == nil {
+			_, err = tx.Exec(fmt.Sprintf("UPDATE review SET %[1]s = %[1]s + 1 WHERE id = ?",
+				voteCountColumn(vote.Helpful)), vote.ReviewID)
+		}
+	} else if err == nil {
+		_, err = tx.Exec(`UPDATE review_vote SET helpful = ?, modifiedtime = ? WHERE review_id = ? AND voter_id = ?`,
+			vote.Helpful, now, vote.ReviewID, vote.VoterID)
+		if err == nil {
+			_, err = tx.Exec(fmt.Sprintf("UPDATE review SET %[1]s = %[1]s + 1, %[2]s = %[2]s - 1 WHERE id = ?",
+				voteCountColumn(vote.Helpful), voteCountColumn(previous)), vote.ReviewID)
+		}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("vote review id %s error, %s", vote.ReviewID, err.Error()))
+		tx.Rollback()
+	}
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlDuplicateEntry {
+		msg := fmt.Sprintf("voter %s voted on review id %s at the same time, please try again", vote.VoterID, vote.ReviewID)
+		return &bserror.ConflictError{Msg: msg}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSentimentMismatches return reviews which star score, scaled to -1..1, and
+// text sentiment are at least threshold apart, biggest gap first
+func (r *MysqlReviewRepository) GetSentimentMismatches(threshold float64) ([]report.SentimentMismatch, error) {
+	rpts := []report.SentimentMismatch{}
+	sql := `SELECT b.id, b.title, r.id, r.score, r.sentiment, ABS((r.score - 3) / 2 - r.sentiment) as gap
+			FROM review r JOIN book b ON b.id = r.book_id
+			WHERE r.sentiment IS NOT NULL AND ABS((r.score - 3) / 2 - r.sentiment) >= ?
+			ORDER BY gap DESC`
+	result, err := r.db.Query(sql, threshold)
+	if err != nil {
+		log.Error("query report error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		each := report.SentimentMismatch{}
+		if err := result.Scan(&each.BookID, &each.Title, &each.ReviewID, &each.Score, &each.Sentiment,
+			&each.Gap); err != nil {
+			return nil, err
+		}
+		rpts = append(rpts, each)
+	}
+	return rpts, nil
+}
+
+func voteCountColumn(helpful bool) string {
+	if helpful {
+		return "helpfulcount"
+	}
+	return "unhelpfulcount"
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanReview(row rowScanner) (model.Review, error) {
+	rev := model.Review{}
+	err := row.Scan(&rev.ID, &rev.Score, &rev.Description, &rev.BookID, &rev.ReviewerID,
+		&rev.VerifiedPurchase, &rev.ScreeningStatus, &rev.HelpfulCount, &rev.UnhelpfulCount,
+		&rev.Sentiment, &rev.CreatedTime, &rev.ModifiedTime, &rev.Version)
+	return rev, err
+}
@@ -1,12 +1,16 @@
 package repository
 
 import (
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
 )
 
 func TestCreateReview(t *testing.T) {
@@ -18,14 +22,27 @@ func TestCreateReview(t *testing.T) {
 
 	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
 	rev := model.Review{
-		Score:       5,
-		Description: "Very good",
-		BookID:      bookID,
+		Score:            5,
+		Description:      "Very good",
+		BookID:           bookID,
+		ReviewerID:       "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		VerifiedPurchase: true,
+		Fingerprint:      "1f4e5c8e0e0ec4fd2ac4dc1d4ff6b2c1b0a4c3f8",
+		ScreeningStatus:  "passed",
+		Verdicts:         []model.ScreeningVerdict{{Rule: "link", Verdict: "passed"}},
 	}
 
-	mock.ExpectPrepare("INSERT INTO review (.+) ").ExpectExec().
-		WithArgs(anyString{}, rev.Score, rev.Description, rev.BookID,
-			anyTime{}, anyTime{}, 1).WillReturnResult((sqlmock.NewResult(0, 1)))
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review (.+) ").
+		WithArgs(anyString{}, rev.Score, rev.Description, rev.BookID, rev.ReviewerID, rev.VerifiedPurchase,
+			rev.Fingerprint, rev.ScreeningStatus, rev.Sentiment, anyTime{}, anyTime{}, 1).WillReturnResult((sqlmock.NewResult(0, 1)))
+	mock.ExpectExec("DELETE FROM review_verdict (.+)").WithArgs(anyString{}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO review_verdict (.+)").
+		WithArgs(anyString{}, "link", "passed", "", anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_review_stat (.+) SELECT (.+) FROM review WHERE book_id = (.+)").
+		WithArgs(bookID, bookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	repo := NewMysqlReviewRepository(db)
 	created, err := repo.CreateReview(rev)
@@ -53,6 +70,12 @@ func TestGetReview(t *testing.T) {
 		"score",
 		"description",
 		"book_id",
+		"reviewer_id",
+		"verifiedpurchase",
+		"screeningstatus",
+		"helpfulcount",
+		"unhelpfulcount",
+		"sentiment",
 		"createdtime",
 		"modifiedtime",
 		"version"}).
@@ -61,6 +84,12 @@ func TestGetReview(t *testing.T) {
 			4,
 			"Good book",
 			"a432eee1-be54-44e6-a5ef-8a0455306f4f",
+			"c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+			true,
+			"passed",
+			3,
+			1,
+			0.6,
 			time.Now(),
 			time.Now(),
 			1)
@@ -73,6 +102,73 @@ func TestGetReview(t *testing.T) {
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, revID, res.ID, "book ID must be "+revID)
 	assert.Equal(t, 4, res.Score, "score must be 4")
+	assert.Equal(t, "passed", res.ScreeningStatus, "screening status must be passed")
+	assert.Equal(t, "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", res.ReviewerID, "reviewer must be returned")
+	assert.Equal(t, 3, res.HelpfulCount, "helpful count must be 3")
+	assert.Equal(t, 1, res.UnhelpfulCount, "unhelpful count must be 1")
+	assert.Equal(t, 0.6, *res.Sentiment, "sentiment must be 0.6")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateDuplicatedReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rev := model.Review{
+		Score:       5,
+		Description: "Very good",
+		BookID:      "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		ReviewerID:  "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review (.+) ").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	created, err := repo.CreateReview(rev)
+
+	assert.Nil(t, created, "should not create second review")
+	assert.IsType(t, &bserror.ConflictError{}, err, "should get conflict error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateReviewOfMissingBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rev := model.Review{
+		Score:       5,
+		Description: "Very good",
+		BookID:      "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		ReviewerID:  "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review (.+) ").
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	created, err := repo.CreateReview(rev)
+
+	assert.Nil(t, created, "should not create review")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -92,19 +188,37 @@ func TestUpdateReview(t *testing.T) {
 	modelVersion := 1
 	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
 	rev := model.Review{
-		ID:           revID,
-		Score:        4,
-		Description:  "All you nee to known about golang",
-		BookID:       "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		ID:              revID,
+		Score:           4,
+		Description:     "All you nee to known about golang",
+		BookID:          "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		Fingerprint:     "1f4e5c8e0e0ec4fd2ac4dc1d4ff6b2c1b0a4c3f8",
+		ScreeningStatus: "flagged",
+		Verdicts: []model.ScreeningVerdict{
+			{Rule: "banned_word", Verdict: "passed"},
+			{Rule: "link", Verdict: "flagged", Reason: "contains link"},
+		},
 		CreatedTime:  &createdTime,
 		ModifiedTime: &modifiedTime,
 		Version:      modelVersion,
 	}
 
-	mock.ExpectPrepare("UPDATE review (.+) ").ExpectExec().
-		WithArgs(rev.Score, rev.Description, rev.BookID,
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review_revision (.+) SELECT (.+) FROM review (.+)").
+		WithArgs(rev.ID, modelVersion).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE review (.+) ").
+		WithArgs(rev.Score, rev.Description, rev.Fingerprint, rev.ScreeningStatus, rev.Sentiment,
 			anyTime{}, modelVersion+1, rev.ID, modelVersion).
 		WillReturnResult((sqlmock.NewResult(1, 1)))
+	mock.ExpectExec("DELETE FROM review_verdict (.+)").WithArgs(revID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO review_verdict (.+)").
+		WithArgs(revID, "banned_word", "passed", "", anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO review_verdict (.+)").
+		WithArgs(revID, "link", "flagged", "contains link", anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO book_review_stat (.+)").
+		WithArgs(rev.BookID, rev.BookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	repo := NewMysqlReviewRepository(db)
 	updated, err := repo.UpdateReview(rev)
@@ -131,6 +245,12 @@ func TestGetRevireByBookID(t *testing.T) {
 		"score",
 		"description",
 		"book_id",
+		"reviewer_id",
+		"verifiedpurchase",
+		"screeningstatus",
+		"helpfulcount",
+		"unhelpfulcount",
+		"sentiment",
 		"createdtime",
 		"modifiedtime",
 		"version"}).
@@ -139,18 +259,124 @@ func TestGetRevireByBookID(t *testing.T) {
 			4,
 			"Good!",
 			bookID,
+			"",
+			true,
+			"flagged",
+			3,
+			1,
+			0.6,
 			time.Now(),
 			time.Now(),
 			1)
-	mock.ExpectQuery(`^SELECT (.+) FROM review (.+)`).
-		WithArgs(bookID).WillReturnRows(rows)
+	mock.ExpectQuery(`^SELECT (.+) FROM review WHERE book_id = (.+) AND verifiedpurchase = \? ORDER BY createdtime DESC, id`).
+		WithArgs(bookID, true).WillReturnRows(rows)
 
+	verified := true
 	repo := NewMysqlReviewRepository(db)
-	reviews, err := repo.GetReviewByBook(bookID)
+	reviews, err := repo.GetReviewByBook(query.ReviewQuery{BookID: bookID, VerifiedPurchase: &verified})
 
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, 1, len(reviews), "should have only one review")
 	assert.Equal(t, bookID, reviews[0].BookID, "review ust belong to given book id")
+	assert.True(t, reviews[0].VerifiedPurchase, "review must be verified purchase")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReviewByReviewer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	reviewerID := "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"score",
+		"description",
+		"book_id",
+		"reviewer_id",
+		"verifiedpurchase",
+		"screeningstatus",
+		"helpfulcount",
+		"unhelpfulcount",
+		"sentiment",
+		"createdtime",
+		"modifiedtime",
+		"version"}).
+		AddRow(
+			"a432eee1-be54-44e6-a5ef-8a0455306f4f",
+			4,
+			"Good!",
+			"3285919c-1db4-42b8-b8a6-3cd8771dfa52",
+			reviewerID,
+			false,
+			"passed",
+			3,
+			1,
+			0.6,
+			time.Now(),
+			time.Now(),
+			1)
+	mock.ExpectQuery(`^SELECT (.+) FROM review WHERE reviewer_id = (.+)`).
+		WithArgs(reviewerID).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	reviews, err := repo.GetReviewByReviewer(reviewerID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(reviews), "should have only one review")
+	assert.Equal(t, reviewerID, reviews[0].ReviewerID, "review must belong to given reviewer")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReviewByWork(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	workID := "5b6c7d8e-9f0a-4b1c-8d2e-3f4a5b6c7d8e"
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"score",
+		"description",
+		"book_id",
+		"reviewer_id",
+		"verifiedpurchase",
+		"screeningstatus",
+		"helpfulcount",
+		"unhelpfulcount",
+		"sentiment",
+		"createdtime",
+		"modifiedtime",
+		"version",
+		"title",
+		"category"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", 4, "Good!", "first-edition", "",
+			true, "passed", 0, 0, nil, time.Now(), time.Now(), 1, "SICP", "Programming").
+		AddRow("0c9d8e7f-6a5b-4c3d-2e1f-0a9b8c7d6e5f", 5, "Better!", "second-edition", "",
+			true, "passed", 0, 0, nil, time.Now(), time.Now(), 1, "SICP, 2nd Edition", "Programming")
+	mock.ExpectQuery(`^SELECT (.+) FROM review JOIN book b (.+)\s+WHERE b.work_id = \? AND review.verifiedpurchase = \? `+
+		`ORDER BY review.createdtime DESC, review.id`).
+		WithArgs(workID, true).WillReturnRows(rows)
+
+	verified := true
+	repo := NewMysqlReviewRepository(db)
+	reviews, err := repo.GetReviewByBook(query.ReviewQuery{WorkID: workID, VerifiedPurchase: &verified})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(reviews), "reviews of every edition must be returned")
+	assert.Equal(t, "second-edition", reviews[1].Book.ID, "edition of review must be returned")
 
 	// we make sure that all expectations were met
 	if err := mock.ExpectationsWereMet(); err != nil {
@@ -166,8 +392,15 @@ func TestDeleteReview(t *testing.T) {
 	defer db.Close()
 
 	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
-	mock.ExpectPrepare("DELETE FROM review (.+) ").ExpectExec().
+	bookID := "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM review WHERE id = ?").
+		WithArgs(revID).WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(bookID))
+	mock.ExpectExec("DELETE FROM review (.+)").
 		WithArgs(revID).WillReturnResult((sqlmock.NewResult(0, 1)))
+	mock.ExpectExec("INSERT INTO book_review_stat (.+)").
+		WithArgs(bookID, bookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
 
 	repo := NewMysqlReviewRepository(db)
 	err = repo.DeleteReview(revID)
@@ -179,3 +412,386 @@ func TestDeleteReview(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestDeleteMissingReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mock.ExpectBegin()
+	mock.ExpectQuery("^SELECT (.+) FROM review WHERE id = ?").
+		WithArgs(revID).WillReturnRows(sqlmock.NewRows([]string{"book_id"}))
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	err = repo.DeleteReview(revID)
+
+	assert.Nil(t, err, "deleting missing review is not an error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountReviewByFingerprint(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	fingerprint := "1f4e5c8e0e0ec4fd2ac4dc1d4ff6b2c1b0a4c3f8"
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(2)
+	mock.ExpectQuery(`^SELECT COUNT(.+) FROM review WHERE fingerprint = (.+)`).
+		WithArgs(fingerprint, revID).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	c, err := repo.CountReviewByFingerprint(fingerprint, revID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, c, "expected 2 from count")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetVerdicts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	rows := sqlmock.NewRows([]string{"rule", "verdict", "reason"}).
+		AddRow("banned_word", "passed", "").
+		AddRow("link", "flagged", "contains link")
+	mock.ExpectQuery(`^SELECT (.+) FROM review_verdict (.+)`).
+		WithArgs(revID).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	verdicts, err := repo.GetVerdicts(revID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(verdicts), "should have 2 verdicts")
+	assert.Equal(t, "flagged", verdicts[1].Verdict, "link rule must be flagged")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateReviewRollbackOnVerdictError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rev := model.Review{
+		Score:    5,
+		BookID:   "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		Verdicts: []model.ScreeningVerdict{{Rule: "link", Verdict: "passed"}},
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review (.+) ").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM review_verdict (.+)").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO review_verdict (.+)").WillReturnError(errors.New("connection lost"))
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	created, err := repo.CreateReview(rev)
+
+	assert.Nil(t, created, "review must not be created without its verdicts")
+	assert.NotNil(t, err, "should get error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountReviewByReviewer(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	since := time.Now().Add(-time.Hour)
+	mock.ExpectQuery(`SELECT COUNT\(id\) (.+) FROM review WHERE reviewer_id = \? AND createdtime >= \?`).
+		WithArgs("c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", since).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	repo := NewMysqlReviewRepository(db)
+	count, err := repo.CountReviewByReviewer("c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", since)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 3, count, "count must be 3")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestVoteReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	vote := model.ReviewVote{ReviewID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", VoterID: "voter-1", Helpful: true}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT helpful FROM review_vote (.+) FOR UPDATE").
+		WithArgs(vote.ReviewID, vote.VoterID).WillReturnRows(sqlmock.NewRows([]string{"helpful"}))
+	mock.ExpectExec("INSERT INTO review_vote (.+)").
+		WithArgs(vote.ReviewID, vote.VoterID, true, anyTime{}, anyTime{}).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE review SET helpfulcount = helpfulcount \+ 1 WHERE id = \?`).
+		WithArgs(vote.ReviewID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlReviewRepository(db)
+	err = repo.VoteReview(vote)
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestConcurrentFirstVoteReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	vote := model.ReviewVote{ReviewID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", VoterID: "voter-1", Helpful: true}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT helpful FROM review_vote (.+) FOR UPDATE").
+		WithArgs(vote.ReviewID, vote.VoterID).WillReturnRows(sqlmock.NewRows([]string{"helpful"}))
+	mock.ExpectExec("INSERT INTO review_vote (.+)").
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	err = repo.VoteReview(vote)
+
+	assert.IsType(t, &bserror.ConflictError{}, err, "should get conflict error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestChangeVoteReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	vote := model.ReviewVote{ReviewID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", VoterID: "voter-1", Helpful: false}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT helpful FROM review_vote (.+) FOR UPDATE").
+		WithArgs(vote.ReviewID, vote.VoterID).WillReturnRows(sqlmock.NewRows([]string{"helpful"}).AddRow(true))
+	mock.ExpectExec("UPDATE review_vote SET (.+)").
+		WithArgs(false, anyTime{}, vote.ReviewID, vote.VoterID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE review SET unhelpfulcount = unhelpfulcount \+ 1, helpfulcount = helpfulcount - 1 (.+)`).
+		WithArgs(vote.ReviewID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlReviewRepository(db)
+	err = repo.VoteReview(vote)
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateReviewWithOldVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rev := model.Review{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Score: 4, Description: "Good", Version: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review_revision (.+)").
+		WithArgs(rev.ID, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE review (.+) ").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	updated, err := repo.UpdateReview(rev)
+
+	assert.Nil(t, updated, "should not return review")
+	assert.IsType(t, &bserror.DataVersionError{}, err, "should get data version error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestConcurrentUpdateReview(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rev := model.Review{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Score: 4, Description: "Good", Version: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO review_revision (.+)").
+		WithArgs(rev.ID, 1).WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	mock.ExpectRollback()
+
+	repo := NewMysqlReviewRepository(db)
+	updated, err := repo.UpdateReview(rev)
+
+	assert.Nil(t, updated, "should not return review")
+	assert.IsType(t, &bserror.DataVersionError{}, err, "should get data version error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReviewHistory(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	revID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	rows := sqlmock.NewRows([]string{"review_id", "version", "score", "description", "modifiedtime"}).
+		AddRow(revID, 2, 3, "Not bad", time.Now()).
+		AddRow(revID, 1, 1, "Bad", time.Now())
+	mock.ExpectQuery("^SELECT (.+) FROM review_revision (.+)").WithArgs(revID).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	revisions, err := repo.GetReviewHistory(revID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(revisions), "should get 2 revisions")
+	assert.Equal(t, 2, revisions[0].Version, "latest revision should come first")
+	assert.Equal(t, "Bad", revisions[1].Description)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetSentimentMismatches(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "id", "score", "sentiment", "gap"}).
+		AddRow("a432eee1-be54-44e6-a5ef-8a0455306f4f", "The Go Programming", "3285919c-1db4-42b8-b8a6-3cd8771dfa52",
+			5, -0.8, 1.8)
+	mock.ExpectQuery("^SELECT (.+) FROM review r JOIN book b (.+) WHERE r.sentiment IS NOT NULL (.+) ORDER BY gap DESC").
+		WithArgs(1.0).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	rpts, err := repo.GetSentimentMismatches(1.0)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(rpts), "should get 1 mismatch")
+	assert.Equal(t, 5, rpts[0].Score)
+	assert.Equal(t, 1.8, rpts[0].Gap)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetReviewFeed(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	after := time.Date(2019, 12, 31, 10, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows([]string{
+		"id",
+		"score",
+		"description",
+		"book_id",
+		"reviewer_id",
+		"verifiedpurchase",
+		"screeningstatus",
+		"helpfulcount",
+		"unhelpfulcount",
+		"sentiment",
+		"createdtime",
+		"modifiedtime",
+		"version",
+		"title",
+		"category"}).
+		AddRow(
+			"a432eee1-be54-44e6-a5ef-8a0455306f4f",
+			1,
+			"Bad",
+			"3285919c-1db4-42b8-b8a6-3cd8771dfa52",
+			"",
+			false,
+			"flagged",
+			0,
+			0,
+			nil,
+			time.Now(),
+			time.Now(),
+			1,
+			"The Go Programming",
+			"Programming")
+	mock.ExpectQuery(`^SELECT (.+) FROM review JOIN book b (.+) WHERE review.score <= \? AND EXISTS \(SELECT 1 FROM book_category fc (.+)\) `+
+		`AND review.screeningstatus = \? AND \(review.createdtime < \? OR (.+)\) ORDER BY (.+) LIMIT \?`).
+		WithArgs(2, "Programming", "Programming", "Programming", "flagged", after, after, "review-9", 21).WillReturnRows(rows)
+
+	repo := NewMysqlReviewRepository(db)
+	reviews, err := repo.GetReviewFeed(query.ReviewFeedQuery{
+		MaxScore:        2,
+		Category:        "Programming",
+		ScreeningStatus: "flagged",
+		AfterTime:       &after,
+		AfterID:         "review-9",
+		Limit:           21,
+	})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(reviews), "should have only one review")
+	assert.Equal(t, "The Go Programming", reviews[0].Book.Title, "book summary must be returned")
+	assert.Equal(t, reviews[0].BookID, reviews[0].Book.ID, "book summary must be of review's book")
+	assert.Nil(t, reviews[0].Sentiment, "review before sentiment scoring has no sentiment")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
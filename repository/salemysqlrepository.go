@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+type MysqlSaleRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlSaleRepository create new mysql sale repository
+func NewMysqlSaleRepository(db *sql.DB) *MysqlSaleRepository {
+	repo := new(MysqlSaleRepository)
+	repo.db = db
+	return repo
+}
+
+// createSale record a sale and add it to daily sale summary, existing review of
+// the customer on the book is marked as verified purchase in the same transaction
+func createSale(tx *sql.Tx, s model.Sale) (*model.Sale, error) {
+	now := time.Now()
+	s.ID = uuid.New().String()
+	s.CreatedTime = &now
+
+	var customerID interface{}
+	if s.CustomerID != "" {
+		customerID = s.CustomerID
+	}
+	_, err := tx.Exec(`INSERT INTO sale (id, book_id, customer_id, format, amount, unitprice, createdtime)
+			values(?, ?, ?, ?, ?, ?, ?)`, s.ID, s.BookID, customerID, s.Format, s.Amount, s.UnitPrice, s.CreatedTime)
+	if err != nil {
+		log.Error(fmt.Sprintf("create sale of book id %s error, %s", s.BookID, err.Error()))
+		return nil, err
+	}
+	if err := addSaleDaily(tx, s); err != nil {
+		return nil, err
+	}
+	if s.CustomerID != "" {
+		res, err := tx.Exec(`UPDATE review SET verifiedpurchase = 1 WHERE book_id = ? AND reviewer_id = ?`,
+			s.BookID, s.CustomerID)
+		if err != nil {
+			log.Error(fmt.Sprintf("mark verified review of book id %s error, %s", s.BookID, err.Error()))
+			return nil, err
+		}
+		if count, _ := res.RowsAffected(); count > 0 {
+			if err := refreshReviewStat(tx, s.BookID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &s, nil
+}
+
+// HasPurchased return true when customer ever bought the book
+func (r *MysqlSaleRepository) HasPurchased(customerID string, bookID string) (bool, error) {
+	sql := "SELECT COUNT(id) as count FROM sale WHERE book_id = ? AND customer_id = ?"
+	var c int
+	if err := r.db.QueryRow(sql, bookID, customerID).Scan(&c); err != nil {
+		log.Error("count sale error, ", err.Error())
+		return false, err
+	}
+	return c > 0, nil
+}
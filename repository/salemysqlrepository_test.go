@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasPurchased(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	customerID := "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"
+	rows := sqlmock.NewRows([]string{"count"}).AddRow(1)
+	mock.ExpectQuery(`^SELECT COUNT(.+) FROM sale WHERE (.+)`).
+		WithArgs(bookID, customerID).WillReturnRows(rows)
+
+	repo := NewMysqlSaleRepository(db)
+	purchased, err := repo.HasPurchased(customerID, bookID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.True(t, purchased, "customer has bought the book")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+const seriesColumns = `s.id, s.name, (SELECT COUNT(b.id) FROM book b WHERE b.series_id = s.id),
+				s.createdtime, s.modifiedtime, s.version`
+
+type MysqlSeriesRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlSeriesRepository create new mysql series repository
+func NewMysqlSeriesRepository(db *sql.DB) *MysqlSeriesRepository {
+	repo := new(MysqlSeriesRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlSeriesRepository) GetSeries(id string) (*model.Series, error) {
+	sql := `SELECT ` + seriesColumns + ` FROM series s WHERE s.id = ?`
+	s, err := scanSeries(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get series id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("series id %s is not found", id)}
+	}
+	return &s, nil
+}
+
+// QuerySeries return series ordered by name
+func (r *MysqlSeriesRepository) QuerySeries(q query.SeriesQuery) ([]model.Series, error) {
+	series := []model.Series{}
+	where, args := composeSeriesWhere(q)
+	sql := `SELECT ` + seriesColumns + ` FROM series s` + where + ` ORDER BY s.name, s.id LIMIT ? OFFSET ?`
+	result, err := r.db.Query(sql, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		log.Error("query series error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		s, err := scanSeries(result)
+		if err != nil {
+			log.Error("query series error", err.Error())
+			return nil, err
+		}
+		series = append(series, s)
+	}
+	return series, nil
+}
+
+func (r *MysqlSeriesRepository) CountSeries(q query.SeriesQuery) (int, error) {
+	where, args := composeSeriesWhere(q)
+	sql := "SELECT COUNT(s.id) as count FROM series s" + where
+	var c int
+	if err := r.db.QueryRow(sql, args...).Scan(&c); err != nil {
+		log.Error("count series error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+func (r *MysqlSeriesRepository) CreateSeries(s model.Series) (*model.Series, error) {
+	sql := `INSERT INTO series (id, name, createdtime, modifiedtime, version) values(?, ?, ?, ?, ?)`
+	now := time.Now()
+	s.ID = uuid.New().String()
+	s.CreatedTime = &now
+	s.ModifiedTime = &now
+	s.Version = 1
+	if _, err := r.db.Exec(sql, s.ID, s.Name, s.CreatedTime, s.ModifiedTime, s.Version); err != nil {
+		log.Error(fmt.Sprintf("create series %s error, %s", s.Name, err.Error()))
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *MysqlSeriesRepository) UpdateSeries(s model.Series) (*model.Series, error) {
+	sql := `UPDATE series SET name = ?, modifiedtime = ?, version = ? WHERE id = ? AND version = ?`
+	res, err := r.db.Exec(sql, s.Name, time.Now(), s.Version+1, s.ID, s.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("update series id %s error, %s", s.ID, err.Error()))
+		return nil, err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	return &s, nil
+}
+
+// DeleteSeries delete series, ConflictError is returned while it has books
+func (r *MysqlSeriesRepository) DeleteSeries(id string) error {
+	_, err := r.db.Exec("DELETE FROM series WHERE id = ?", id)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlRowReferenced {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("series id %s has books", id)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete series id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func composeSeriesWhere(q query.SeriesQuery) (string, []interface{}) {
+	if q.Name == "" {
+		return "", []interface{}{}
+	}
+	return " WHERE s.name LIKE ?", []interface{}{"%" + likeEscaper.Replace(q.Name) + "%"}
+}
+
+func scanSeries(row rowScanner) (model.Series, error) {
+	s := model.Series{}
+	err := row.Scan(&s.ID, &s.Name, &s.BookCount, &s.CreatedTime, &s.ModifiedTime, &s.Version)
+	return s, err
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+type MysqlStockRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlStockRepository create new mysql stock movement repository
+func NewMysqlStockRepository(db *sql.DB) *MysqlStockRepository {
+	repo := new(MysqlStockRepository)
+	repo.db = db
+	return repo
+}
+
+// createMovement record a change of stock on hand of a book, in the transaction
+// changing stock on hand so replayed stock never drifts from current amount of the book
+func createMovement(ex execer, m model.StockMovement) (*model.StockMovement, error) {
+	now := time.Now()
+	m.ID = uuid.New().String()
+	m.CreatedTime = &now
+	sql := `INSERT INTO stock_movement (id, book_id, quantity, reason, createdtime) values(?, ?, ?, ?, ?)`
+	_, err := ex.Exec(sql, m.ID, m.BookID, m.Quantity, m.Reason, m.CreatedTime)
+	if err != nil {
+		log.Error(fmt.Sprintf("create stock movement of book id %s error, %s", m.BookID, err.Error()))
+		return nil, err
+	}
+	return &m, nil
+}
+
+// CreateRestock create new restock of a book
+func (r *MysqlStockRepository) CreateRestock(rs model.Restock) (*model.Restock, error) {
+	now := time.Now()
+	rs.ID = uuid.New().String()
+	rs.CreatedTime = &now
+	rs.ModifiedTime = &now
+	rs.Version = 1
+	sql := `INSERT INTO restock (id, book_id, quantity, status, createdtime, modifiedtime, version)
+			values(?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(sql, rs.ID, rs.BookID, rs.Quantity, rs.Status, rs.CreatedTime, rs.ModifiedTime, rs.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("create restock of book id %s error, %s", rs.BookID, err.Error()))
+		if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+			return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("book id %s is not found", rs.BookID)}
+		}
+		return nil, err
+	}
+	return &rs, nil
+}
+
+// GetRestock return restock by given ID
+func (r *MysqlStockRepository) GetRestock(id string) (*model.Restock, error) {
+	sql := `SELECT id, book_id, quantity, status, createdtime, modifiedtime, version FROM restock WHERE id = ?`
+	rs := model.Restock{}
+	err := r.db.QueryRow(sql, id).Scan(&rs.ID, &rs.BookID, &rs.Quantity, &rs.Status, &rs.CreatedTime,
+		&rs.ModifiedTime, &rs.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("get restock id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("restock id %s is not found", id)}
+	}
+	return &rs, nil
+}
+
+// UpdateRestockStatus move restock to status of rs when it is still at given version,
+// received restock fills stock of its book in the same transaction
+func (r *MysqlStockRepository) UpdateRestockStatus(rs model.Restock) (*model.Restock, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return nil, err
+	}
+	now := time.Now()
+	res, err := tx.Exec(`UPDATE restock SET status = ?, modifiedtime = ?, version = ? WHERE id = ? AND version = ?`,
+		rs.Status, now, rs.Version+1, rs.ID, rs.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("update restock id %s error, %s", rs.ID, err.Error()))
+		tx.Rollback()
+		return nil, err
+	}
+	if count, _ := res.RowsAffected(); count == 0 {
+		tx.Rollback()
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	if rs.Status == model.RestockReceived {
+		if err := fillBook(tx, rs.BookID, rs.Quantity); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		log.Error(fmt.Sprintf("update restock id %s error, %s", rs.ID, err.Error()))
+		return nil, err
+	}
+	rs.ModifiedTime = &now
+	rs.Version = rs.Version + 1
+	return &rs, nil
+}
+
+// GetRestocks return restocks in given status, oldest first
+func (r *MysqlStockRepository) GetRestocks(status string) ([]model.Restock, error) {
+	restocks := []model.Restock{}
+	sql := `SELECT id, book_id, quantity, status, createdtime, modifiedtime, version
+			FROM restock WHERE status = ? ORDER BY createdtime`
+	result, err := r.db.Query(sql, status)
+	if err != nil {
+		log.Error("query restocks error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		rs := model.Restock{}
+		if err := result.Scan(&rs.ID, &rs.BookID, &rs.Quantity, &rs.Status, &rs.CreatedTime,
+			&rs.ModifiedTime, &rs.Version); err != nil {
+			return nil, err
+		}
+		restocks = append(restocks, rs)
+	}
+	return restocks, nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+func TestCreateMovement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	m := model.StockMovement{BookID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Quantity: -2, Reason: model.MovementSale}
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, m.BookID, m.Quantity, m.Reason, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	created, err := createMovement(db, m)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotEqual(t, "", created.ID, "new id must be generated")
+	assert.NotNil(t, created.CreatedTime, "created time must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateRestock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rs := model.Restock{BookID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", Quantity: 20, Status: model.RestockDraft}
+	mock.ExpectExec("INSERT INTO restock (.+)").
+		WithArgs(anyString{}, rs.BookID, rs.Quantity, rs.Status, anyTime{}, anyTime{}, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	repo := NewMysqlStockRepository(db)
+	created, err := repo.CreateRestock(rs)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.NotEqual(t, "", created.ID, "new id must be generated")
+	assert.Equal(t, 1, created.Version, "new restock starts at version 1")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetRestocks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"id", "book_id", "quantity", "status", "createdtime", "modifiedtime", "version"}).
+		AddRow("5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", "a432eee1-be54-44e6-a5ef-8a0455306f4f", 20, "draft", now, now, 1)
+	mock.ExpectQuery("^SELECT (.+) FROM restock WHERE status = (.+)").
+		WithArgs(model.RestockDraft).WillReturnRows(rows)
+
+	repo := NewMysqlStockRepository(db)
+	res, err := repo.GetRestocks(model.RestockDraft)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 20, res[0].Quantity)
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReceiveRestock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rs := model.Restock{ID: "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", BookID: "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		Quantity: 20, Status: model.RestockReceived, Version: 2}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE restock SET status = \?, (.+) WHERE id = \? AND version = \?`).
+		WithArgs(model.RestockReceived, anyTime{}, 3, rs.ID, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE book SET currentamount = currentamount \+ \?(.+)`).
+		WithArgs(20, anyTime{}, rs.BookID).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO stock_movement (.+)").
+		WithArgs(anyString{}, rs.BookID, 20, model.MovementFill, anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMysqlStockRepository(db)
+	updated, err := repo.UpdateRestockStatus(rs)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 3, updated.Version, "version must be increased")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCancelStaleRestock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rs := model.Restock{ID: "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", Status: model.RestockCancelled, Version: 1}
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE restock SET status = \?, (.+)`).
+		WithArgs(model.RestockCancelled, anyTime{}, 2, rs.ID, 1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	repo := NewMysqlStockRepository(db)
+	_, err = repo.UpdateRestockStatus(rs)
+
+	assert.IsType(t, &bserror.DataVersionError{}, err, "stale version must be reported")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// execer is implemented by both sql.DB and sql.Tx, summaries are written in the
+// transaction writing the raw rows they are computed from
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// bookAncestorsSQL select distinct categories of book ? and categories above them
+const bookAncestorsSQL = `SELECT DISTINCT cp.ancestor_id FROM book_category bc
+		JOIN category_path cp ON cp.descendant_id = bc.category_id WHERE bc.book_id = ?`
+
+// rebuildCategorySaleDailySQL recompute daily sales per category from daily sales per
+// book, each book counted once in every category it is in or below
+var rebuildCategorySaleDailySQL = []string{
+	`DELETE FROM category_sale_daily`,
+	`INSERT INTO category_sale_daily (saledate, category_id, amount, revenue)
+		SELECT d.saledate, bca.ancestor_id, SUM(d.amount), SUM(d.revenue)
+		FROM sale_daily d
+			JOIN (SELECT DISTINCT bc.book_id, cp.ancestor_id FROM book_category bc
+				JOIN category_path cp ON cp.descendant_id = bc.category_id) bca ON bca.book_id = d.book_id
+		GROUP BY d.saledate, bca.ancestor_id`,
+}
+
+// rebuildSummarySQL recompute summary tables of books from raw sales and reviews
+var rebuildSummarySQL = []string{
+	`DELETE FROM sale_daily`,
+	`INSERT INTO sale_daily (saledate, book_id, format, amount, revenue)
+		SELECT DATE(createdtime), book_id, format, SUM(amount), SUM(amount * IFNULL(unitprice, 0))
+		FROM sale GROUP BY DATE(createdtime), book_id, format`,
+	`DELETE FROM book_review_stat`,
+	`INSERT INTO book_review_stat (book_id, reviewcount, scoresum, verifiedcount, verifiedscoresum)
+		SELECT book_id, COUNT(id), SUM(score), SUM(verifiedpurchase),
+			SUM(CASE WHEN verifiedpurchase = 1 THEN score ELSE 0 END)
+		FROM review WHERE book_id IS NOT NULL GROUP BY book_id`,
+}
+
+type MysqlSummaryRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlSummaryRepository create new mysql summary repository
+func NewMysqlSummaryRepository(db *sql.DB) *MysqlSummaryRepository {
+	repo := new(MysqlSummaryRepository)
+	repo.db = db
+	return repo
+}
+
+// RebuildSummaries replace content of summary tables with totals of raw data, in
+// one transaction so reports never read half built summaries
+func (r *MysqlSummaryRepository) RebuildSummaries() error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		log.Error("begin transaction error, ", err.Error())
+		return err
+	}
+	for _, sql := range rebuildSummarySQL {
+		if _, err := tx.Exec(sql); err != nil {
+			log.Error("rebuild summaries error, ", err.Error())
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := rebuildCategorySaleDaily(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// addSaleDaily add the sale to daily sale summary of its book and format
+func addSaleDaily(ex execer, s model.Sale) error {
+	revenue := 0.0
+	if s.UnitPrice != nil {
+		revenue = float64(s.Amount) * *s.UnitPrice
+	}
+	_, err := ex.Exec(`INSERT INTO sale_daily (saledate, book_id, format, amount, revenue)
+			values(DATE(?), ?, ?, ?, ?)
+			ON DUPLICATE KEY UPDATE amount = amount + VALUES(amount), revenue = revenue + VALUES(revenue)`,
+		s.CreatedTime, s.BookID, s.Format, s.Amount, revenue)
+	if err != nil {
+		log.Error(fmt.Sprintf("add daily sale of book id %s error, %s", s.BookID, err.Error()))
+		return err
+	}
+	_, err = ex.Exec(`INSERT INTO category_sale_daily (saledate, category_id, amount, revenue)
+			SELECT DATE(?), a.ancestor_id, ?, ? FROM (`+bookAncestorsSQL+`) a
+			ON DUPLICATE KEY UPDATE amount = amount + VALUES(amount), revenue = revenue + VALUES(revenue)`,
+		s.CreatedTime, s.Amount, revenue, s.BookID)
+	if err != nil {
+		log.Error(fmt.Sprintf("add daily category sale of book id %s error, %s", s.BookID, err.Error()))
+	}
+	return err
+}
+
+// addBookCategorySales add daily sales of the book to its categories and categories
+// above them, sign -1 takes them out before categories of the book change
+func addBookCategorySales(ex execer, bookID string, sign int) error {
+	_, err := ex.Exec(`INSERT INTO category_sale_daily (saledate, category_id, amount, revenue)
+			SELECT d.saledate, a.ancestor_id, ? * SUM(d.amount), ? * SUM(d.revenue)
+			FROM sale_daily d JOIN (`+bookAncestorsSQL+`) a
+			WHERE d.book_id = ? GROUP BY d.saledate, a.ancestor_id
+			ON DUPLICATE KEY UPDATE amount = amount + VALUES(amount), revenue = revenue + VALUES(revenue)`,
+		sign, sign, bookID, bookID)
+	if err != nil {
+		log.Error(fmt.Sprintf("move daily category sales of book id %s error, %s", bookID, err.Error()))
+	}
+	return err
+}
+
+// rebuildCategorySaleDaily recompute daily sales per category after the tree changed
+func rebuildCategorySaleDaily(ex execer) error {
+	for _, sql := range rebuildCategorySaleDailySQL {
+		if _, err := ex.Exec(sql); err != nil {
+			log.Error("rebuild daily category sales error, ", err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshReviewStat recompute review summary of the book from its reviews
+func refreshReviewStat(ex execer, bookID string) error {
+	if bookID == "" {
+		return nil
+	}
+	_, err := ex.Exec(`INSERT INTO book_review_stat (book_id, reviewcount, scoresum, verifiedcount, verifiedscoresum)
+			SELECT ?, COUNT(id), IFNULL(SUM(score), 0), IFNULL(SUM(verifiedpurchase), 0),
+				IFNULL(SUM(CASE WHEN verifiedpurchase = 1 THEN score ELSE 0 END), 0)
+			FROM review WHERE book_id = ?
+			ON DUPLICATE KEY UPDATE reviewcount = VALUES(reviewcount), scoresum = VALUES(scoresum),
+				verifiedcount = VALUES(verifiedcount), verifiedscoresum = VALUES(verifiedscoresum)`,
+		bookID, bookID)
+	if err != nil {
+		log.Error(fmt.Sprintf("refresh review stat of book id %s error, %s", bookID, err.Error()))
+	}
+	return err
+}
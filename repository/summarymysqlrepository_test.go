@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebuildSummaries(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM sale_daily").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO sale_daily (.+) SELECT (.+) FROM sale GROUP BY (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM book_review_stat").WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("INSERT INTO book_review_stat (.+) SELECT (.+) FROM review (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec("DELETE FROM category_sale_daily").WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectExec("INSERT INTO category_sale_daily (.+) SELECT (.+) FROM sale_daily d (.+) JOIN category_path cp (.+)").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectCommit()
+
+	repo := NewMysqlSummaryRepository(db)
+	err = repo.RebuildSummaries()
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRebuildSummariesError(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM sale_daily").WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("INSERT INTO sale_daily (.+)").WillReturnError(errors.New("lock wait timeout"))
+	mock.ExpectRollback()
+
+	repo := NewMysqlSummaryRepository(db)
+	err = repo.RebuildSummaries()
+
+	assert.NotNil(t, err, "error must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
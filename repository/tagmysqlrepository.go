@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+type MysqlTagRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlTagRepository create new mysql tag repository
+func NewMysqlTagRepository(db *sql.DB) *MysqlTagRepository {
+	repo := new(MysqlTagRepository)
+	repo.db = db
+	return repo
+}
+
+// AddBookTags attach tags to the book, tags already attached are kept as they are
+func (r *MysqlTagRepository) AddBookTags(bookID string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+	now := time.Now()
+	args := []interface{}{}
+	for _, tag := range tags {
+		args = append(args, bookID, tag, now)
+	}
+	sql := `INSERT IGNORE INTO book_tag (book_id, tag, createdtime) values(?, ?, ?)` +
+		strings.Repeat(", (?, ?, ?)", len(tags)-1)
+	_, err := r.db.Exec(sql, args...)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlNoReferencedRow {
+		return &bserror.NotFoundError{Msg: fmt.Sprintf("book id %s is not found", bookID)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("tag book id %s error, %s", bookID, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// RemoveBookTag detach tag from the book, NotFoundError is returned when the tag
+// is not attached to it
+func (r *MysqlTagRepository) RemoveBookTag(bookID string, tag string) error {
+	res, err := r.db.Exec("DELETE FROM book_tag WHERE book_id = ? AND tag = ?", bookID, tag)
+	if err != nil {
+		log.Error(fmt.Sprintf("untag book id %s error, %s", bookID, err.Error()))
+		return err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return &bserror.NotFoundError{Msg: fmt.Sprintf("tag %s is not attached to book id %s", tag, bookID)}
+	}
+	return nil
+}
+
+// QueryTag return tags with number of books they are attached to, most used first
+func (r *MysqlTagRepository) QueryTag(q query.TagQuery) ([]model.Tag, error) {
+	tags := []model.Tag{}
+	args := []interface{}{}
+	where := ""
+	if q.Prefix != "" {
+		where = " WHERE tag LIKE ?"
+		args = append(args, likeEscaper.Replace(q.Prefix)+"%")
+	}
+	having := ""
+	if q.MinCount > 0 {
+		having = " HAVING bookcount >= ?"
+		args = append(args, q.MinCount)
+	}
+	sql := `SELECT tag, COUNT(book_id) as bookcount FROM book_tag` + where + ` GROUP BY tag` + having +
+		` ORDER BY bookcount DESC, tag` + composeLimit(q.Limit, &args)
+	result, err := r.db.Query(sql, args...)
+	if err != nil {
+		log.Error("query tags error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		t := model.Tag{}
+		if err := result.Scan(&t.Name, &t.BookCount); err != nil {
+			log.Error("query tags error", err.Error())
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+func TestAddBookTags(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mock.ExpectExec(`INSERT IGNORE INTO book_tag (.+) values\(\?, \?, \?\), \(\?, \?, \?\)`).
+		WithArgs(bookID, "staff-pick", anyTime{}, bookID, "summer-reading", anyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	repo := NewMysqlTagRepository(db)
+	err = repo.AddBookTags(bookID, []string{"staff-pick", "summer-reading"})
+
+	assert.Nil(t, err, "should not get any error")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestAddTagsToMissingBook(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT IGNORE INTO book_tag (.+)`).
+		WillReturnError(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"})
+
+	repo := NewMysqlTagRepository(db)
+	err = repo.AddBookTags("missing", []string{"staff-pick"})
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "missing book can not be tagged")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRemoveBookTagNotAttached(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mock.ExpectExec(`DELETE FROM book_tag WHERE book_id = \? AND tag = \?`).
+		WithArgs(bookID, "staff-pick").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	repo := NewMysqlTagRepository(db)
+	err = repo.RemoveBookTag(bookID, "staff-pick")
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "tag not attached can not be detached")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestQueryTag(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"tag", "bookcount"}).
+		AddRow("summer-reading", 12).
+		AddRow("staff-pick", 3)
+	mock.ExpectQuery(`^SELECT tag, COUNT\(book_id\) as bookcount FROM book_tag WHERE tag LIKE \? GROUP BY tag `+
+		`HAVING bookcount >= \? ORDER BY bookcount DESC, tag LIMIT \?`).
+		WithArgs("s%", 2, 20).WillReturnRows(rows)
+
+	repo := NewMysqlTagRepository(db)
+	tags, err := repo.QueryTag(query.TagQuery{Prefix: "s", MinCount: 2, Limit: 20})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(tags), "should get 2 tags")
+	assert.Equal(t, "summer-reading", tags[0].Name, "most used tag comes first")
+	assert.Equal(t, 12, tags[0].BookCount, "book count of tag must be returned")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/uuid"
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// workSelect read works w with edition count and review scores summed over editions
+const workSelect = `SELECT w.id, w.title, COUNT(b.id), IFNULL(SUM(rs.reviewcount), 0),
+				SUM(rs.scoresum) / NULLIF(SUM(rs.reviewcount), 0),
+				SUM(rs.verifiedscoresum) / NULLIF(SUM(rs.verifiedcount), 0),
+				w.createdtime, w.modifiedtime, w.version
+			FROM work w LEFT JOIN book b ON b.work_id = w.id
+				LEFT JOIN book_review_stat rs ON rs.book_id = b.id`
+
+const workGroupBy = ` GROUP BY w.id, w.title, w.createdtime, w.modifiedtime, w.version`
+
+type MysqlWorkRepository struct {
+	db *sql.DB
+}
+
+// NewMysqlWorkRepository create new mysql work repository
+func NewMysqlWorkRepository(db *sql.DB) *MysqlWorkRepository {
+	repo := new(MysqlWorkRepository)
+	repo.db = db
+	return repo
+}
+
+func (r *MysqlWorkRepository) GetWork(id string) (*model.Work, error) {
+	sql := workSelect + ` WHERE w.id = ?` + workGroupBy
+	w, err := scanWork(r.db.QueryRow(sql, id))
+	if err != nil {
+		log.Error(fmt.Sprintf("get work id %s error, %s", id, err.Error()))
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("work id %s is not found", id)}
+	}
+	return &w, nil
+}
+
+// QueryWork return works ordered by title
+func (r *MysqlWorkRepository) QueryWork(q query.WorkQuery) ([]model.Work, error) {
+	works := []model.Work{}
+	where, args := composeWorkWhere(q)
+	sql := workSelect + where + workGroupBy + ` ORDER BY w.title, w.id LIMIT ? OFFSET ?`
+	result, err := r.db.Query(sql, append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		log.Error("query works error", err.Error())
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		w, err := scanWork(result)
+		if err != nil {
+			log.Error("query works error", err.Error())
+			return nil, err
+		}
+		works = append(works, w)
+	}
+	return works, nil
+}
+
+func (r *MysqlWorkRepository) CountWork(q query.WorkQuery) (int, error) {
+	where, args := composeWorkWhere(q)
+	sql := "SELECT COUNT(w.id) as count FROM work w" + where
+	var c int
+	if err := r.db.QueryRow(sql, args...).Scan(&c); err != nil {
+		log.Error("count work error, ", err.Error())
+		return c, err
+	}
+	return c, nil
+}
+
+func (r *MysqlWorkRepository) CreateWork(w model.Work) (*model.Work, error) {
+	sql := `INSERT INTO work (id, title, createdtime, modifiedtime, version) values(?, ?, ?, ?, ?)`
+	now := time.Now()
+	w.ID = uuid.New().String()
+	w.CreatedTime = &now
+	w.ModifiedTime = &now
+	w.Version = 1
+	if _, err := r.db.Exec(sql, w.ID, w.Title, w.CreatedTime, w.ModifiedTime, w.Version); err != nil {
+		log.Error(fmt.Sprintf("create work %s error, %s", w.Title, err.Error()))
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (r *MysqlWorkRepository) UpdateWork(w model.Work) (*model.Work, error) {
+	sql := `UPDATE work SET title = ?, modifiedtime = ?, version = ? WHERE id = ? AND version = ?`
+	res, err := r.db.Exec(sql, w.Title, time.Now(), w.Version+1, w.ID, w.Version)
+	if err != nil {
+		log.Error(fmt.Sprintf("update work id %s error, %s", w.ID, err.Error()))
+		return nil, err
+	}
+	count, _ := res.RowsAffected()
+	if count == 0 {
+		return nil, &bserror.DataVersionError{Msg: "data conflict"}
+	}
+	return &w, nil
+}
+
+// DeleteWork delete work, ConflictError is returned while it has editions
+func (r *MysqlWorkRepository) DeleteWork(id string) error {
+	_, err := r.db.Exec("DELETE FROM work WHERE id = ?", id)
+	if e, ok := err.(*mysql.MySQLError); ok && e.Number == mysqlRowReferenced {
+		return &bserror.ConflictError{Msg: fmt.Sprintf("work id %s has editions", id)}
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("delete work id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func composeWorkWhere(q query.WorkQuery) (string, []interface{}) {
+	if q.Title == "" {
+		return "", []interface{}{}
+	}
+	return " WHERE w.title LIKE ?", []interface{}{"%" + likeEscaper.Replace(q.Title) + "%"}
+}
+
+func scanWork(row rowScanner) (model.Work, error) {
+	w := model.Work{}
+	err := row.Scan(&w.ID, &w.Title, &w.EditionCount, &w.ReviewCount, &w.AverageScore, &w.VerifiedAverageScore,
+		&w.CreatedTime, &w.ModifiedTime, &w.Version)
+	return w, err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+)
+
+func TestGetWork(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	rows := sqlmock.NewRows([]string{"id", "title", "editions", "reviewcount", "averagescore",
+		"verifiedaveragescore", "createdtime", "modifiedtime", "version"}).
+		AddRow("sicp", "Structure and Interpretation of Computer Programs", 2, 10, 4.5, nil,
+			time.Now(), time.Now(), 1)
+	mock.ExpectQuery(`^SELECT w.id, (.+) FROM work w LEFT JOIN book b ON b.work_id = w.id\s+` +
+		`LEFT JOIN book_review_stat rs ON rs.book_id = b.id WHERE w.id = \? GROUP BY (.+)`).
+		WithArgs("sicp").WillReturnRows(rows)
+
+	repo := NewMysqlWorkRepository(db)
+	w, err := repo.GetWork("sicp")
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, w.EditionCount, "editions of the work must be counted")
+	assert.Equal(t, 4.5, *w.AverageScore, "average score of all editions must be returned")
+	assert.Nil(t, w.VerifiedAverageScore, "work without verified reviews has no verified score")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteWorkWithEditions(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM work WHERE id = \?`).WithArgs("sicp").
+		WillReturnError(&mysql.MySQLError{Number: 1451, Message: "Cannot delete or update a parent row"})
+
+	repo := NewMysqlWorkRepository(db)
+	err = repo.DeleteWork("sicp")
+
+	assert.IsType(t, &bserror.ConflictError{}, err, "work with editions can not be deleted")
+
+	// we make sure that all expectations were met
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,279 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// reportPath is path of report endpoints jobs are rendered from
+const reportPath = "/v1/reports/"
+
+const dateLayout = "2006-01-02"
+
+// staleRunAge is how long a run may stay running before it is taken as left by a
+// stopped instance
+const staleRunAge = time.Hour
+
+// staleCheckInterval is how often running instances look for stale runs
+const staleCheckInterval = 15 * time.Minute
+
+// validName keep job and report names safe to use in file and url paths
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// relativeDay is parameter value like -7d meaning 7 days before scheduled time
+var relativeDay = regexp.MustCompile(`^-([0-9]+)d$`)
+
+// RunRecorder define interface for keeping runs, CreateRun must return
+// ConflictError when the job already ran at the scheduled time and did not fail
+type RunRecorder interface {
+	CreateRun(model.ReportRun) (*model.ReportRun, error)
+	UpdateRun(model.ReportRun) error
+	FailStaleRuns(before time.Time) ([]model.ReportRun, error)
+}
+
+// Job is a report rendered on schedule, params are query parameters of the report
+// endpoint, values today, yesterday and -Nd are replaced by dates relative to the
+// scheduled time
+type Job struct {
+	Name     string            `json:"name"`
+	Cron     string            `json:"cron"`
+	Report   string            `json:"report"`
+	Format   string            `json:"format"` //json, csv or xlsx
+	Params   map[string]string `json:"params"`
+	schedule *Cron
+}
+
+// LoadJobs read jobs from JSON file holding an array of jobs
+func LoadJobs(path string) ([]Job, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	jobs := []Job{}
+	if err := json.NewDecoder(f).Decode(&jobs); err != nil {
+		return nil, fmt.Errorf("read jobs from %s error, %s", path, err.Error())
+	}
+	return jobs, nil
+}
+
+// Scheduler render reports of jobs on their schedule into archive directory, which
+// may be storage shared by every instance
+type Scheduler struct {
+	jobs     []Job
+	reports  http.Handler
+	recorder RunRecorder
+	dir      string
+	now      func() time.Time
+}
+
+// NewScheduler create new scheduler, reports is handler serving report endpoints
+func NewScheduler(jobs []Job, reports http.Handler, recorder RunRecorder, dir string) (*Scheduler, error) {
+	s := new(Scheduler)
+	names := map[string]bool{}
+	for _, j := range jobs {
+		if !validName.MatchString(j.Name) || !validName.MatchString(j.Report) {
+			return nil, fmt.Errorf("job %q must have lower case name and report", j.Name)
+		}
+		if names[j.Name] {
+			return nil, fmt.Errorf("job %q is defined twice", j.Name)
+		}
+		names[j.Name] = true
+		if j.Format == "" {
+			j.Format = "json"
+		}
+		if j.Format != "json" && j.Format != "csv" && j.Format != "xlsx" {
+			return nil, fmt.Errorf("job %q format must be json, csv or xlsx", j.Name)
+		}
+		schedule, err := ParseCron(j.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("job %q %s", j.Name, err.Error())
+		}
+		j.schedule = schedule
+		s.jobs = append(s.jobs, j)
+	}
+	s.reports = reports
+	s.recorder = recorder
+	s.dir = dir
+	s.now = time.Now
+	return s, nil
+}
+
+// Start run jobs in background until the process exits, runs left running by a
+// stopped instance are failed and run again first, then every staleCheckInterval
+func (s *Scheduler) Start() {
+	if len(s.jobs) == 0 {
+		return
+	}
+	go func() {
+		s.RetryStaleRuns()
+		s.loop()
+	}()
+}
+
+// RetryStaleRuns fail runs which have been running longer than staleRunAge and
+// run their jobs again for the same scheduled time
+func (s *Scheduler) RetryStaleRuns() {
+	failed, err := s.recorder.FailStaleRuns(s.now().Add(-staleRunAge))
+	if err != nil {
+		log.Error(fmt.Sprintf("fail stale runs error, %s", err.Error()))
+		return
+	}
+	for _, run := range failed {
+		for _, j := range s.jobs {
+			if j.Name == run.Job {
+				log.Info(fmt.Sprintf("retry job %s at %s", j.Name, run.ScheduledTime.Format(time.RFC3339)))
+				s.Run(j, *run.ScheduledTime)
+			}
+		}
+	}
+}
+
+func (s *Scheduler) loop() {
+	next := make([]time.Time, len(s.jobs))
+	for i, j := range s.jobs {
+		next[i] = j.schedule.Next(s.now())
+	}
+	retry := s.now().Add(staleCheckInterval)
+	for {
+		wake := retry
+		for _, t := range next {
+			if !t.IsZero() && t.Before(wake) {
+				wake = t
+			}
+		}
+		time.Sleep(wake.Sub(s.now()))
+		retry = s.runDue(next, retry)
+	}
+}
+
+// runDue run jobs whose next time has come and retry stale runs when retry time
+// has come, next times of jobs are moved on and next retry time is returned
+func (s *Scheduler) runDue(next []time.Time, retry time.Time) time.Time {
+	now := s.now()
+	if !retry.After(now) {
+		s.RetryStaleRuns()
+		retry = now.Add(staleCheckInterval)
+	}
+	for i, j := range s.jobs {
+		if !next[i].IsZero() && !next[i].After(now) {
+			s.Run(j, next[i])
+			next[i] = j.schedule.Next(now)
+		}
+	}
+	return retry
+}
+
+// Run render the job for scheduled time unless another instance already did,
+// outcome is kept in the run record
+func (s *Scheduler) Run(j Job, scheduled time.Time) error {
+	run := model.ReportRun{Job: j.Name, Report: j.Report, Format: j.Format, ScheduledTime: &scheduled,
+		Status: model.RunRunning}
+	created, err := s.recorder.CreateRun(run)
+	if _, ok := err.(*bserror.ConflictError); ok {
+		log.Info(fmt.Sprintf("job %s at %s is taken by another instance", j.Name, scheduled.Format(time.RFC3339)))
+		return nil
+	}
+	if err != nil {
+		log.Error(fmt.Sprintf("claim job %s error, %s", j.Name, err.Error()))
+		return err
+	}
+
+	file := j.Name + "-" + scheduled.Format("20060102T1504") + "." + j.Format
+	err = s.render(j, scheduled, filepath.Join(s.dir, j.Name, file))
+	finished := s.now()
+	created.FinishedTime = &finished
+	if err != nil {
+		log.Error(fmt.Sprintf("run job %s error, %s", j.Name, err.Error()))
+		created.Status = model.RunFailed
+		created.Error = err.Error()
+	} else {
+		created.Status = model.RunDone
+		created.File = file
+	}
+	return s.recorder.UpdateRun(*created)
+}
+
+// render request the report endpoint and write its response into path, through a
+// temporary file so a partly written output is never archived
+func (s *Scheduler) render(j Job, scheduled time.Time, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	params := url.Values{}
+	for k, v := range j.Params {
+		params.Set(k, resolveParam(v, scheduled))
+	}
+	if j.Format != "json" {
+		params.Set("format", j.Format)
+	}
+	req, err := http.NewRequest(http.MethodGet, reportPath+j.Report+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := &fileResponse{header: http.Header{}, file: f}
+	s.reports.ServeHTTP(w, req)
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if w.status != 0 && w.status != http.StatusOK {
+		os.Remove(tmp)
+		return fmt.Errorf("report %s responded with status %d", j.Report, w.status)
+	}
+	return os.Rename(tmp, path)
+}
+
+// resolveParam replace relative date with date of scheduled time
+func resolveParam(v string, scheduled time.Time) string {
+	switch v {
+	case "today":
+		return scheduled.Format(dateLayout)
+	case "yesterday":
+		return scheduled.AddDate(0, 0, -1).Format(dateLayout)
+	}
+	if m := relativeDay.FindStringSubmatch(v); m != nil {
+		days, _ := strconv.Atoi(m[1])
+		return scheduled.AddDate(0, 0, -days).Format(dateLayout)
+	}
+	return v
+}
+
+// fileResponse is http.ResponseWriter writing body into a file
+type fileResponse struct {
+	header http.Header
+	status int
+	file   *os.File
+}
+
+func (w *fileResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *fileResponse) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *fileResponse) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.file.Write(b)
+}
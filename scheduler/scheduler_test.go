@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+type stubRecorder struct {
+	conflict bool
+	stale    []model.ReportRun
+	checked  []time.Time
+	updated  []model.ReportRun
+}
+
+func (s *stubRecorder) CreateRun(run model.ReportRun) (*model.ReportRun, error) {
+	if s.conflict {
+		return nil, &bserror.ConflictError{Msg: "already ran"}
+	}
+	run.ID = "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11"
+	return &run, nil
+}
+
+func (s *stubRecorder) UpdateRun(run model.ReportRun) error {
+	s.updated = append(s.updated, run)
+	return nil
+}
+
+func (s *stubRecorder) FailStaleRuns(before time.Time) ([]model.ReportRun, error) {
+	s.checked = append(s.checked, before)
+	return s.stale, nil
+}
+
+func TestParseCron(t *testing.T) {
+	at := time.Date(2020, 1, 15, 10, 30, 0, 0, time.UTC) // Wednesday
+	cases := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2020, 1, 15, 10, 31, 0, 0, time.UTC)},
+		{"0 1 * * *", time.Date(2020, 1, 16, 1, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2020, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"*/15 9-17 * * 1-5", time.Date(2020, 1, 15, 10, 45, 0, 0, time.UTC)},
+		{"0 6 * * 7", time.Date(2020, 1, 19, 6, 0, 0, 0, time.UTC)},
+		{"0 0 1 */3 *", time.Date(2020, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2020, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		cron, err := ParseCron(c.expr)
+		assert.Nil(t, err, c.expr+" should be valid")
+		assert.Equal(t, c.next, cron.Next(at), c.expr)
+	}
+}
+
+func TestParseInvalidCron(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *", "5-1 * * * *"} {
+		_, err := ParseCron(expr)
+		assert.NotNil(t, err, expr+" should be invalid")
+	}
+}
+
+func TestCronNeverMatch(t *testing.T) {
+	cron, _ := ParseCron("0 0 31 2 *")
+
+	assert.True(t, cron.Next(time.Now()).IsZero(), "31 February never comes")
+}
+
+func TestNewSchedulerWithInvalidJob(t *testing.T) {
+	_, err := NewScheduler([]Job{{Name: "../etc", Cron: "@daily", Report: "revenue"}}, nil, nil, "")
+	assert.NotNil(t, err, "job name must be safe for file path")
+
+	_, err = NewScheduler([]Job{{Name: "daily", Cron: "@daily", Report: "revenue", Format: "pdf"}}, nil, nil, "")
+	assert.NotNil(t, err, "unknown format must be rejected")
+}
+
+func TestRun(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "reports")
+	defer os.RemoveAll(dir)
+	var requested string
+	reports := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.String()
+		w.Write([]byte("Book ID,Title\n"))
+	})
+	recorder := new(stubRecorder)
+	job := Job{Name: "daily-bestsellers", Cron: "0 1 * * *", Report: "bestsallbook", Format: "csv",
+		Params: map[string]string{"from": "yesterday", "to": "yesterday", "limit": "10"}}
+	s, err := NewScheduler([]Job{job}, reports, recorder, dir)
+	assert.Nil(t, err, "should not get any error")
+
+	err = s.Run(s.jobs[0], time.Date(2020, 1, 15, 1, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "/v1/reports/bestsallbook?format=csv&from=2020-01-14&limit=10&to=2020-01-14", requested)
+	assert.Equal(t, model.RunDone, recorder.updated[0].Status)
+	assert.Equal(t, "daily-bestsellers-20200115T0100.csv", recorder.updated[0].File)
+	body, err := ioutil.ReadFile(filepath.Join(dir, "daily-bestsellers", recorder.updated[0].File))
+	assert.Nil(t, err, "output must be archived")
+	assert.Equal(t, "Book ID,Title\n", string(body))
+}
+
+func TestRunWithFailedReport(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "reports")
+	defer os.RemoveAll(dir)
+	reports := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	recorder := new(stubRecorder)
+	s, _ := NewScheduler([]Job{{Name: "weekly-revenue", Cron: "@weekly", Report: "revenue"}}, reports, recorder, dir)
+
+	s.Run(s.jobs[0], time.Date(2020, 1, 12, 0, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, model.RunFailed, recorder.updated[0].Status)
+	assert.NotEqual(t, "", recorder.updated[0].Error, "reason must be recorded")
+	files, _ := filepath.Glob(filepath.Join(dir, "weekly-revenue", "*"))
+	assert.Equal(t, 0, len(files), "failed output must not be archived")
+}
+
+func TestRunTakenByAnotherInstance(t *testing.T) {
+	called := false
+	reports := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true })
+	recorder := &stubRecorder{conflict: true}
+	s, _ := NewScheduler([]Job{{Name: "weekly-revenue", Cron: "@weekly", Report: "revenue"}}, reports, recorder, "")
+
+	err := s.Run(s.jobs[0], time.Date(2020, 1, 12, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, err, "losing the lock is not an error")
+	assert.False(t, called, "report must not be rendered twice")
+}
+
+func TestRetryStaleRuns(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "reports")
+	defer os.RemoveAll(dir)
+	scheduled := time.Date(2020, 1, 12, 0, 0, 0, 0, time.UTC)
+	var requested string
+	reports := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		w.Write([]byte("{}"))
+	})
+	recorder := &stubRecorder{stale: []model.ReportRun{
+		{Job: "weekly-revenue", ScheduledTime: &scheduled, Status: model.RunFailed},
+		{Job: "removed-job", ScheduledTime: &scheduled, Status: model.RunFailed},
+	}}
+	s, _ := NewScheduler([]Job{{Name: "weekly-revenue", Cron: "@weekly", Report: "revenue"}}, reports, recorder, dir)
+
+	s.RetryStaleRuns()
+
+	assert.Equal(t, "/v1/reports/revenue", requested, "job of stale run must run again")
+	assert.Equal(t, 1, len(recorder.updated), "run of removed job is not retried")
+	assert.Equal(t, model.RunDone, recorder.updated[0].Status)
+	assert.Equal(t, scheduled, *recorder.updated[0].ScheduledTime)
+}
+
+func TestRunDueRetryStaleRunsOnInterval(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "reports")
+	defer os.RemoveAll(dir)
+	reports := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("{}")) })
+	recorder := new(stubRecorder)
+	s, _ := NewScheduler([]Job{{Name: "weekly-revenue", Cron: "@weekly", Report: "revenue"}}, reports, recorder, dir)
+	clock := time.Date(2020, 1, 15, 10, 0, 0, 0, time.UTC) // Wednesday
+	s.now = func() time.Time { return clock }
+	next := []time.Time{s.jobs[0].schedule.Next(clock)}
+
+	retry := s.runDue(next, clock.Add(staleCheckInterval))
+	assert.Equal(t, 0, len(recorder.checked), "stale runs are not checked before interval")
+
+	clock = clock.Add(staleCheckInterval)
+	retry = s.runDue(next, retry)
+	assert.Equal(t, []time.Time{clock.Add(-staleRunAge)}, recorder.checked, "stale runs must be checked while running")
+	assert.Equal(t, clock.Add(staleCheckInterval), retry)
+	assert.Equal(t, 0, len(recorder.updated), "job is not due yet")
+
+	clock = next[0]
+	s.runDue(next, retry)
+	assert.Equal(t, 1, len(recorder.updated), "due job must run")
+	assert.Equal(t, 2, len(recorder.checked), "retry time has passed as well")
+}
@@ -0,0 +1,85 @@
+package screening
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// DefaultBannedWords is used when no word list file is configured
+var DefaultBannedWords = []string{
+	"fuck", "fucking", "shit", "bitch", "asshole", "bastard", "cunt", "motherfucker",
+	"เหี้ย", "สัส", "ควย", "เย็ดแม่", "ไอ้สัตว์", "อีดอก",
+}
+
+// BannedWordRule reject review containing a banned word
+type BannedWordRule struct {
+	words []string
+}
+
+// NewBannedWordRule create new banned word rule
+func NewBannedWordRule(words []string) *BannedWordRule {
+	r := new(BannedWordRule)
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			r.words = append(r.words, w)
+		}
+	}
+	return r
+}
+
+// LoadWordList read banned words from file, one word per line,
+// blank lines and lines start with # are ignored
+func LoadWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	words := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	return words, scanner.Err()
+}
+
+func (r *BannedWordRule) Name() string {
+	return "banned_word"
+}
+
+// Check match English words against whole words only, Thai is written
+// without spaces so Thai words are matched anywhere in the text
+func (r *BannedWordRule) Check(review model.Review) (model.ScreeningVerdict, error) {
+	text := strings.ToLower(review.Description)
+	tokens := map[string]bool{}
+	for _, t := range strings.FieldsFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	}) {
+		tokens[t] = true
+	}
+	for _, w := range r.words {
+		if (isASCII(w) && tokens[w]) || (!isASCII(w) && strings.Contains(text, w)) {
+			return verdict(r.Name(), Reject, fmt.Sprintf("contains banned word %q", w)), nil
+		}
+	}
+	return verdict(r.Name(), Pass, ""), nil
+}
+
+func isASCII(s string) bool {
+	for _, c := range s {
+		if c > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
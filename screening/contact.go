@@ -0,0 +1,40 @@
+package screening
+
+import (
+	"regexp"
+
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+var (
+	linkPattern  = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|io|co|th|me|ly|shop|link)\b`)
+	phonePattern = regexp.MustCompile(`(^|[^\d-])(\+66|0)[\s.-]?[2-9]([\s.-]?\d){7,8}\b`)
+)
+
+// LinkRule flag review containing url for human review
+type LinkRule struct{}
+
+func (r LinkRule) Name() string {
+	return "link"
+}
+
+func (r LinkRule) Check(review model.Review) (model.ScreeningVerdict, error) {
+	if linkPattern.MatchString(review.Description) {
+		return verdict(r.Name(), Flag, "contains link"), nil
+	}
+	return verdict(r.Name(), Pass, ""), nil
+}
+
+// PhoneRule flag review containing phone number for human review
+type PhoneRule struct{}
+
+func (r PhoneRule) Name() string {
+	return "phone"
+}
+
+func (r PhoneRule) Check(review model.Review) (model.ScreeningVerdict, error) {
+	if phonePattern.MatchString(review.Description) {
+		return verdict(r.Name(), Flag, "contains phone number"), nil
+	}
+	return verdict(r.Name(), Pass, ""), nil
+}
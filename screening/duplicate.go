@@ -0,0 +1,46 @@
+package screening
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// minDuplicateLength keep short texts like "Good!" out of duplicate detection
+const minDuplicateLength = 20
+
+// FingerprintCounter define interface for counting reviews by fingerprint
+type FingerprintCounter interface {
+	CountReviewByFingerprint(fingerprint string, excludeID string) (int, error)
+}
+
+// DuplicateRule flag review which text already posted in another review
+type DuplicateRule struct {
+	counter FingerprintCounter
+}
+
+// NewDuplicateRule create new duplicate text rule
+func NewDuplicateRule(counter FingerprintCounter) *DuplicateRule {
+	r := new(DuplicateRule)
+	r.counter = counter
+	return r
+}
+
+func (r *DuplicateRule) Name() string {
+	return "duplicate"
+}
+
+func (r *DuplicateRule) Check(review model.Review) (model.ScreeningVerdict, error) {
+	if utf8.RuneCountInString(normalize(review.Description)) < minDuplicateLength {
+		return verdict(r.Name(), Pass, ""), nil
+	}
+	count, err := r.counter.CountReviewByFingerprint(Fingerprint(review.Description), review.ID)
+	if err != nil {
+		return model.ScreeningVerdict{}, err
+	}
+	if count > 0 {
+		return verdict(r.Name(), Flag, fmt.Sprintf("same text as %d other review(s)", count)), nil
+	}
+	return verdict(r.Name(), Pass, ""), nil
+}
@@ -0,0 +1,51 @@
+package screening
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// ReviewCounter define interface for counting reviews a reviewer posted
+type ReviewCounter interface {
+	CountReviewByReviewer(reviewerID string, since time.Time) (int, error)
+}
+
+// RateRule reject reviewer who already posted limit reviews within window. Reviews
+// are counted from the database, so every instance sees the same count and only
+// reviews actually posted count, editing an existing review is not limited
+type RateRule struct {
+	counter ReviewCounter
+	limit   int
+	window  time.Duration
+	now     func() time.Time
+}
+
+// NewRateRule create new rate rule
+func NewRateRule(counter ReviewCounter, limit int, window time.Duration) *RateRule {
+	r := new(RateRule)
+	r.counter = counter
+	r.limit = limit
+	r.window = window
+	r.now = time.Now
+	return r
+}
+
+func (r *RateRule) Name() string {
+	return "rate"
+}
+
+func (r *RateRule) Check(review model.Review) (model.ScreeningVerdict, error) {
+	if review.ReviewerID == "" || review.ID != "" {
+		return verdict(r.Name(), Pass, ""), nil
+	}
+	count, err := r.counter.CountReviewByReviewer(review.ReviewerID, r.now().Add(-r.window))
+	if err != nil {
+		return model.ScreeningVerdict{}, err
+	}
+	if count >= r.limit {
+		return verdict(r.Name(), Reject, fmt.Sprintf("more than %d reviews within %s", r.limit, r.window)), nil
+	}
+	return verdict(r.Name(), Pass, ""), nil
+}
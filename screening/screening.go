@@ -0,0 +1,82 @@
+package screening
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// Verdicts a rule can return, from least to most severe
+const (
+	Pass   = "passed"
+	Flag   = "flagged"
+	Reject = "rejected"
+)
+
+var severity = map[string]int{Pass: 0, Flag: 1, Reject: 2}
+
+// Rule define interface for a single screening rule
+type Rule interface {
+	Name() string
+	Check(review model.Review) (model.ScreeningVerdict, error)
+}
+
+// Pipeline run reviews through a list of rules
+type Pipeline struct {
+	rules []Rule
+}
+
+// NewPipeline create new screening pipeline with given rules
+func NewPipeline(rules ...Rule) *Pipeline {
+	p := new(Pipeline)
+	p.rules = rules
+	return p
+}
+
+// Screen run every rule against the review and return the most severe verdict
+// together with the verdict of each rule
+func (p *Pipeline) Screen(review model.Review) (string, []model.ScreeningVerdict, error) {
+	status := Pass
+	verdicts := []model.ScreeningVerdict{}
+	for _, rule := range p.rules {
+		v, err := rule.Check(review)
+		if err != nil {
+			log.Error("screening rule ", rule.Name(), " error, ", err.Error())
+			return "", nil, err
+		}
+		if severity[v.Verdict] > severity[status] {
+			status = v.Verdict
+		}
+		verdicts = append(verdicts, v)
+	}
+	return status, verdicts, nil
+}
+
+// Fingerprint return hash of normalized text, texts that differ only by case,
+// spacing or punctuation share the same fingerprint
+func Fingerprint(text string) string {
+	normalized := normalize(text)
+	if normalized == "" {
+		return ""
+	}
+	sum := sha1.Sum([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func verdict(rule string, v string, reason string) model.ScreeningVerdict {
+	return model.ScreeningVerdict{Rule: rule, Verdict: v, Reason: reason}
+}
@@ -0,0 +1,128 @@
+package screening
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+type stubCounter struct {
+	count int
+	err   error
+}
+
+func (s stubCounter) CountReviewByFingerprint(fingerprint string, excludeID string) (int, error) {
+	return s.count, s.err
+}
+
+func TestPipelineReturnMostSevereVerdict(t *testing.T) {
+	p := NewPipeline(NewBannedWordRule(DefaultBannedWords), LinkRule{}, PhoneRule{})
+
+	status, verdicts, err := p.Screen(model.Review{Description: "buy cheap copy at www.example.com"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, Flag, status, "review with link should be flagged")
+	assert.Equal(t, 3, len(verdicts), "every rule should return verdict")
+
+	status, _, _ = p.Screen(model.Review{Description: "what a shit book, see www.example.com"})
+	assert.Equal(t, Reject, status, "reject should win over flag")
+}
+
+func TestPipelineWithErrorFromRule(t *testing.T) {
+	p := NewPipeline(NewDuplicateRule(stubCounter{err: errors.New("there is something wrong")}))
+
+	_, _, err := p.Screen(model.Review{Description: "The best book about concurrency I have read"})
+
+	assert.NotNil(t, err, "should get error when rule return error")
+}
+
+func TestBannedWordRule(t *testing.T) {
+	rule := NewBannedWordRule([]string{"Shit", "เหี้ย"})
+
+	v, _ := rule.Check(model.Review{Description: "SHIT!"})
+	assert.Equal(t, Reject, v.Verdict, "english word should match ignoring case")
+
+	v, _ = rule.Check(model.Review{Description: "shitake recipes are great"})
+	assert.Equal(t, Pass, v.Verdict, "english word should match whole word only")
+
+	v, _ = rule.Check(model.Review{Description: "หนังสือเหี้ยมาก"})
+	assert.Equal(t, Reject, v.Verdict, "thai word should match inside text")
+}
+
+func TestContactRules(t *testing.T) {
+	cases := []struct {
+		text  string
+		link  string
+		phone string
+	}{
+		{"great read", Pass, Pass},
+		{"more at https://spam.example/offer", Flag, Pass},
+		{"สั่งซื้อที่ shop.co.th", Flag, Pass},
+		{"โทร 081-234-5678", Pass, Flag},
+		{"call +66 2 123 4567", Pass, Flag},
+		{"ISBN 978-0321349606", Pass, Pass},
+	}
+	for _, c := range cases {
+		v, _ := LinkRule{}.Check(model.Review{Description: c.text})
+		assert.Equal(t, c.link, v.Verdict, "link verdict of "+c.text)
+		v, _ = PhoneRule{}.Check(model.Review{Description: c.text})
+		assert.Equal(t, c.phone, v.Verdict, "phone verdict of "+c.text)
+	}
+}
+
+func TestDuplicateRule(t *testing.T) {
+	text := "The best book about concurrency I have read"
+
+	v, _ := NewDuplicateRule(stubCounter{count: 1}).Check(model.Review{Description: text})
+	assert.Equal(t, Flag, v.Verdict, "duplicated text should be flagged")
+
+	v, _ = NewDuplicateRule(stubCounter{count: 0}).Check(model.Review{Description: text})
+	assert.Equal(t, Pass, v.Verdict, "unique text should pass")
+
+	v, _ = NewDuplicateRule(stubCounter{count: 10}).Check(model.Review{Description: "Good!"})
+	assert.Equal(t, Pass, v.Verdict, "short text should not be checked")
+}
+
+func TestFingerprint(t *testing.T) {
+	assert.Equal(t, Fingerprint("Great book!!"), Fingerprint("great   BOOK"), "case and punctuation should be ignored")
+	assert.NotEqual(t, Fingerprint("great book"), Fingerprint("good book"), "different text should differ")
+	assert.Equal(t, "", Fingerprint("  !! "), "text without letters has no fingerprint")
+}
+
+type stubReviewCounter struct {
+	counts map[string][]time.Time
+}
+
+func (s stubReviewCounter) CountReviewByReviewer(reviewerID string, since time.Time) (int, error) {
+	count := 0
+	for _, t := range s.counts[reviewerID] {
+		if !t.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func TestRateRule(t *testing.T) {
+	now := time.Now()
+	counter := stubReviewCounter{counts: map[string][]time.Time{
+		"reviewer-1": {now.Add(-30 * time.Minute), now.Add(-10 * time.Minute)},
+		"reviewer-2": {now.Add(-10 * time.Minute)},
+	}}
+	rule := NewRateRule(counter, 2, time.Hour)
+	rule.now = func() time.Time { return now }
+
+	v, _ := rule.Check(model.Review{ReviewerID: "reviewer-1"})
+	assert.Equal(t, Reject, v.Verdict, "third review within window should be rejected")
+	v, _ = rule.Check(model.Review{ID: "existing", ReviewerID: "reviewer-1"})
+	assert.Equal(t, Pass, v.Verdict, "editing a review should not be limited")
+	v, _ = rule.Check(model.Review{ReviewerID: "reviewer-2"})
+	assert.Equal(t, Pass, v.Verdict, "other reviewer has own limit")
+
+	now = now.Add(time.Hour)
+	v, _ = rule.Check(model.Review{ReviewerID: "reviewer-1"})
+	assert.Equal(t, Pass, v.Verdict, "limit should reset after window")
+}
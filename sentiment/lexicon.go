@@ -0,0 +1,34 @@
+package sentiment
+
+// englishLexicon holds polarity of English words from -4 (very negative) to 4 (very positive)
+var englishLexicon = map[string]float64{
+	"amazing": 4, "awesome": 4, "brilliant": 4, "excellent": 4, "fantastic": 4, "masterpiece": 4,
+	"outstanding": 4, "perfect": 4, "superb": 4, "wonderful": 4, "best": 3.5, "love": 3, "loved": 3,
+	"beautiful": 3, "great": 3, "enjoyed": 2.5, "enjoy": 2.5, "recommend": 2.5, "recommended": 2.5,
+	"insightful": 2.5, "inspiring": 2.5, "engaging": 2.5, "fun": 2, "good": 2, "helpful": 2, "nice": 2,
+	"clear": 1.5, "interesting": 1.5, "useful": 1.5, "like": 1.5, "liked": 1.5, "worth": 1.5,
+	"easy": 1, "ok": 0.5, "okay": 0.5, "fine": 0.5,
+	"awful": -4, "horrible": -4, "terrible": -4, "worst": -4, "garbage": -4, "trash": -4, "hate": -3.5,
+	"hated": -3.5, "useless": -3, "waste": -3, "bad": -3, "poor": -2.5, "disappointing": -2.5,
+	"disappointed": -2.5, "boring": -2.5, "dull": -2, "confusing": -2, "wrong": -2, "mistakes": -2,
+	"errors": -2, "outdated": -1.5, "slow": -1.5, "hard": -1, "expensive": -1, "meh": -1,
+}
+
+// thaiLexicon holds polarity of Thai words, Thai is written without spaces
+// so these are matched inside the text, longest word first
+var thaiLexicon = map[string]float64{
+	"ยอดเยี่ยม": 4, "สุดยอด": 4, "เยี่ยม": 3.5, "ประทับใจ": 3, "ชอบมาก": 3, "ดีมาก": 3, "แนะนำ": 2.5,
+	"สนุก": 2.5, "คุ้มค่า": 2.5, "คุ้ม": 2, "ชอบ": 2, "ดี": 2, "เข้าใจง่าย": 2, "มีประโยชน์": 2,
+	"น่าสนใจ": 1.5, "อ่านง่าย": 1.5, "โอเค": 0.5,
+	"แย่มาก": -4, "ห่วย": -3.5, "เสียดาย": -3, "เสียเวลา": -3, "แย่": -3, "ผิดหวัง": -2.5, "น่าเบื่อ": -2.5,
+	"งง": -1.5, "ผิดเยอะ": -2, "แพง": -1,
+}
+
+// englishNegations flip polarity of the next few words
+var englishNegations = map[string]bool{
+	"not": true, "no": true, "never": true, "isn't": true, "wasn't": true, "don't": true,
+	"didn't": true, "doesn't": true, "nothing": true, "hardly": true,
+}
+
+// thaiNegation flip polarity of the word right after it
+const thaiNegation = "ไม่"
@@ -0,0 +1,103 @@
+package sentiment
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// normalizeAlpha spread raw lexicon sum into -1..1, higher alpha needs more words
+// to reach the ends of the range
+const normalizeAlpha = 15
+
+// negationWindow is number of English words after a negation which polarity is flipped
+const negationWindow = 3
+
+// thaiWords is Thai lexicon words, longest first so "ดีมาก" wins over "ดี"
+var thaiWords = sortedByLength(thaiLexicon)
+
+// Score return sentiment of text from -1 (negative) to 1 (positive), ok is false
+// when text has no lexicon word so it can not be scored at all
+func Score(text string) (score float64, ok bool) {
+	text = strings.ToLower(text)
+	englishSum, englishHits := englishSum(text)
+	thaiSum, thaiHits := thaiSum(text)
+	if englishHits+thaiHits == 0 {
+		return 0, false
+	}
+	sum := englishSum + thaiSum
+	return sum / math.Sqrt(sum*sum+normalizeAlpha), true
+}
+
+// englishSum return sum of polarity of english lexicon words and number of them
+func englishSum(text string) (float64, int) {
+	sum := 0.0
+	hits := 0
+	negated := 0
+	for _, token := range strings.FieldsFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && c != '\''
+	}) {
+		if englishNegations[token] {
+			negated = negationWindow
+			continue
+		}
+		if v, ok := englishLexicon[token]; ok {
+			if negated > 0 {
+				v = -v
+			}
+			sum += v
+			hits++
+		}
+		if negated > 0 {
+			negated--
+		}
+	}
+	return sum, hits
+}
+
+// thaiSum return sum of polarity of thai lexicon words and number of them
+func thaiSum(text string) (float64, int) {
+	sum := 0.0
+	hits := 0
+	for i := 0; i < len(text); {
+		word := matchThai(text[i:])
+		if word == "" {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		v := thaiLexicon[word]
+		if strings.HasSuffix(strings.TrimRight(text[:i], " "), thaiNegation) {
+			v = -v
+		}
+		sum += v
+		hits++
+		i += len(word)
+	}
+	return sum, hits
+}
+
+func matchThai(text string) string {
+	for _, w := range thaiWords {
+		if strings.HasPrefix(text, w) {
+			return w
+		}
+	}
+	return ""
+}
+
+func sortedByLength(lexicon map[string]float64) []string {
+	words := []string{}
+	for w := range lexicon {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+	return words
+}
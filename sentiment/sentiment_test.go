@@ -0,0 +1,43 @@
+package sentiment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// score return sentiment of text which is expected to have lexicon words
+func score(t *testing.T, text string) float64 {
+	s, ok := Score(text)
+	assert.True(t, ok, "text should be scored, "+text)
+	return s
+}
+
+func TestScore(t *testing.T) {
+	assert.True(t, score(t, "An excellent book, I loved it") > 0.5, "positive english text")
+	assert.True(t, score(t, "Terrible and boring, a waste of money") < -0.5, "negative english text")
+	assert.Equal(t, 0.0, score(t, "good but wrong"), "balanced text should score 0")
+}
+
+func TestScoreWithoutLexiconWord(t *testing.T) {
+	_, ok := Score("The book has 300 pages")
+	assert.False(t, ok, "text without lexicon word should not be scored")
+	_, ok = Score("หนังสือเล่มนี้มี 300 หน้า")
+	assert.False(t, ok, "thai text without lexicon word should not be scored")
+}
+
+func TestScoreWithNegation(t *testing.T) {
+	assert.True(t, score(t, "not good at all") < 0, "negation should flip english word")
+	assert.True(t, score(t, "ไม่ดี") < 0, "negation should flip thai word")
+}
+
+func TestScoreThai(t *testing.T) {
+	assert.True(t, score(t, "หนังสือดีมาก แนะนำเลย") > 0.5, "positive thai text")
+	assert.True(t, score(t, "น่าเบื่อ เสียเวลา") < -0.5, "negative thai text")
+	assert.True(t, score(t, "ยอดเยี่ยม") > score(t, "ดี"), "longest thai word should match first")
+}
+
+func TestScoreRange(t *testing.T) {
+	s := score(t, "amazing amazing amazing amazing amazing amazing amazing amazing")
+	assert.True(t, s > 0.9 && s <= 1, "score should stay within 1")
+}
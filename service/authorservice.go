@@ -0,0 +1,86 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+type AuthorService struct {
+	repo repository.AuthorRepository
+}
+
+func NewAuthorService(authorRepo repository.AuthorRepository) *AuthorService {
+	s := new(AuthorService)
+	s.repo = authorRepo
+	return s
+}
+
+func (s *AuthorService) GetAuthor(id string) (*model.Author, error) {
+	return s.repo.GetAuthor(id)
+}
+
+func (s *AuthorService) QueryAuthor(q query.AuthorQuery) ([]model.Author, error) {
+	return s.repo.QueryAuthor(q)
+}
+
+func (s *AuthorService) CountAuthor(q query.AuthorQuery) (int, error) {
+	return s.repo.CountAuthor(q)
+}
+
+func (s *AuthorService) Create(a model.Author) (*model.Author, error) {
+	log.Info(fmt.Sprintf("create new author, name %s", a.Name))
+	if err := normalizeAuthor(&a); err != nil {
+		return nil, err
+	}
+	return s.repo.CreateAuthor(a)
+}
+
+func (s *AuthorService) Update(a model.Author) (*model.Author, error) {
+	if _, err := s.repo.GetAuthor(a.ID); err != nil {
+		return nil, err
+	}
+	if err := normalizeAuthor(&a); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateAuthor(a)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetAuthor(updated.ID)
+}
+
+// Delete delete author no book credits
+func (s *AuthorService) Delete(id string) error {
+	if _, err := s.repo.GetAuthor(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteAuthor(id)
+}
+
+// normalizeAuthor trim names, sort name is derived from name when not given
+func normalizeAuthor(a *model.Author) error {
+	a.Name = strings.TrimSpace(a.Name)
+	a.SortName = strings.TrimSpace(a.SortName)
+	if a.Name == "" {
+		return &bserror.BadParameterError{Msg: "name is required"}
+	}
+	if a.SortName == "" {
+		a.SortName = sortName(a.Name)
+	}
+	return nil
+}
+
+// sortName return name in "last, first" form, names of one word are kept as is
+func sortName(name string) string {
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return name
+	}
+	return words[len(words)-1] + ", " + strings.Join(words[:len(words)-1], " ")
+}
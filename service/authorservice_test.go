@@ -0,0 +1,77 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking author repository //
+type MockAuthorRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuthorRepository) GetAuthor(id string) (*model.Author, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Author), args.Error(1)
+}
+
+func (m *MockAuthorRepository) QueryAuthor(q query.AuthorQuery) ([]model.Author, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Author), args.Error(1)
+}
+
+func (m *MockAuthorRepository) CountAuthor(q query.AuthorQuery) (int, error) {
+	args := m.Called(q)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthorRepository) CreateAuthor(a model.Author) (*model.Author, error) {
+	args := m.Called(a)
+	return args.Get(0).(*model.Author), args.Error(1)
+}
+
+func (m *MockAuthorRepository) UpdateAuthor(a model.Author) (*model.Author, error) {
+	args := m.Called(a)
+	return args.Get(0).(*model.Author), args.Error(1)
+}
+
+func (m *MockAuthorRepository) DeleteAuthor(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// end mocking author repository //
+
+func TestCreateAuthorWithDerivedSortName(t *testing.T) {
+	author := model.Author{Name: " Alan A. A. Donovan "}
+	expected := model.Author{Name: "Alan A. A. Donovan", SortName: "Donovan, Alan A. A."}
+	mockRepo := new(MockAuthorRepository)
+	mockRepo.On("CreateAuthor", expected).Return(&expected, nil)
+
+	sev := NewAuthorService(mockRepo)
+	created, err := sev.Create(author)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "Donovan, Alan A. A.", created.SortName)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateAuthorWithoutName(t *testing.T) {
+	mockRepo := new(MockAuthorRepository)
+
+	sev := NewAuthorService(mockRepo)
+	_, err := sev.Create(model.Author{Name: "  "})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "CreateAuthor", mock.Anything)
+}
+
+func TestSortName(t *testing.T) {
+	assert.Equal(t, "Pike, Rob", sortName("Rob Pike"))
+	assert.Equal(t, "Plato", sortName("Plato"))
+}
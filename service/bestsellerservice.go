@@ -0,0 +1,164 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// DefaultBestsellerSize is number of books on a bestseller list
+const DefaultBestsellerSize = 10
+
+type BestsellerService struct {
+	repo repository.BestsellerRepository
+	size int
+	now  func() time.Time
+}
+
+// NewBestsellerService create new bestseller list service, size is number of books
+// on each list
+func NewBestsellerService(bestsellerRepo repository.BestsellerRepository, size int) *BestsellerService {
+	s := new(BestsellerService)
+	s.repo = bestsellerRepo
+	s.size = size
+	s.now = time.Now
+	return s
+}
+
+// GetList return bestseller list of the category for the week of period, last
+// finished week when period is nil. Lists are only read here, NotFoundError is
+// returned for a week SnapshotLists has not ranked yet
+func (s *BestsellerService) GetList(category string, period *time.Time) (*model.BestsellerList, error) {
+	current := startOfWeek(s.now().UTC())
+	week := current.AddDate(0, 0, -7)
+	if period != nil {
+		week = startOfWeek(*period)
+	}
+	if !week.Before(current) {
+		msg := fmt.Sprintf("week of %s is not finished yet", week.Format(dateLayout))
+		return nil, &bserror.BadParameterError{Msg: msg}
+	}
+	id, err := s.repo.GetCategoryID(category)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.repo.GetBestsellerList(id, week)
+	if _, ok := err.(*bserror.NotFoundError); ok {
+		msg := fmt.Sprintf("bestseller list of %s for %s is not found", category, week.Format(dateLayout))
+		return nil, &bserror.NotFoundError{Msg: msg}
+	}
+	if err != nil {
+		return nil, err
+	}
+	list.Category = category
+	return list, nil
+}
+
+// SnapshotLists rank last finished week of every category and save the lists so a
+// published list never changes, earlier weeks missing are snapshotted first because
+// rank movement builds on them. It returns number of lists created
+func (s *BestsellerService) SnapshotLists() (int, error) {
+	week := startOfWeek(s.now().UTC()).AddDate(0, 0, -7)
+	ids, err := s.repo.GetCategoryIDs()
+	if err != nil {
+		return 0, err
+	}
+	created := 0
+	for _, id := range ids {
+		missing, err := s.missingWeeks(id, week)
+		if err != nil {
+			return created, err
+		}
+		for i := len(missing) - 1; i >= 0; i-- {
+			if _, err := s.snapshot(id, missing[i]); err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// missingWeeks return week and the weeks before it without list, back to the latest
+// week with list or the first week the category sold, latest first, nil when week
+// already has list
+func (s *BestsellerService) missingWeeks(category string, week time.Time) ([]time.Time, error) {
+	periods, err := s.repo.GetBestsellerPeriods(category)
+	if err != nil {
+		return nil, err
+	}
+	first, err := s.repo.GetFirstSaleDay(category)
+	if err != nil {
+		return nil, err
+	}
+	stop := week
+	if first != nil {
+		stop = startOfWeek(*first)
+	}
+	for _, p := range periods {
+		if p.Equal(week) {
+			return nil, nil
+		}
+		if p.Before(week) && !p.Before(stop) {
+			stop = p.AddDate(0, 0, 7)
+			break
+		}
+	}
+	missing := []time.Time{week}
+	for w := week.AddDate(0, 0, -7); !w.Before(stop); w = w.AddDate(0, 0, -7) {
+		missing = append(missing, w)
+	}
+	return missing, nil
+}
+
+// snapshot rank books of the category by units sold in the week and save the list,
+// previous rank, weeks on list and peak rank carry on from earlier lists
+func (s *BestsellerService) snapshot(category string, week time.Time) (*model.BestsellerList, error) {
+	entries, err := s.repo.GetCategorySales(category, week, week.AddDate(0, 0, 7), s.size)
+	if err != nil {
+		return nil, err
+	}
+	bookIDs := []string{}
+	for _, e := range entries {
+		bookIDs = append(bookIDs, e.BookID)
+	}
+	last, err := s.repo.GetLastBestsellerEntries(category, week, bookIDs)
+	if err != nil {
+		return nil, err
+	}
+	history := map[string]model.BestsellerEntry{}
+	for _, e := range last {
+		history[e.BookID] = e
+	}
+
+	previous := week.AddDate(0, 0, -7)
+	for i := range entries {
+		e := &entries[i]
+		e.Period = &week
+		e.Rank = i + 1
+		e.WeeksOnList = 1
+		e.PeakRank = e.Rank
+		h, ok := history[e.BookID]
+		if !ok {
+			continue
+		}
+		e.WeeksOnList = h.WeeksOnList + 1
+		if h.PeakRank < e.PeakRank {
+			e.PeakRank = h.PeakRank
+		}
+		if h.Period != nil && h.Period.Equal(previous) {
+			rank := h.Rank
+			e.PreviousRank = &rank
+		}
+	}
+
+	list := model.BestsellerList{Category: category, Period: &week, Entries: entries}
+	created, err := s.repo.CreateBestsellerList(list)
+	if _, ok := err.(*bserror.ConflictError); ok {
+		return s.repo.GetBestsellerList(category, week)
+	}
+	return created, err
+}
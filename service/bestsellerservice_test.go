@@ -0,0 +1,179 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// start mocking bestseller repository //
+type MockBestsellerRepository struct {
+	mock.Mock
+}
+
+func (m *MockBestsellerRepository) GetCategoryID(category string) (string, error) {
+	args := m.Called(category)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetCategoryIDs() ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetFirstSaleDay(category string) (*time.Time, error) {
+	args := m.Called(category)
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetBestsellerPeriods(category string) ([]time.Time, error) {
+	args := m.Called(category)
+	return args.Get(0).([]time.Time), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetCategorySales(category string, from time.Time, to time.Time,
+	limit int) ([]model.BestsellerEntry, error) {
+	args := m.Called(category, from, to, limit)
+	return args.Get(0).([]model.BestsellerEntry), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetLastBestsellerEntries(category string, before time.Time,
+	bookIDs []string) ([]model.BestsellerEntry, error) {
+	args := m.Called(category, before, bookIDs)
+	return args.Get(0).([]model.BestsellerEntry), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) GetBestsellerList(category string, period time.Time) (*model.BestsellerList, error) {
+	args := m.Called(category, period)
+	return args.Get(0).(*model.BestsellerList), args.Error(1)
+}
+
+func (m *MockBestsellerRepository) CreateBestsellerList(list model.BestsellerList) (*model.BestsellerList, error) {
+	args := m.Called(list)
+	return &list, args.Error(0)
+}
+
+// end mocking bestseller repository //
+
+// wednesday in week starting 2019-12-09, so last finished week starts 2019-12-02
+var bestsellerNow = time.Date(2019, 12, 11, 15, 0, 0, 0, time.UTC)
+
+func weekOf(month time.Month, day int) time.Time {
+	return time.Date(2019, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestGetBestsellerList(t *testing.T) {
+	mockRepo := new(MockBestsellerRepository)
+	week := weekOf(12, 2)
+	list := &model.BestsellerList{Category: "cat-1", Period: &week}
+	mockRepo.On("GetCategoryID", "Programming").Return("cat-1", nil)
+	mockRepo.On("GetBestsellerList", "cat-1", week).Return(list, nil)
+
+	sev := NewBestsellerService(mockRepo, DefaultBestsellerSize)
+	sev.now = func() time.Time { return bestsellerNow }
+	res, err := sev.GetList("Programming", nil)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, week, *res.Period)
+	assert.Equal(t, "Programming", res.Category, "category must be returned as requested")
+	mockRepo.AssertNotCalled(t, "CreateBestsellerList", mock.Anything)
+}
+
+func TestGetBestsellerListNotSnapshotted(t *testing.T) {
+	mockRepo := new(MockBestsellerRepository)
+	mockRepo.On("GetCategoryID", "Programming").Return("cat-1", nil)
+	mockRepo.On("GetBestsellerList", "cat-1", weekOf(12, 2)).
+		Return((*model.BestsellerList)(nil), &bserror.NotFoundError{Msg: "not found"})
+
+	sev := NewBestsellerService(mockRepo, DefaultBestsellerSize)
+	sev.now = func() time.Time { return bestsellerNow }
+	_, err := sev.GetList("Programming", &bestsellerNow)
+	assert.IsType(t, &bserror.BadParameterError{}, err, "current week is not finished")
+
+	period := weekOf(12, 4)
+	_, err = sev.GetList("Programming", &period)
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "CreateBestsellerList", mock.Anything)
+}
+
+func TestSnapshotBestsellerListRankMovement(t *testing.T) {
+	mockRepo := new(MockBestsellerRepository)
+	week := weekOf(12, 2)
+	first := weekOf(10, 1)
+	lastWeek, earlier := weekOf(11, 25), weekOf(11, 11)
+	mockRepo.On("GetCategoryIDs").Return([]string{"cat-1"}, nil)
+	mockRepo.On("GetBestsellerPeriods", "cat-1").Return([]time.Time{lastWeek, earlier}, nil)
+	mockRepo.On("GetFirstSaleDay", "cat-1").Return(&first, nil)
+	mockRepo.On("GetCategorySales", "cat-1", week, weekOf(12, 9), 10).Return([]model.BestsellerEntry{
+		{BookID: "a", Title: "Go in Action", Amount: 20},
+		{BookID: "b", Title: "The Go Programming", Amount: 10},
+	}, nil)
+	mockRepo.On("GetLastBestsellerEntries", "cat-1", week, []string{"a", "b"}).Return([]model.BestsellerEntry{
+		{Period: &lastWeek, Rank: 3, BookID: "a", WeeksOnList: 2, PeakRank: 2},
+		{Period: &earlier, Rank: 1, BookID: "b", WeeksOnList: 4, PeakRank: 1},
+	}, nil)
+	var res model.BestsellerList
+	mockRepo.On("CreateBestsellerList", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		res = args.Get(0).(model.BestsellerList)
+	})
+
+	sev := NewBestsellerService(mockRepo, DefaultBestsellerSize)
+	sev.now = func() time.Time { return bestsellerNow }
+	created, err := sev.SnapshotLists()
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, created)
+	assert.Equal(t, week, *res.Period, "last finished week must be snapshotted")
+	a, b := res.Entries[0], res.Entries[1]
+	assert.Equal(t, 1, a.Rank)
+	assert.Equal(t, 3, *a.PreviousRank, "book a was on list of last week")
+	assert.Equal(t, 3, a.WeeksOnList)
+	assert.Equal(t, 1, a.PeakRank, "new rank is its peak")
+	assert.Equal(t, 2, b.Rank)
+	assert.Nil(t, b.PreviousRank, "book b was not on list of last week")
+	assert.Equal(t, 5, b.WeeksOnList)
+	assert.Equal(t, 1, b.PeakRank, "earlier peak is kept")
+}
+
+func TestSnapshotBestsellerListMissingWeeksFirst(t *testing.T) {
+	mockRepo := new(MockBestsellerRepository)
+	first := time.Date(2019, 11, 20, 0, 0, 0, 0, time.UTC)
+	mockRepo.On("GetCategoryIDs").Return([]string{"cat-1", "cat-2"}, nil)
+	mockRepo.On("GetBestsellerPeriods", "cat-1").Return([]time.Time{}, nil)
+	mockRepo.On("GetFirstSaleDay", "cat-1").Return(&first, nil)
+	mockRepo.On("GetBestsellerPeriods", "cat-2").Return([]time.Time{weekOf(12, 2)}, nil)
+	mockRepo.On("GetFirstSaleDay", "cat-2").Return(&first, nil)
+	mockRepo.On("GetCategorySales", "cat-1", mock.Anything, mock.Anything, 10).
+		Return([]model.BestsellerEntry{}, nil)
+	mockRepo.On("GetLastBestsellerEntries", "cat-1", mock.Anything, []string{}).
+		Return([]model.BestsellerEntry{}, nil)
+	periods := []time.Time{}
+	mockRepo.On("CreateBestsellerList", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		periods = append(periods, *args.Get(0).(model.BestsellerList).Period)
+	})
+
+	sev := NewBestsellerService(mockRepo, DefaultBestsellerSize)
+	sev.now = func() time.Time { return bestsellerNow }
+	created, err := sev.SnapshotLists()
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 3, created, "category already snapshotted must be skipped")
+	assert.Equal(t, []time.Time{weekOf(11, 18), weekOf(11, 25), weekOf(12, 2)}, periods,
+		"weeks since first sale must be snapshotted oldest first")
+}
+
+func TestGetBestsellerListOfUnknownCategory(t *testing.T) {
+	mockRepo := new(MockBestsellerRepository)
+	mockRepo.On("GetCategoryID", "Cooking").Return("", &bserror.NotFoundError{Msg: "not found"})
+
+	sev := NewBestsellerService(mockRepo, DefaultBestsellerSize)
+	_, err := sev.GetList("Cooking", nil)
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "GetBestsellerList", mock.Anything, mock.Anything)
+}
@@ -2,27 +2,64 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/labstack/gommon/log"
 	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
 	"github.com/tsongpon/backend-challenge-2019/query"
-	"github.com/tsongpon/backend-challenge-2019/report"
 	"github.com/tsongpon/backend-challenge-2019/repository"
 )
 
+// authorRoles are roles an author can be credited in
+var authorRoles = map[string]bool{
+	model.RoleAuthor:      true,
+	model.RoleTranslator:  true,
+	model.RoleIllustrator: true,
+	model.RoleEditor:      true,
+}
+
 type BookService struct {
-	bookRepo repository.BookRepository
+	bookRepo      repository.BookRepository
+	authorRepo    repository.AuthorRepository
+	publisherRepo repository.PublisherRepository
+	categoryRepo  repository.CategoryRepository
+	seriesRepo    repository.SeriesRepository
+	workRepo      repository.WorkRepository
 }
 
-func NewBookService(bookRepo repository.BookRepository) *BookService {
+func NewBookService(bookRepo repository.BookRepository,
+	authorRepo repository.AuthorRepository, publisherRepo repository.PublisherRepository,
+	categoryRepo repository.CategoryRepository, seriesRepo repository.SeriesRepository,
+	workRepo repository.WorkRepository) *BookService {
 	s := new(BookService)
 	s.bookRepo = bookRepo
+	s.authorRepo = authorRepo
+	s.publisherRepo = publisherRepo
+	s.categoryRepo = categoryRepo
+	s.seriesRepo = seriesRepo
+	s.workRepo = workRepo
 	return s
 }
 
 func (s *BookService) Create(b model.Book) (*model.Book, error) {
 	log.Info(fmt.Sprintf("create new book, title %s", b.Title))
+	if err := s.checkAuthors(b.Authors); err != nil {
+		return nil, err
+	}
+	if err := s.resolvePublisher(&b); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategories(&b, nil); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeries(&b); err != nil {
+		return nil, err
+	}
+	if err := s.checkWork(b); err != nil {
+		return nil, err
+	}
 	created, err := s.bookRepo.CreateBook(b)
 	if err != nil {
 		log.Error("create book error", err.Error())
@@ -45,6 +82,10 @@ func (s *BookService) GetBook(id string) (*model.Book, error) {
 }
 
 func (s *BookService) QueryBook(q query.BookQuery) ([]model.Book, error) {
+	q, err := normalizeBookQuery(q)
+	if err != nil {
+		return nil, err
+	}
 	if books, err := s.bookRepo.QueryBook(q); err != nil {
 		log.Error("error while listing books", err.Error())
 		return nil, err
@@ -54,14 +95,48 @@ func (s *BookService) QueryBook(q query.BookQuery) ([]model.Book, error) {
 }
 
 func (s *BookService) CountBook(q query.BookQuery) (int, error) {
+	q, err := normalizeBookQuery(q)
+	if err != nil {
+		return 0, err
+	}
 	return s.bookRepo.CountBook(q)
 }
 
+// normalizeBookQuery normalize tags to filter by the same way tags are attached
+func normalizeBookQuery(q query.BookQuery) (query.BookQuery, error) {
+	var err error
+	if q.AnyTags, err = normalizeTags(q.AnyTags); err != nil {
+		return q, err
+	}
+	if q.AllTags, err = normalizeTags(q.AllTags); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
 func (s *BookService) Update(b model.Book) (*model.Book, error) {
-	_, err := s.bookRepo.GetBook(b.ID)
+	current, err := s.bookRepo.GetBook(b.ID)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkAuthors(b.Authors); err != nil {
+		return nil, err
+	}
+	if b.PublisherID == "" && strings.TrimSpace(b.Publisher) == "" {
+		b.PublisherID = current.PublisherID
+		b.Publisher = current.Publisher
+	} else if err := s.resolvePublisher(&b); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCategories(&b, current); err != nil {
+		return nil, err
+	}
+	if err := s.checkSeries(&b); err != nil {
+		return nil, err
+	}
+	if err := s.checkWork(b); err != nil {
+		return nil, err
+	}
 	if updated, err := s.bookRepo.UpdateBook(b); err != nil {
 		log.Error(fmt.Sprintf("update book id %s error, %s", b.ID, err.Error()))
 		return nil, err
@@ -70,48 +145,219 @@ func (s *BookService) Update(b model.Book) (*model.Book, error) {
 	}
 }
 
-func (s *BookService) Delete(id string) error {
-	if err := s.bookRepo.DeleteBook(id); err != nil {
-		log.Error(fmt.Sprintf("delete book id %s error, %s", id, err.Error()))
-		return err
+// checkAuthors validate credits of a book, role is author when not given
+func (s *BookService) checkAuthors(authors []model.BookAuthor) error {
+	credited := map[model.BookAuthor]bool{}
+	for i := range authors {
+		a := &authors[i]
+		if a.Role == "" {
+			a.Role = model.RoleAuthor
+		}
+		if !authorRoles[a.Role] {
+			return &bserror.BadParameterError{Msg: "role must be author, translator, illustrator or editor"}
+		}
+		credit := model.BookAuthor{AuthorID: a.AuthorID, Role: a.Role}
+		if credited[credit] {
+			msg := fmt.Sprintf("author id %s is credited as %s more than once", a.AuthorID, a.Role)
+			return &bserror.BadParameterError{Msg: msg}
+		}
+		credited[credit] = true
+		if _, err := s.authorRepo.GetAuthor(a.AuthorID); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (s *BookService) FillBook(id string, amount int) error {
-	b, err := s.bookRepo.GetBook(id)
+// resolvePublisher set publisher id of the book, publisher given by name only must
+// already exist, NotFoundError is returned otherwise
+func (s *BookService) resolvePublisher(b *model.Book) error {
+	if b.PublisherID != "" {
+		p, err := s.publisherRepo.GetPublisher(b.PublisherID)
+		if err != nil {
+			return err
+		}
+		b.Publisher = p.Name
+		return nil
+	}
+	name := strings.TrimSpace(b.Publisher)
+	if name == "" {
+		return &bserror.BadParameterError{Msg: "publisher or publisher_id is required"}
+	}
+	p, err := s.publisherRepo.GetPublisherByName(name)
+	if _, ok := err.(*bserror.NotFoundError); ok {
+		p, err = s.findPublisherByKey(name)
+	}
 	if err != nil {
 		return err
 	}
-	b.CurrentAmount = b.CurrentAmount + amount
-	if _, err := s.bookRepo.UpdateBook(*b); err != nil {
+	b.PublisherID = p.ID
+	b.Publisher = p.Name
+	return nil
+}
+
+// resolveCategories check categories of the book. Flat category given without
+// categories replace main category of current book, it is created at top level
+// when no category has that slug or name. Categories are kept when neither is
+// given or flat category is unchanged
+// findPublisherByKey return the only publisher whose name differs from given name
+// just in case, punctuation or suffix, publishers are never created from book names
+func (s *BookService) findPublisherByKey(name string) (*model.Publisher, error) {
+	publishers, err := s.publisherRepo.QueryPublisher(query.PublisherQuery{})
+	if err != nil {
+		return nil, err
+	}
+	key := duplicateKey(name)
+	matches := []model.Publisher{}
+	for _, p := range publishers {
+		if duplicateKey(p.Name) == key {
+			matches = append(matches, p)
+		}
+	}
+	if len(matches) > 1 {
+		msg := fmt.Sprintf("publisher %s matches %d publishers, give publisher_id instead", name, len(matches))
+		return nil, &bserror.BadParameterError{Msg: msg}
+	}
+	if len(matches) == 0 {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("publisher %s is not found", name)}
+	}
+	return &matches[0], nil
+}
+
+func (s *BookService) resolveCategories(b *model.Book, current *model.Book) error {
+	if b.Categories != nil {
+		given := map[string]bool{}
+		for i := range b.Categories {
+			c := &b.Categories[i]
+			if given[c.CategoryID] {
+				return &bserror.BadParameterError{Msg: fmt.Sprintf("category id %s is given more than once", c.CategoryID)}
+			}
+			given[c.CategoryID] = true
+			found, err := s.categoryRepo.GetCategory(c.CategoryID)
+			if err != nil {
+				return err
+			}
+			c.Slug = found.Slug
+			c.Name = found.Name
+		}
+		return nil
+	}
+	name := strings.TrimSpace(b.Category)
+	if name == "" || (current != nil && name == current.Category) {
+		return nil
+	}
+	found, err := s.categoryRepo.FindCategory(name)
+	if _, ok := err.(*bserror.NotFoundError); ok {
+		c := model.Category{Slug: categorySlug(name), Name: name}
+		if err := normalizeCategory(&c); err != nil {
+			return err
+		}
+		found, err = s.categoryRepo.CreateCategory(c)
+		if _, ok := err.(*bserror.ConflictError); ok {
+			found, err = s.categoryRepo.FindCategory(c.Slug)
+		}
+	}
+	if err != nil {
 		return err
 	}
+	b.Categories = []model.BookCategory{{CategoryID: found.ID, Slug: found.Slug, Name: found.Name}}
+	if current != nil && len(current.Categories) > 1 {
+		for _, c := range current.Categories[1:] {
+			if c.CategoryID != found.ID {
+				b.Categories = append(b.Categories, c)
+			}
+		}
+	}
 	return nil
 }
 
-func (s *BookService) SaleBook(id string, amount int) error {
-	b, err := s.bookRepo.GetBook(id)
+// checkSeries check series of the book, volume is only allowed within a series
+func (s *BookService) checkSeries(b *model.Book) error {
+	if b.SeriesVolume != nil {
+		v := *b.SeriesVolume
+		// v*100 of a decimal like 1.1 is not exactly whole in binary floating point
+		if v < 0 || math.Abs(v*100-math.Round(v*100)) >= 1e-9 {
+			return &bserror.BadParameterError{Msg: "series_volume must be 0 or more with at most 2 decimals"}
+		}
+		rounded := math.Round(v*100) / 100
+		b.SeriesVolume = &rounded
+	}
+	if b.SeriesID == "" {
+		if b.SeriesVolume != nil {
+			return &bserror.BadParameterError{Msg: "series_volume is given without series_id"}
+		}
+		b.Series = ""
+		return nil
+	}
+	series, err := s.seriesRepo.GetSeries(b.SeriesID)
 	if err != nil {
 		return err
 	}
-	if b.CurrentAmount < amount {
-		msg := fmt.Sprintf("insufficient stock, only %d items left", b.CurrentAmount)
-		err := &bserror.InsufficientStockError{Msg: msg}
-		return err
+	b.Series = series.Name
+	return nil
+}
+
+// checkWork check work the book is an edition of, if any
+func (s *BookService) checkWork(b model.Book) error {
+	if b.WorkID == "" {
+		return nil
 	}
-	b.CurrentAmount = b.CurrentAmount - amount
-	b.SoldAmount = b.SoldAmount + amount
-	if _, err := s.bookRepo.UpdateBook(*b); err != nil {
+	_, err := s.workRepo.GetWork(b.WorkID)
+	return err
+}
+
+func (s *BookService) Delete(id string) error {
+	if err := s.bookRepo.DeleteBook(id); err != nil {
+		log.Error(fmt.Sprintf("delete book id %s error, %s", id, err.Error()))
 		return err
 	}
 	return nil
 }
 
-func (s *BookService) GetBestSallBooks() ([]report.BestSallerBook, error) {
-	return s.bookRepo.GetBestSaller()
+// FillBook add amount to stock on hand, stock and its movement are written together
+func (s *BookService) FillBook(id string, amount int) error {
+	if err := s.bookRepo.FillBook(id, amount); err != nil {
+		log.Error(fmt.Sprintf("fill book id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
 }
 
-func (s *BookService) GetBestSallCategory() ([]report.BestSallerCategory, error) {
-	return s.bookRepo.GetBestSallerByCategory()
+// SaleBook record the sale at current price of the format, paperback sale also
+// reduce stock, customerID is optional and used to verify reviews of the customer.
+// Book, sale and stock movement are written together so reports never see stock
+// change without its sale
+func (s *BookService) SaleBook(id string, amount int, customerID string, format string) error {
+	b, err := s.bookRepo.GetBook(id)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = model.FormatPaperback
+	}
+	var price *float64
+	switch format {
+	case model.FormatPaperback:
+		if b.CurrentAmount < amount {
+			msg := fmt.Sprintf("insufficient stock, only %d items left", b.CurrentAmount)
+			err := &bserror.InsufficientStockError{Msg: msg}
+			return err
+		}
+		b.CurrentAmount = b.CurrentAmount - amount
+		price = b.PaperbackPrice
+	case model.FormatEbook:
+		if b.EbookPrice == nil {
+			return &bserror.BadParameterError{Msg: fmt.Sprintf("book id %s has no ebook", id)}
+		}
+		price = b.EbookPrice
+	default:
+		return &bserror.BadParameterError{Msg: "format must be paperback or ebook"}
+	}
+	b.SoldAmount = b.SoldAmount + amount
+	sale := model.Sale{BookID: id, CustomerID: customerID, Format: format, Amount: amount, UnitPrice: price}
+	if _, err := s.bookRepo.SaleBook(*b, sale); err != nil {
+		log.Error(fmt.Sprintf("record sale of book id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
 }
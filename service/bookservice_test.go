@@ -6,9 +6,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
 	"github.com/tsongpon/backend-challenge-2019/query"
-	"github.com/tsongpon/backend-challenge-2019/report"
 )
 
 // start mocking book repository //
@@ -32,6 +32,11 @@ func (m *MockBookRepository) UpdateBook(b model.Book) (*model.Book, error) {
 	return args.Get(0).(*model.Book), args.Error(1)
 }
 
+func (m *MockBookRepository) FillBook(id string, amount int) error {
+	args := m.Called(id, amount)
+	return args.Error(0)
+}
+
 func (m *MockBookRepository) QueryBook(q query.BookQuery) ([]model.Book, error) {
 	args := m.Called(q)
 	return args.Get(0).([]model.Book), args.Error(1)
@@ -47,17 +52,51 @@ func (m *MockBookRepository) DeleteBook(id string) error {
 	return args.Error(0)
 }
 
-func (m *MockBookRepository) GetBestSaller() ([]report.BestSallerBook, error) {
-	args := m.Called()
-	return args.Get(0).([]report.BestSallerBook), args.Error(1)
+func (m *MockBookRepository) SaleBook(b model.Book, sale model.Sale) (*model.Sale, error) {
+	args := m.Called(b, sale)
+	return args.Get(0).(*model.Sale), args.Error(1)
 }
 
-func (m *MockBookRepository) GetBestSallerByCategory() ([]report.BestSallerCategory, error) {
-	args := m.Called()
-	return args.Get(0).([]report.BestSallerCategory), args.Error(1)
+// end mocking book repository //
+
+// start mocking sale repository //
+type MockSaleRepository struct {
+	mock.Mock
 }
 
-// end mocking book repository //
+func (m *MockSaleRepository) HasPurchased(customerID string, bookID string) (bool, error) {
+	args := m.Called(customerID, bookID)
+	return args.Bool(0), args.Error(1)
+}
+
+// end mocking sale repository //
+
+// start mocking stock repository //
+type MockStockRepository struct {
+	mock.Mock
+}
+
+func (m *MockStockRepository) CreateRestock(restock model.Restock) (*model.Restock, error) {
+	args := m.Called(restock)
+	return args.Get(0).(*model.Restock), args.Error(1)
+}
+
+func (m *MockStockRepository) GetRestocks(status string) ([]model.Restock, error) {
+	args := m.Called(status)
+	return args.Get(0).([]model.Restock), args.Error(1)
+}
+
+func (m *MockStockRepository) GetRestock(id string) (*model.Restock, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Restock), args.Error(1)
+}
+
+func (m *MockStockRepository) UpdateRestockStatus(restock model.Restock) (*model.Restock, error) {
+	args := m.Called(restock)
+	return args.Get(0).(*model.Restock), args.Error(1)
+}
+
+// end mocking stock repository //
 
 func TestCreate(t *testing.T) {
 	now := time.Now()
@@ -97,11 +136,22 @@ func TestCreate(t *testing.T) {
 		Version:        1,
 	}
 
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "Addison-Wesley Professional"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisherByName", "Addison-Wesley Professional").Return(&publisher, nil)
+
+	category := model.Category{ID: "5c3f8a0e-2b1d-4e6f-9a7c-8d2e4f6a1b3c", Slug: "programming", Name: "Programming"}
+	mockCategoryRepo := new(MockCategoryRepository)
+	mockCategoryRepo.On("FindCategory", "Programming").Return(&category, nil)
+
+	withPublisher := book
+	withPublisher.PublisherID = publisher.ID
+	withPublisher.Categories = []model.BookCategory{{CategoryID: category.ID, Slug: "programming", Name: "Programming"}}
 	mockRepo := new(MockBookRepository)
-	mockRepo.On("CreateBook", book).Return(&createdBook, nil)
+	mockRepo.On("CreateBook", withPublisher).Return(&createdBook, nil)
 	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&createdBook, nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), mockPublisherRepo, mockCategoryRepo, new(MockSeriesRepository), new(MockWorkRepository))
 	created, err := sev.Create(book)
 
 	assert.Nil(t, err, "should not get any error")
@@ -138,7 +188,7 @@ func TestGetBook(t *testing.T) {
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&book, nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 
 	b, err := sev.GetBook("a432eee1-be54-44e6-a5ef-8a0455306f4f")
 
@@ -181,7 +231,7 @@ func TestQueryBook(t *testing.T) {
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("QueryBook", q).Return(book, nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 
 	books, err := sev.QueryBook(q)
 	assert.Nil(t, err, "Should not get any error")
@@ -196,7 +246,7 @@ func TestCountBook(t *testing.T) {
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("CountBook", q).Return(1, nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 	count, err := sev.CountBook(q)
 	assert.Nil(t, err, "Should not get any error")
 	assert.Equal(t, 1, count, "have only one book")
@@ -208,7 +258,7 @@ func TestCountDelete(t *testing.T) {
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("DeleteBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 	err := sev.Delete("a432eee1-be54-44e6-a5ef-8a0455306f4f")
 	assert.Nil(t, err, "Should not get any error")
 
@@ -216,50 +266,10 @@ func TestCountDelete(t *testing.T) {
 }
 
 func TestFillBook(t *testing.T) {
-	now := time.Now()
-	paperbackPrice := 1353.29
-	ebookPrice := 1100.00
-	book := model.Book{
-		ID:             "a432eee1-be54-44e6-a5ef-8a0455306f4f",
-		Title:          "The Go Programming",
-		Synopsis:       "All you nee to known about golang",
-		ISBN10:         "0321349601",
-		ISBN13:         "978-0321349606",
-		Language:       "Thai",
-		Publisher:      "Addison-Wesley Professional",
-		Category:       "Programming",
-		Edition:        "1nd Edition, Kindle Edition",
-		SoldAmount:     0,
-		CurrentAmount:  10,
-		PaperbackPrice: &paperbackPrice,
-		EbookPrice:     &ebookPrice,
-		CreatedTime:    &now,
-		ModifiedTime:   &now,
-		Version:        1,
-	}
-	filled := model.Book{
-		ID:             "a432eee1-be54-44e6-a5ef-8a0455306f4f",
-		Title:          "The Go Programming",
-		Synopsis:       "All you nee to known about golang",
-		ISBN10:         "0321349601",
-		ISBN13:         "978-0321349606",
-		Language:       "Thai",
-		Publisher:      "Addison-Wesley Professional",
-		Category:       "Programming",
-		Edition:        "1nd Edition, Kindle Edition",
-		SoldAmount:     0,
-		CurrentAmount:  12,
-		PaperbackPrice: &paperbackPrice,
-		EbookPrice:     &ebookPrice,
-		CreatedTime:    &now,
-		ModifiedTime:   &now,
-		Version:        1,
-	}
 	mockRepo := new(MockBookRepository)
-	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&book, nil)
-	mockRepo.On("UpdateBook", filled).Return(&filled, nil)
+	mockRepo.On("FillBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f", 2).Return(nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 	err := sev.FillBook("a432eee1-be54-44e6-a5ef-8a0455306f4f", 2)
 	assert.Nil(t, err, "should not get any error")
 
@@ -278,6 +288,7 @@ func TestUpdateBook(t *testing.T) {
 		ISBN10:         "0321349601",
 		ISBN13:         "978-0321349606",
 		Language:       "Thai",
+		PublisherID:    "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 		Publisher:      "Addison-Wesley Professional",
 		Category:       "Programming",
 		Edition:        "1nd Edition, Kindle Edition",
@@ -297,6 +308,7 @@ func TestUpdateBook(t *testing.T) {
 		ISBN10:         "0321349601",
 		ISBN13:         "978-0321349606",
 		Language:       "Thai",
+		PublisherID:    "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11",
 		Publisher:      "Addison-Wesley Professional",
 		Category:       "Programming",
 		Edition:        "1nd Edition, Kindle Edition",
@@ -312,8 +324,11 @@ func TestUpdateBook(t *testing.T) {
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&updated, nil)
 	mockRepo.On("UpdateBook", book).Return(&updated, nil)
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "Addison-Wesley Professional"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", publisher.ID).Return(&publisher, nil)
 
-	sev := NewBookService(mockRepo)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
 	result, err := sev.Update(book)
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, "a432eee1-be54-44e6-a5ef-8a0455306f4f", result.ID)
@@ -362,45 +377,175 @@ func TestSallBook(t *testing.T) {
 		ModifiedTime:   &now,
 		Version:        1,
 	}
+	sale := model.Sale{
+		BookID:     "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		CustomerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		Format:     model.FormatPaperback,
+		Amount:     2,
+		UnitPrice:  &paperbackPrice,
+	}
 	mockRepo := new(MockBookRepository)
 	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&book, nil)
-	mockRepo.On("UpdateBook", sold).Return(&sold, nil)
+	mockRepo.On("SaleBook", sold, sale).Return(&sale, nil)
 
-	sev := NewBookService(mockRepo)
-	err := sev.SaleBook("a432eee1-be54-44e6-a5ef-8a0455306f4f", 2)
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	err := sev.SaleBook("a432eee1-be54-44e6-a5ef-8a0455306f4f", 2, "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "")
 	assert.Nil(t, err, "should not get any error")
 
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetBestSallBooks(t *testing.T) {
-	rpt := []report.BestSallerBook{
-		{Ttile: "Go is good", TotalSaleAmount: 5000},
-		{Ttile: "NodeJS is the best", TotalSaleAmount: 50},
+func TestSallEbook(t *testing.T) {
+	ebookPrice := 1100.00
+	book := model.Book{
+		ID:            "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		Title:         "The Go Programming",
+		SoldAmount:    0,
+		CurrentAmount: 0,
+		EbookPrice:    &ebookPrice,
+		Version:       1,
+	}
+	sold := book
+	sold.SoldAmount = 3
+	sale := model.Sale{
+		BookID:    "a432eee1-be54-44e6-a5ef-8a0455306f4f",
+		Format:    model.FormatEbook,
+		Amount:    3,
+		UnitPrice: &ebookPrice,
 	}
 	mockRepo := new(MockBookRepository)
-	mockRepo.On("GetBestSaller").Return(rpt, nil)
+	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&book, nil)
+	mockRepo.On("SaleBook", sold, sale).Return(&sale, nil)
 
-	sev := NewBookService(mockRepo)
-	bsrpt, err := sev.GetBestSallBooks()
-	assert.Nil(t, err, "Should not get any error")
-	assert.Equal(t, 2, len(bsrpt), "report contain 2 entry")
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	err := sev.SaleBook("a432eee1-be54-44e6-a5ef-8a0455306f4f", 3, "", model.FormatEbook)
+	assert.Nil(t, err, "ebook sale should not need stock")
 
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetBestSallCategory(t *testing.T) {
-	rpt := []report.BestSallerCategory{
-		{Category: "Programming", TotalSaleAmount: 5000},
-		{Category: "How to get rich", TotalSaleAmount: 50},
-	}
+func TestSallUnknownFormat(t *testing.T) {
+	book := model.Book{ID: "a432eee1-be54-44e6-a5ef-8a0455306f4f", CurrentAmount: 10}
 	mockRepo := new(MockBookRepository)
-	mockRepo.On("GetBestSallerByCategory").Return(rpt, nil)
+	mockRepo.On("GetBook", "a432eee1-be54-44e6-a5ef-8a0455306f4f").Return(&book, nil)
 
-	sev := NewBookService(mockRepo)
-	bsrpt, err := sev.GetBestSallCategory()
-	assert.Nil(t, err, "Should not get any error")
-	assert.Equal(t, 2, len(bsrpt), "report contain 2 entry")
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	err := sev.SaleBook("a432eee1-be54-44e6-a5ef-8a0455306f4f", 1, "", "audiobook")
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "SaleBook", mock.Anything, mock.Anything)
+}
 
+func TestCreateBookWithAuthors(t *testing.T) {
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	book := model.Book{Title: "The Go Programming", PublisherID: "aw", Authors: []model.BookAuthor{
+		{AuthorID: "pike"},
+		{AuthorID: "kernighan", Role: model.RoleEditor},
+	}}
+	mockRepo := new(MockBookRepository)
+	mockRepo.On("CreateBook", mock.MatchedBy(func(b model.Book) bool {
+		return assert.ObjectsAreEqual([]model.BookAuthor{
+			{AuthorID: "pike", Role: model.RoleAuthor},
+			{AuthorID: "kernighan", Role: model.RoleEditor},
+		}, b.Authors)
+	})).Return(&model.Book{ID: bookID}, nil)
+	mockRepo.On("GetBook", bookID).Return(&model.Book{ID: bookID}, nil)
+	mockAuthorRepo := new(MockAuthorRepository)
+	mockAuthorRepo.On("GetAuthor", mock.Anything).Return(&model.Author{}, nil)
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", "aw").Return(&model.Publisher{ID: "aw", Name: "Addison-Wesley"}, nil)
+
+	sev := NewBookService(mockRepo, mockAuthorRepo, mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(book)
+
+	assert.Nil(t, err, "should not get any error")
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCreateBookWithUnknownAuthorRole(t *testing.T) {
+	book := model.Book{Title: "The Go Programming", Authors: []model.BookAuthor{{AuthorID: "pike", Role: "ghost"}}}
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(book)
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
+
+func TestCreateBookWithMissingAuthor(t *testing.T) {
+	book := model.Book{Title: "The Go Programming", Authors: []model.BookAuthor{{AuthorID: "pike"}}}
+	mockRepo := new(MockBookRepository)
+	mockAuthorRepo := new(MockAuthorRepository)
+	mockAuthorRepo.On("GetAuthor", "pike").Return((*model.Author)(nil), &bserror.NotFoundError{Msg: "not found"})
+
+	sev := NewBookService(mockRepo, mockAuthorRepo, new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(book)
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
+
+func TestUpdateBookKeepAuthorsWhenNotGiven(t *testing.T) {
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	book := model.Book{ID: bookID, Title: "The Go Programming", Version: 1}
+	mockRepo := new(MockBookRepository)
+	mockRepo.On("GetBook", bookID).Return(&book, nil)
+	mockRepo.On("UpdateBook", book).Return(&book, nil)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Update(book)
+
+	assert.Nil(t, err, "should not get any error")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateBookWithPublisherNameVariant(t *testing.T) {
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	book := model.Book{Title: "The Go Programming", Publisher: " Addison-Wesley Inc. "}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisherByName", "Addison-Wesley Inc.").
+		Return((*model.Publisher)(nil), &bserror.NotFoundError{Msg: "not found"})
+	mockPublisherRepo.On("QueryPublisher", query.PublisherQuery{}).Return([]model.Publisher{
+		{ID: "aw", Name: "Addison-Wesley"},
+		{ID: "mn", Name: "Manning"},
+	}, nil)
+	mockRepo := new(MockBookRepository)
+	mockRepo.On("CreateBook", model.Book{Title: "The Go Programming", PublisherID: "aw", Publisher: "Addison-Wesley"}).
+		Return(&model.Book{ID: bookID}, nil)
+	mockRepo.On("GetBook", bookID).Return(&model.Book{ID: bookID}, nil)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(book)
+
+	assert.Nil(t, err, "should not get any error")
+	mockRepo.AssertExpectations(t)
+	mockPublisherRepo.AssertNotCalled(t, "CreatePublisher", mock.Anything)
+}
+
+func TestCreateBookWithUnknownPublisher(t *testing.T) {
+	book := model.Book{Title: "The Go Programming", Publisher: "Addison Wesly"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisherByName", "Addison Wesly").
+		Return((*model.Publisher)(nil), &bserror.NotFoundError{Msg: "not found"})
+	mockPublisherRepo.On("QueryPublisher", query.PublisherQuery{}).
+		Return([]model.Publisher{{ID: "aw", Name: "Addison-Wesley"}}, nil)
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(book)
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "unknown publisher must not be created")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+	mockPublisherRepo.AssertNotCalled(t, "CreatePublisher", mock.Anything)
+}
+
+func TestCreateBookWithoutPublisher(t *testing.T) {
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository), new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(model.Book{Title: "The Go Programming"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// validSlug is lower case words of letters and digits joined by dash
+var validSlug = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// slugSeparator match runs of characters which are not allowed in slug
+var slugSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+
+type CategoryService struct {
+	repo repository.CategoryRepository
+}
+
+func NewCategoryService(categoryRepo repository.CategoryRepository) *CategoryService {
+	s := new(CategoryService)
+	s.repo = categoryRepo
+	return s
+}
+
+func (s *CategoryService) GetCategory(id string) (*model.Category, error) {
+	return s.repo.GetCategory(id)
+}
+
+func (s *CategoryService) QueryCategory(q query.CategoryQuery) ([]model.Category, error) {
+	if q.Root != "" {
+		if _, err := s.repo.GetCategory(q.Root); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.QueryCategory(q)
+}
+
+// Create create category below parent, top level when parent is not given
+func (s *CategoryService) Create(c model.Category) (*model.Category, error) {
+	log.Info(fmt.Sprintf("create new category, name %s", c.Name))
+	if err := normalizeCategory(&c); err != nil {
+		return nil, err
+	}
+	if c.ParentID != "" {
+		if _, err := s.repo.GetCategory(c.ParentID); err != nil {
+			return nil, err
+		}
+	}
+	created, err := s.repo.CreateCategory(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCategory(created.ID)
+}
+
+// Update change slug and names of category, it is moved by Move
+func (s *CategoryService) Update(c model.Category) (*model.Category, error) {
+	if _, err := s.repo.GetCategory(c.ID); err != nil {
+		return nil, err
+	}
+	if err := normalizeCategory(&c); err != nil {
+		return nil, err
+	}
+	updated, err := s.repo.UpdateCategory(c)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCategory(updated.ID)
+}
+
+// Delete delete category without books and categories below it
+func (s *CategoryService) Delete(id string) error {
+	if _, err := s.repo.GetCategory(id); err != nil {
+		return err
+	}
+	return s.repo.DeleteCategory(id)
+}
+
+// Move move category with categories below it under parent, to top level when
+// parentID is empty
+func (s *CategoryService) Move(id string, parentID string) (*model.Category, error) {
+	c, err := s.repo.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	if parentID != "" {
+		parent, err := s.repo.GetCategory(parentID)
+		if err != nil {
+			return nil, err
+		}
+		if isBelow(*parent, *c) {
+			return nil, &bserror.BadParameterError{Msg: "category can not be moved below itself"}
+		}
+	}
+	if c.ParentID == parentID {
+		return c, nil
+	}
+	log.Info(fmt.Sprintf("move category %s under %q", id, parentID))
+	if err := s.repo.MoveCategory(id, parentID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCategory(id)
+}
+
+// Merge move books and categories below merged categories to target category and
+// delete merged categories
+func (s *CategoryService) Merge(targetID string, mergedIDs []string) (*model.Category, error) {
+	if len(mergedIDs) == 0 {
+		return nil, &bserror.BadParameterError{Msg: "category_ids is required"}
+	}
+	target, err := s.repo.GetCategory(targetID)
+	if err != nil {
+		return nil, err
+	}
+	merged := map[string]bool{}
+	for _, id := range mergedIDs {
+		if merged[id] {
+			return nil, &bserror.BadParameterError{Msg: fmt.Sprintf("category id %s is given more than once", id)}
+		}
+		merged[id] = true
+		c, err := s.repo.GetCategory(id)
+		if err != nil {
+			return nil, err
+		}
+		if isBelow(*target, *c) {
+			return nil, &bserror.BadParameterError{Msg: "category can not be merged into itself or a category below it"}
+		}
+	}
+	log.Info(fmt.Sprintf("merge categories %s into %s", strings.Join(mergedIDs, ", "), targetID))
+	if err := s.repo.MergeCategories(targetID, mergedIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCategory(targetID)
+}
+
+// NormalizeSlugs give categories whose slug validSlug rejects, like slugs made from
+// free text categories by migration, slug derived from their name the same way as
+// new categories, number suffix keeps them unique. It returns number of categories
+// changed
+func (s *CategoryService) NormalizeSlugs() (int, error) {
+	categories, err := s.repo.QueryCategory(query.CategoryQuery{})
+	if err != nil {
+		return 0, err
+	}
+	taken := map[string]bool{}
+	for _, c := range categories {
+		taken[c.Slug] = true
+	}
+	changed := 0
+	for _, c := range categories {
+		if validSlug.MatchString(c.Slug) {
+			continue
+		}
+		base := categorySlug(strings.TrimSpace(c.Name))
+		slug := base
+		for i := 2; taken[slug]; i++ {
+			slug = fmt.Sprintf("%s-%d", base, i)
+		}
+		log.Info(fmt.Sprintf("change slug of category id %s from %q to %q", c.ID, c.Slug, slug))
+		c.Slug = slug
+		c.Names = nil
+		if _, err := s.repo.UpdateCategory(c); err != nil {
+			return changed, err
+		}
+		taken[slug] = true
+		changed++
+	}
+	return changed, nil
+}
+
+// isBelow return true when c is root or any category below root
+func isBelow(c model.Category, root model.Category) bool {
+	return c.ID == root.ID || strings.HasPrefix(c.Path, root.Path+"/")
+}
+
+// normalizeCategory trim names, slug is derived from name when not given
+func normalizeCategory(c *model.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	c.Slug = strings.TrimSpace(c.Slug)
+	if c.Name == "" {
+		return &bserror.BadParameterError{Msg: "name is required"}
+	}
+	if c.Slug == "" {
+		c.Slug = categorySlug(c.Name)
+	}
+	if !validSlug.MatchString(c.Slug) {
+		return &bserror.BadParameterError{Msg: "slug must be lower case letters and digits joined by dash"}
+	}
+	for locale, name := range c.Names {
+		name = strings.TrimSpace(name)
+		if strings.TrimSpace(locale) == "" || name == "" {
+			return &bserror.BadParameterError{Msg: "localized names must have locale and name"}
+		}
+		c.Names[locale] = name
+	}
+	return nil
+}
+
+// categorySlug derive slug of category from name, names without latin letters or
+// digits like Thai names get slug generated from hash of the name
+func categorySlug(name string) string {
+	if slug := slugify(name); slug != "" {
+		return slug
+	}
+	sum := sha1.Sum([]byte(name))
+	return "category-" + hex.EncodeToString(sum[:])[:12]
+}
+
+// slugify turn name into slug, like "Science Fiction & Fantasy" into
+// "science-fiction-fantasy"
+func slugify(name string) string {
+	return strings.Trim(slugSeparator.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
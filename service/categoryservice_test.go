@@ -0,0 +1,165 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking category repository //
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) GetCategory(id string) (*model.Category, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) FindCategory(key string) (*model.Category, error) {
+	args := m.Called(key)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) QueryCategory(q query.CategoryQuery) ([]model.Category, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) CreateCategory(c model.Category) (*model.Category, error) {
+	args := m.Called(c)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) UpdateCategory(c model.Category) (*model.Category, error) {
+	args := m.Called(c)
+	return args.Get(0).(*model.Category), args.Error(1)
+}
+
+func (m *MockCategoryRepository) DeleteCategory(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) MoveCategory(id string, parentID string) error {
+	args := m.Called(id, parentID)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) MergeCategories(targetID string, mergedIDs []string) error {
+	args := m.Called(targetID, mergedIDs)
+	return args.Error(0)
+}
+
+// end mocking category repository //
+
+func TestCreateCategoryWithDerivedSlug(t *testing.T) {
+	category := model.Category{Name: " Science Fiction & Fantasy ", ParentID: "fiction"}
+	expected := model.Category{Name: "Science Fiction & Fantasy", Slug: "science-fiction-fantasy", ParentID: "fiction"}
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("GetCategory", "fiction").Return(&model.Category{ID: "fiction"}, nil)
+	mockRepo.On("CreateCategory", expected).Return(&model.Category{ID: "scifi"}, nil)
+	mockRepo.On("GetCategory", "scifi").Return(&expected, nil)
+
+	sev := NewCategoryService(mockRepo)
+	created, err := sev.Create(category)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "science-fiction-fantasy", created.Slug)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateCategoryWithInvalidSlug(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Create(model.Category{Name: "Go", Slug: "Go Lang"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "CreateCategory", mock.Anything)
+}
+
+func TestCreateCategoryWithThaiName(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("CreateCategory", mock.Anything).Return(&model.Category{ID: "novel"}, nil)
+	mockRepo.On("GetCategory", "novel").Return(&model.Category{ID: "novel"}, nil)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Create(model.Category{Name: "นิยาย"})
+
+	assert.Nil(t, err, "should not get any error")
+	slug := mockRepo.Calls[0].Arguments.Get(0).(model.Category).Slug
+	assert.Regexp(t, validSlug, slug, "slug must be generated")
+	assert.Equal(t, slug, categorySlug("นิยาย"), "same name must get same slug")
+}
+
+func TestNormalizeCategorySlugs(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("QueryCategory", query.CategoryQuery{}).Return([]model.Category{
+		{ID: "1", Slug: "sci-fi-fantasy", Name: "Sci-Fi, Fantasy", Version: 1},
+		{ID: "2", Slug: "sci-fi-fantasy!", Name: "Sci-Fi; Fantasy!", Version: 1},
+		{ID: "3", Slug: "c++", Name: "C++", Version: 2},
+	}, nil)
+	mockRepo.On("UpdateCategory", mock.Anything).Return(&model.Category{}, nil)
+
+	sev := NewCategoryService(mockRepo)
+	changed, err := sev.NormalizeSlugs()
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, changed)
+	mockRepo.AssertCalled(t, "UpdateCategory", model.Category{ID: "2", Slug: "sci-fi-fantasy-2", Name: "Sci-Fi; Fantasy!", Version: 1})
+	mockRepo.AssertCalled(t, "UpdateCategory", model.Category{ID: "3", Slug: "c", Name: "C++", Version: 2})
+}
+
+func TestMoveCategoryBelowItself(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("GetCategory", "programming").Return(&model.Category{ID: "programming", Path: "programming"}, nil)
+	mockRepo.On("GetCategory", "go").Return(&model.Category{ID: "go", ParentID: "programming", Path: "programming/go"}, nil)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Move("programming", "go")
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "MoveCategory", mock.Anything, mock.Anything)
+}
+
+func TestMoveCategoryToTopLevel(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("GetCategory", "go").Return(&model.Category{ID: "go", ParentID: "programming", Path: "programming/go"}, nil)
+	mockRepo.On("MoveCategory", "go", "").Return(nil)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Move("go", "")
+
+	assert.Nil(t, err, "should not get any error")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMergeCategoryIntoCategoryBelowIt(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("GetCategory", "programming").Return(&model.Category{ID: "programming", Path: "programming"}, nil)
+	mockRepo.On("GetCategory", "go").Return(&model.Category{ID: "go", ParentID: "programming", Path: "programming/go"}, nil)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Merge("go", []string{"programming"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "MergeCategories", mock.Anything, mock.Anything)
+}
+
+func TestMergeSiblingCategories(t *testing.T) {
+	mockRepo := new(MockCategoryRepository)
+	mockRepo.On("GetCategory", "golang").Return(&model.Category{ID: "golang", Path: "programming/golang"}, nil)
+	mockRepo.On("GetCategory", "go").Return(&model.Category{ID: "go", Path: "programming/go"}, nil)
+	mockRepo.On("MergeCategories", "go", []string{"golang"}).Return(nil)
+
+	sev := NewCategoryService(mockRepo)
+	_, err := sev.Merge("go", []string{"golang"})
+
+	assert.Nil(t, err, "should not get any error")
+	mockRepo.AssertExpectations(t)
+}
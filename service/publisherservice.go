@@ -0,0 +1,196 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// publisherSuffixes are trailing words ignored when looking for duplicated publishers
+var publisherSuffixes = map[string]bool{
+	"books": true, "publishing": true, "publishers": true, "publisher": true, "press": true,
+	"media": true, "group": true, "company": true, "co": true, "inc": true, "ltd": true, "llc": true,
+}
+
+type PublisherService struct {
+	repo repository.PublisherRepository
+}
+
+func NewPublisherService(publisherRepo repository.PublisherRepository) *PublisherService {
+	s := new(PublisherService)
+	s.repo = publisherRepo
+	return s
+}
+
+func (s *PublisherService) GetPublisher(id string) (*model.Publisher, error) {
+	return s.repo.GetPublisher(id)
+}
+
+func (s *PublisherService) QueryPublisher(q query.PublisherQuery) ([]model.Publisher, error) {
+	return s.repo.QueryPublisher(q)
+}
+
+func (s *PublisherService) CountPublisher(q query.PublisherQuery) (int, error) {
+	return s.repo.CountPublisher(q)
+}
+
+func (s *PublisherService) Create(p model.Publisher) (*model.Publisher, error) {
+	log.Info(fmt.Sprintf("create new publisher, name %s", p.Name))
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return nil, &bserror.BadParameterError{Msg: "name is required"}
+	}
+	return s.repo.CreatePublisher(p)
+}
+
+func (s *PublisherService) Update(p model.Publisher) (*model.Publisher, error) {
+	if _, err := s.repo.GetPublisher(p.ID); err != nil {
+		return nil, err
+	}
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return nil, &bserror.BadParameterError{Msg: "name is required"}
+	}
+	updated, err := s.repo.UpdatePublisher(p)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPublisher(updated.ID)
+}
+
+// Delete delete publisher without books
+func (s *PublisherService) Delete(id string) error {
+	if _, err := s.repo.GetPublisher(id); err != nil {
+		return err
+	}
+	return s.repo.DeletePublisher(id)
+}
+
+// Merge move books of merged publishers to target publisher and delete merged publishers
+func (s *PublisherService) Merge(targetID string, mergedIDs []string) (*model.Publisher, error) {
+	if len(mergedIDs) == 0 {
+		return nil, &bserror.BadParameterError{Msg: "publisher_ids is required"}
+	}
+	if _, err := s.repo.GetPublisher(targetID); err != nil {
+		return nil, err
+	}
+	for _, id := range mergedIDs {
+		if id == targetID {
+			return nil, &bserror.BadParameterError{Msg: "publisher can not be merged into itself"}
+		}
+		if _, err := s.repo.GetPublisher(id); err != nil {
+			return nil, err
+		}
+	}
+	log.Info(fmt.Sprintf("merge publishers %s into %s", strings.Join(mergedIDs, ", "), targetID))
+	if err := s.repo.MergePublishers(targetID, mergedIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPublisher(targetID)
+}
+
+// FindDuplicates return groups of publishers whose names differ only in case,
+// punctuation or suffix like "Press" or "Inc", publisher to keep comes first in
+// its group, which is the one with most books
+func (s *PublisherService) FindDuplicates() ([][]model.Publisher, error) {
+	publishers, err := s.repo.QueryPublisher(query.PublisherQuery{})
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{}
+	byKey := map[string][]model.Publisher{}
+	for _, p := range publishers {
+		key := duplicateKey(p.Name)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], p)
+	}
+	groups := [][]model.Publisher{}
+	for _, key := range keys {
+		group := byKey[key]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].BookCount > group[j].BookCount
+		})
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
+
+// MergeDuplicates merge each given group of publisher ids into its first publisher,
+// every group must be part of one group FindDuplicates returns so publishers are only
+// merged after the caller reviewed them. Merged groups are returned
+func (s *PublisherService) MergeDuplicates(groups [][]string) ([][]model.Publisher, error) {
+	if len(groups) == 0 {
+		return nil, &bserror.BadParameterError{Msg: "groups is required"}
+	}
+	found, err := s.FindDuplicates()
+	if err != nil {
+		return nil, err
+	}
+	groupOf := map[string]int{}
+	byID := map[string]model.Publisher{}
+	for i, group := range found {
+		for _, p := range group {
+			groupOf[p.ID] = i
+			byID[p.ID] = p
+		}
+	}
+	seen := map[string]bool{}
+	merged := [][]model.Publisher{}
+	for _, ids := range groups {
+		if len(ids) < 2 {
+			return nil, &bserror.BadParameterError{Msg: "each group must have at least 2 publisher ids"}
+		}
+		group := []model.Publisher{}
+		for _, id := range ids {
+			i, ok := groupOf[id]
+			if !ok || i != groupOf[ids[0]] {
+				msg := fmt.Sprintf("publisher id %s is not a duplicate of publisher id %s", id, ids[0])
+				return nil, &bserror.BadParameterError{Msg: msg}
+			}
+			if seen[id] {
+				return nil, &bserror.BadParameterError{Msg: fmt.Sprintf("publisher id %s is given more than once", id)}
+			}
+			seen[id] = true
+			group = append(group, byID[id])
+		}
+		merged = append(merged, group)
+	}
+	for _, ids := range groups {
+		if _, err := s.Merge(ids[0], ids[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return merged, nil
+}
+
+// duplicateKey reduce publisher name to lower case letters and digits without
+// trailing suffix words
+func duplicateKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ',' || r == '.' || unicode.IsSpace(r)
+	})
+	for len(words) > 1 && publisherSuffixes[strings.Trim(words[len(words)-1], "'")] {
+		words = words[:len(words)-1]
+	}
+	key := strings.Builder{}
+	for _, w := range words {
+		for _, r := range w {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				key.WriteRune(r)
+			}
+		}
+	}
+	return key.String()
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking publisher repository //
+type MockPublisherRepository struct {
+	mock.Mock
+}
+
+func (m *MockPublisherRepository) GetPublisher(id string) (*model.Publisher, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Publisher), args.Error(1)
+}
+
+func (m *MockPublisherRepository) GetPublisherByName(name string) (*model.Publisher, error) {
+	args := m.Called(name)
+	return args.Get(0).(*model.Publisher), args.Error(1)
+}
+
+func (m *MockPublisherRepository) QueryPublisher(q query.PublisherQuery) ([]model.Publisher, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Publisher), args.Error(1)
+}
+
+func (m *MockPublisherRepository) CountPublisher(q query.PublisherQuery) (int, error) {
+	args := m.Called(q)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockPublisherRepository) CreatePublisher(p model.Publisher) (*model.Publisher, error) {
+	args := m.Called(p)
+	return args.Get(0).(*model.Publisher), args.Error(1)
+}
+
+func (m *MockPublisherRepository) UpdatePublisher(p model.Publisher) (*model.Publisher, error) {
+	args := m.Called(p)
+	return args.Get(0).(*model.Publisher), args.Error(1)
+}
+
+func (m *MockPublisherRepository) DeletePublisher(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockPublisherRepository) MergePublishers(targetID string, mergedIDs []string) error {
+	args := m.Called(targetID, mergedIDs)
+	return args.Error(0)
+}
+
+// end mocking publisher repository //
+
+func TestCreatePublisherWithoutName(t *testing.T) {
+	mockRepo := new(MockPublisherRepository)
+
+	sev := NewPublisherService(mockRepo)
+	_, err := sev.Create(model.Publisher{Name: "  "})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "CreatePublisher", mock.Anything)
+}
+
+func TestMergePublisherIntoItself(t *testing.T) {
+	mockRepo := new(MockPublisherRepository)
+	mockRepo.On("GetPublisher", "oreilly").Return(&model.Publisher{ID: "oreilly"}, nil)
+
+	sev := NewPublisherService(mockRepo)
+	_, err := sev.Merge("oreilly", []string{"oreilly"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "MergePublishers", mock.Anything, mock.Anything)
+}
+
+func TestFindDuplicatePublishers(t *testing.T) {
+	mockRepo := new(MockPublisherRepository)
+	mockRepo.On("QueryPublisher", query.PublisherQuery{}).Return([]model.Publisher{
+		{ID: "1", Name: "Addison-Wesley", BookCount: 1},
+		{ID: "2", Name: "O'Reilly Media", BookCount: 2},
+		{ID: "3", Name: "Addison Wesley Professional", BookCount: 3},
+		{ID: "4", Name: "o'reilly", BookCount: 5},
+		{ID: "5", Name: "Manning Publications", BookCount: 1},
+		{ID: "6", Name: "addison-wesley inc.", BookCount: 4},
+	}, nil)
+
+	sev := NewPublisherService(mockRepo)
+	groups, err := sev.FindDuplicates()
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(groups))
+	assert.Equal(t, []string{"6", "1"}, []string{groups[0][0].ID, groups[0][1].ID})
+	assert.Equal(t, []string{"4", "2"}, []string{groups[1][0].ID, groups[1][1].ID})
+}
+
+func TestMergeDuplicatePublishers(t *testing.T) {
+	mockRepo := new(MockPublisherRepository)
+	mockRepo.On("QueryPublisher", query.PublisherQuery{}).Return([]model.Publisher{
+		{ID: "1", Name: "Manning", BookCount: 1},
+		{ID: "2", Name: "Manning Press", BookCount: 7},
+		{ID: "3", Name: "manning.", BookCount: 0},
+	}, nil)
+	mockRepo.On("GetPublisher", mock.Anything).Return(&model.Publisher{}, nil)
+	mockRepo.On("MergePublishers", "2", []string{"1"}).Return(nil)
+
+	sev := NewPublisherService(mockRepo)
+	groups, err := sev.MergeDuplicates([][]string{{"2", "1"}})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, 2, len(groups[0]), "only given publishers are merged")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestMergePublishersNotDuplicated(t *testing.T) {
+	mockRepo := new(MockPublisherRepository)
+	mockRepo.On("QueryPublisher", query.PublisherQuery{}).Return([]model.Publisher{
+		{ID: "1", Name: "Manning", BookCount: 1},
+		{ID: "2", Name: "Manning Press", BookCount: 7},
+		{ID: "3", Name: "Packt", BookCount: 2},
+	}, nil)
+
+	sev := NewPublisherService(mockRepo)
+	_, err := sev.MergeDuplicates([][]string{{"2", "3"}})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "MergePublishers", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+type ReplyService struct {
+	repo       repository.ReplyRepository
+	reviewRepo repository.ReviewRepository
+}
+
+func NewReplyService(replyRepo repository.ReplyRepository, reviewRepo repository.ReviewRepository) *ReplyService {
+	s := new(ReplyService)
+	s.repo = replyRepo
+	s.reviewRepo = reviewRepo
+	return s
+}
+
+// GetReply return reply only when it belongs to given review of given book
+func (s *ReplyService) GetReply(bookID string, reviewID string, id string) (*model.Reply, error) {
+	if _, err := reviewOfBook(s.reviewRepo, bookID, reviewID); err != nil {
+		return nil, err
+	}
+	reply, err := s.repo.GetReply(id)
+	if err != nil {
+		return nil, err
+	}
+	if reply.ReviewID != reviewID {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("reply id %s is not found", id)}
+	}
+	return reply, nil
+}
+
+func (s *ReplyService) GetReviewReplies(bookID string, reviewID string) ([]model.Reply, error) {
+	if _, err := reviewOfBook(s.reviewRepo, bookID, reviewID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReplyByReviews([]string{reviewID})
+}
+
+func (s *ReplyService) CreateReply(bookID string, reply model.Reply) (*model.Reply, error) {
+	if _, err := reviewOfBook(s.reviewRepo, bookID, reply.ReviewID); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.CreateReply(reply)
+	if err != nil {
+		log.Error(fmt.Sprintf("create reply of review id %s error", reply.ReviewID))
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *ReplyService) UpdateReply(bookID string, reply model.Reply) (*model.Reply, error) {
+	existing, err := s.GetReply(bookID, reply.ReviewID, reply.ID)
+	if err != nil {
+		return nil, err
+	}
+	if reply.AuthorID != existing.AuthorID {
+		msg := fmt.Sprintf("reply id %s belongs to another author", reply.ID)
+		return nil, &bserror.ForbiddenError{Msg: msg}
+	}
+	if _, err := s.repo.UpdateReply(reply); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReply(reply.ID)
+}
+
+func (s *ReplyService) Delete(bookID string, reviewID string, id string) error {
+	if _, err := s.GetReply(bookID, reviewID, id); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteReply(id); err != nil {
+		log.Error(fmt.Sprintf("delete reply id %s error, %s", id, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// AttachReplies load replies of all given reviews and nest them into each review
+func (s *ReplyService) AttachReplies(reviews []model.Review) error {
+	ids := []string{}
+	for _, r := range reviews {
+		ids = append(ids, r.ID)
+	}
+	replies, err := s.repo.GetReplyByReviews(ids)
+	if err != nil {
+		return err
+	}
+	byReview := map[string][]model.Reply{}
+	for _, reply := range replies {
+		byReview[reply.ReviewID] = append(byReview[reply.ReviewID], reply)
+	}
+	for i := range reviews {
+		reviews[i].Replies = byReview[reviews[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// start mocking review reply repository //
+type MockReplyRepository struct {
+	mock.Mock
+}
+
+func (m *MockReplyRepository) GetReply(id string) (*model.Reply, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Reply), args.Error(1)
+}
+func (m *MockReplyRepository) GetReplyByReviews(reviewIDs []string) ([]model.Reply, error) {
+	args := m.Called(reviewIDs)
+	return args.Get(0).([]model.Reply), args.Error(1)
+}
+func (m *MockReplyRepository) CreateReply(reply model.Reply) (*model.Reply, error) {
+	args := m.Called(reply)
+	return args.Get(0).(*model.Reply), args.Error(1)
+}
+func (m *MockReplyRepository) UpdateReply(reply model.Reply) (*model.Reply, error) {
+	args := m.Called(reply)
+	return args.Get(0).(*model.Reply), args.Error(1)
+}
+func (m *MockReplyRepository) DeleteReply(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// end mocking review reply repository //
+
+func TestCreateReplyOfMissingReview(t *testing.T) {
+	reply := model.Reply{ReviewID: "63ce552e-b750-4665-acf3-568a2e844a83", AuthorID: "staff-1", Description: "Thanks"}
+	mockReviewRepo := new(MockReviewRepository)
+	mockReviewRepo.On("GetReview", reply.ReviewID).
+		Return((*model.Review)(nil), &bserror.NotFoundError{Msg: "not found"})
+	mockRepo := new(MockReplyRepository)
+
+	sev := NewReplyService(mockRepo, mockReviewRepo)
+	created, err := sev.CreateReply("52b3637a-6984-401e-84d2-2aa6b9d55665", reply)
+
+	assert.Nil(t, created, "should not create reply")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "CreateReply", mock.Anything)
+}
+
+func TestGetReplyOfAnotherReview(t *testing.T) {
+	review := model.Review{ID: "review-2", BookID: "book-1"}
+	reply := model.Reply{ID: "r1", ReviewID: "review-1", AuthorID: "staff-1", Description: "Thanks"}
+	mockReviewRepo := new(MockReviewRepository)
+	mockReviewRepo.On("GetReview", "review-2").Return(&review, nil)
+	mockRepo := new(MockReplyRepository)
+	mockRepo.On("GetReply", "r1").Return(&reply, nil)
+
+	sev := NewReplyService(mockRepo, mockReviewRepo)
+	found, err := sev.GetReply("book-1", "review-2", "r1")
+
+	assert.Nil(t, found, "should not get reply of another review")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+}
+
+func TestUpdateReplyOfAnotherAuthor(t *testing.T) {
+	review := model.Review{ID: "review-1", BookID: "book-1"}
+	existing := model.Reply{ID: "r1", ReviewID: "review-1", AuthorID: "staff-1", Description: "Thanks", Version: 1}
+	toUpdate := existing
+	toUpdate.AuthorID = "staff-2"
+	mockReviewRepo := new(MockReviewRepository)
+	mockReviewRepo.On("GetReview", "review-1").Return(&review, nil)
+	mockRepo := new(MockReplyRepository)
+	mockRepo.On("GetReply", "r1").Return(&existing, nil)
+
+	sev := NewReplyService(mockRepo, mockReviewRepo)
+	updated, err := sev.UpdateReply("book-1", toUpdate)
+
+	assert.Nil(t, updated, "should not update reply of another author")
+	assert.IsType(t, &bserror.ForbiddenError{}, err, "should get forbidden error")
+	mockRepo.AssertNotCalled(t, "UpdateReply", mock.Anything)
+}
+
+func TestCreateReplyOfReviewOfAnotherBook(t *testing.T) {
+	review := model.Review{ID: "review-1", BookID: "book-1"}
+	reply := model.Reply{ReviewID: "review-1", AuthorID: "staff-1", Description: "Thanks"}
+	mockReviewRepo := new(MockReviewRepository)
+	mockReviewRepo.On("GetReview", "review-1").Return(&review, nil)
+	mockRepo := new(MockReplyRepository)
+
+	sev := NewReplyService(mockRepo, mockReviewRepo)
+	created, err := sev.CreateReply("book-2", reply)
+
+	assert.Nil(t, created, "should not create reply")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "CreateReply", mock.Anything)
+}
+
+func TestAttachReplies(t *testing.T) {
+	reviews := []model.Review{{ID: "review-1"}, {ID: "review-2"}}
+	replies := []model.Reply{
+		{ID: "r1", ReviewID: "review-1"},
+		{ID: "r2", ReviewID: "review-1"},
+	}
+	mockRepo := new(MockReplyRepository)
+	mockRepo.On("GetReplyByReviews", []string{"review-1", "review-2"}).Return(replies, nil)
+
+	sev := NewReplyService(mockRepo, new(MockReviewRepository))
+	err := sev.AttachReplies(reviews)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(reviews[0].Replies), "first review should have 2 replies")
+	assert.Equal(t, 0, len(reviews[1].Replies), "second review should not have reply")
+	mockRepo.AssertExpectations(t)
+}
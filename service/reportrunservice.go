@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+type ReportRunService struct {
+	repo repository.ReportRunRepository
+	dir  string
+}
+
+// NewReportRunService create new report run service, dir is archive directory of
+// scheduled report outputs
+func NewReportRunService(runRepo repository.ReportRunRepository, dir string) *ReportRunService {
+	s := new(ReportRunService)
+	s.repo = runRepo
+	s.dir = dir
+	return s
+}
+
+func (s *ReportRunService) GetRun(id string) (*model.ReportRun, error) {
+	return s.repo.GetRun(id)
+}
+
+// GetRuns return latest runs of the job, or of all jobs when job is empty
+func (s *ReportRunService) GetRuns(job string, limit int) ([]model.ReportRun, error) {
+	return s.repo.GetRuns(job, limit)
+}
+
+// GetRunFile return path of archived output of a finished run, outputs are kept in
+// directory of their job. Older runs recorded the job directory with the file name
+func (s *ReportRunService) GetRunFile(id string) (string, error) {
+	run, err := s.repo.GetRun(id)
+	if err != nil {
+		return "", err
+	}
+	if run.Status != model.RunDone || run.File == "" {
+		return "", &bserror.NotFoundError{Msg: fmt.Sprintf("report run id %s has no output", id)}
+	}
+	return filepath.Join(s.dir, run.Job, filepath.Base(run.File)), nil
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+)
+
+// start mocking report run repository //
+type MockReportRunRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRunRepository) GetRun(id string) (*model.ReportRun, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportRunRepository) GetRuns(job string, limit int) ([]model.ReportRun, error) {
+	args := m.Called(job, limit)
+	return args.Get(0).([]model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportRunRepository) CreateRun(run model.ReportRun) (*model.ReportRun, error) {
+	args := m.Called(run)
+	return args.Get(0).(*model.ReportRun), args.Error(1)
+}
+
+func (m *MockReportRunRepository) UpdateRun(run model.ReportRun) error {
+	args := m.Called(run)
+	return args.Error(0)
+}
+
+// end mocking report run repository //
+
+func TestGetRunFile(t *testing.T) {
+	run := model.ReportRun{ID: "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", Job: "daily-bestsellers",
+		Status: model.RunDone, File: "daily-bestsellers-20200115T0100.csv"}
+	mockRepo := new(MockReportRunRepository)
+	mockRepo.On("GetRun", run.ID).Return(&run, nil)
+
+	sev := NewReportRunService(mockRepo, "/var/reports")
+	path, err := sev.GetRunFile(run.ID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, filepath.Join("/var/reports", "daily-bestsellers", run.File), path)
+}
+
+func TestGetRunFileArchivedWithJobDirectory(t *testing.T) {
+	run := model.ReportRun{ID: "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", Job: "daily-bestsellers",
+		Status: model.RunDone, File: filepath.Join("daily-bestsellers", "daily-bestsellers-20200115T0100.csv")}
+	mockRepo := new(MockReportRunRepository)
+	mockRepo.On("GetRun", run.ID).Return(&run, nil)
+
+	sev := NewReportRunService(mockRepo, "/var/reports")
+	path, err := sev.GetRunFile(run.ID)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, filepath.Join("/var/reports", run.File), path, "older archive must still be found")
+}
+
+func TestGetRunFileOfFailedRun(t *testing.T) {
+	run := model.ReportRun{ID: "5b0e3f9a-4a55-4b6a-9f0e-3c1c0e7b6f11", Status: model.RunFailed}
+	mockRepo := new(MockReportRunRepository)
+	mockRepo.On("GetRun", run.ID).Return(&run, nil)
+
+	sev := NewReportRunService(mockRepo, "/var/reports")
+	_, err := sev.GetRunFile(run.ID)
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "failed run has no output")
+}
@@ -0,0 +1,567 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// Revenue report groups
+const (
+	RevenueByBook      = "book"
+	RevenueByCategory  = "category"
+	RevenueByPublisher = "publisher"
+	RevenueByLanguage  = "language"
+)
+
+// Inventory valuation cost sources
+const (
+	CostSourceCost   = "cost"
+	CostSourceRetail = "retail" //paperback price as cost is not known
+	CostSourceNone   = "none"
+)
+
+// dateLayout is format of report dates
+const dateLayout = "2006-01-02"
+
+// Report time buckets
+const (
+	BucketDay   = "day"
+	BucketWeek  = "week"
+	BucketMonth = "month"
+)
+
+// Inventory aging defaults in days
+const (
+	DefaultAgingDays   = 90
+	DefaultAgingWindow = 365
+)
+
+// ABC classes by cumulative share of catalogue revenue, books are class A until
+// abcLimitA of revenue is reached, then class B until abcLimitB, the rest class C
+const (
+	abcLimitA = 0.8
+	abcLimitB = 0.95
+)
+
+// Demand forecast methods
+const (
+	ForecastAverage   = "average"   //moving average of history weeks
+	ForecastSmoothing = "smoothing" //simple exponential smoothing, recent weeks weigh more
+)
+
+// Reorder suggestion defaults, history and weeks in weeks, lead time in days
+const (
+	DefaultForecastHistory = 12
+	DefaultForecastWeeks   = 4
+	DefaultLeadTime        = 14
+)
+
+// Review analytics defaults
+const (
+	DefaultMinReviews   = 3
+	DefaultRatedLimit   = 10
+	DefaultReviewBucket = BucketMonth
+)
+
+// smoothingAlpha is weight of the latest week in exponential smoothing
+const smoothingAlpha = 0.3
+
+// smoothingSeedWeeks is number of first weeks which mean starts exponential smoothing
+const smoothingSeedWeeks = 4
+
+// restockStatuses are every status a restock can be in
+var restockStatuses = map[string]bool{
+	model.RestockDraft:     true,
+	model.RestockOrdered:   true,
+	model.RestockReceived:  true,
+	model.RestockCancelled: true,
+}
+
+// restockTransitions list statuses each restock status can move to
+var restockTransitions = map[string][]string{
+	model.RestockDraft:   {model.RestockOrdered, model.RestockCancelled},
+	model.RestockOrdered: {model.RestockReceived, model.RestockCancelled},
+}
+
+// pendingRestockStatuses are statuses of restocks not yet in stock
+var pendingRestockStatuses = []string{model.RestockDraft, model.RestockOrdered}
+
+type ReportService struct {
+	repo      repository.ReportRepository
+	stockRepo repository.StockRepository
+	now       func() time.Time
+}
+
+func NewReportService(reportRepo repository.ReportRepository, stockRepo repository.StockRepository) *ReportService {
+	s := new(ReportService)
+	s.repo = reportRepo
+	s.stockRepo = stockRepo
+	s.now = time.Now
+	return s
+}
+
+func (s *ReportService) GetBestSallBooks(q query.SaleReportQuery) ([]report.BestSallerBook, error) {
+	if err := validateSaleReportQuery(q); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBestSaller(q)
+}
+
+func (s *ReportService) GetBestSallCategory(q query.SaleReportQuery) ([]report.BestSallerCategory, error) {
+	if err := validateSaleReportQuery(q); err != nil {
+		return nil, err
+	}
+	return s.repo.GetBestSallerByCategory(q)
+}
+
+// GetRevenue return revenue grouped by book when group is not given
+func (s *ReportService) GetRevenue(q query.RevenueReportQuery) ([]report.Revenue, error) {
+	if err := validateSaleReportQuery(q.SaleReportQuery); err != nil {
+		return nil, err
+	}
+	if q.GroupBy == "" {
+		q.GroupBy = RevenueByBook
+	}
+	switch q.GroupBy {
+	case RevenueByBook, RevenueByCategory, RevenueByPublisher, RevenueByLanguage:
+	default:
+		return nil, &bserror.BadParameterError{Msg: "group must be book, category, publisher or language"}
+	}
+	switch q.Bucket {
+	case "", BucketDay, BucketWeek, BucketMonth:
+	default:
+		return nil, &bserror.BadParameterError{Msg: "bucket must be day, week or month"}
+	}
+	return s.repo.GetRevenue(q)
+}
+
+// GetInventoryValuation value stock on hand per book with category and publisher
+// subtotals, asOf nil means now otherwise stock at end of asOf date is replayed from
+// stock movements, books are always valued at their current cost
+func (s *ReportService) GetInventoryValuation(asOf *time.Time) (*report.InventoryValuation, error) {
+	var before *time.Time
+	rpt := report.InventoryValuation{}
+	if asOf != nil {
+		t := asOf.AddDate(0, 0, 1)
+		before = &t
+		rpt.AsOf = asOf.Format(dateLayout)
+	}
+	books, err := s.repo.GetInventory(before)
+	if err != nil {
+		return nil, err
+	}
+	for i := range books {
+		b := &books[i]
+		switch {
+		case b.CostPrice != nil:
+			b.UnitCost, b.CostSource = *b.CostPrice, CostSourceCost
+		case b.RetailPrice != nil:
+			b.UnitCost, b.CostSource = *b.RetailPrice, CostSourceRetail
+		default:
+			b.CostSource = CostSourceNone
+		}
+		b.Value = float64(b.Amount) * b.UnitCost
+		rpt.TotalAmount += b.Amount
+		rpt.TotalValue += b.Value
+	}
+	rpt.Books = books
+	rpt.Categories = inventorySubtotals(books, func(b report.InventoryBook) string { return b.Category })
+	rpt.Publishers = inventorySubtotals(books, func(b report.InventoryBook) string { return b.Publisher })
+	return &rpt, nil
+}
+
+// GetInventoryAging list dead stock, books with stock and no paperback sale within
+// days, and classify the catalogue by revenue within window, days of cover is
+// current amount over paperback units sold per day within window, or since created
+// for books newer than window
+func (s *ReportService) GetInventoryAging(q query.InventoryAgingQuery) (*report.InventoryAging, error) {
+	if q.Days == 0 {
+		q.Days = DefaultAgingDays
+	}
+	if q.Window == 0 {
+		q.Window = DefaultAgingWindow
+	}
+	if q.Days < 0 || q.Window < 0 {
+		return nil, &bserror.BadParameterError{Msg: "days and window must be positive"}
+	}
+	now := s.now()
+	books, err := s.repo.GetStockAging(now.AddDate(0, 0, -q.Window))
+	if err != nil {
+		return nil, err
+	}
+	total := 0.0
+	for _, b := range books {
+		total += b.WindowRevenue
+	}
+	for i := range books {
+		b := &books[i]
+		last := b.LastSaleTime
+		if last == nil {
+			last = b.CreatedTime
+		}
+		if last != nil {
+			b.IdleDays = int(now.Sub(*last).Hours() / 24)
+		}
+		b.AverageDailySales = float64(b.WindowSoldAmount) / sellingDays(q.Window, b.CreatedTime, now)
+		if b.AverageDailySales > 0 {
+			cover := float64(b.CurrentAmount) / b.AverageDailySales
+			b.DaysOfCover = &cover
+		}
+		if total > 0 {
+			b.Contribution = b.WindowRevenue / total
+		}
+		b.Dead = b.CurrentAmount > 0 && b.IdleDays >= q.Days
+	}
+	sort.SliceStable(books, func(i, j int) bool {
+		if books[i].WindowRevenue != books[j].WindowRevenue {
+			return books[i].WindowRevenue > books[j].WindowRevenue
+		}
+		return books[i].Title < books[j].Title
+	})
+	cumulative := 0.0
+	for i := range books {
+		books[i].Class = abcClass(books[i].WindowRevenue, cumulative)
+		cumulative += books[i].Contribution
+	}
+
+	dead := []report.StockAging{}
+	for _, b := range books {
+		if b.Dead {
+			dead = append(dead, b)
+		}
+	}
+	sort.SliceStable(dead, func(i, j int) bool { return dead[i].IdleDays > dead[j].IdleDays })
+	return &report.InventoryAging{Days: q.Days, Window: q.Window, DeadStock: dead, Books: books}, nil
+}
+
+// sellingDays return days the book could sell within window, at least one day
+func sellingDays(window int, created *time.Time, now time.Time) float64 {
+	days := float64(window)
+	if created != nil {
+		if age := math.Ceil(now.Sub(*created).Hours() / 24); age < days {
+			days = math.Max(age, 1)
+		}
+	}
+	return days
+}
+
+// GetReorderSuggestions forecast weekly paperback demand of each book from complete
+// history weeks and suggest quantity to order so stock, including draft and ordered
+// restocks, covers demand during lead time and the following weeks
+func (s *ReportService) GetReorderSuggestions(q query.ReorderQuery) (*report.ReorderSuggestions, error) {
+	if q.Method == "" {
+		q.Method = ForecastSmoothing
+	}
+	if q.History == 0 {
+		q.History = DefaultForecastHistory
+	}
+	if q.Weeks == 0 {
+		q.Weeks = DefaultForecastWeeks
+	}
+	if q.Method != ForecastAverage && q.Method != ForecastSmoothing {
+		return nil, &bserror.BadParameterError{Msg: "method must be average or smoothing"}
+	}
+	if q.History < 0 || q.Weeks < 0 || q.LeadTime < 0 {
+		return nil, &bserror.BadParameterError{Msg: "history, weeks and lead time must be positive"}
+	}
+	to := startOfWeek(s.now())
+	from := to.AddDate(0, 0, -7*q.History)
+	sales, err := s.repo.GetWeeklySales(from, to)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := s.pendingRestock()
+	if err != nil {
+		return nil, err
+	}
+
+	rpt := report.ReorderSuggestions{Method: q.Method, History: q.History, Weeks: q.Weeks, LeadTime: q.LeadTime}
+	rpt.Suggestions = []report.ReorderSuggestion{}
+	for len(sales) > 0 {
+		n := 1
+		for n < len(sales) && sales[n].BookID == sales[0].BookID {
+			n++
+		}
+		weekly := map[string]int{}
+		for _, e := range sales[:n] {
+			if e.Week != nil {
+				weekly[*e.Week] = e.Amount
+			}
+		}
+		series := []float64{}
+		for w := from; w.Before(to); w = w.AddDate(0, 0, 7) {
+			series = append(series, float64(weekly[w.Format(dateLayout)]))
+		}
+		sg := report.ReorderSuggestion{
+			BookID:         sales[0].BookID,
+			Title:          sales[0].Title,
+			CurrentAmount:  sales[0].CurrentAmount,
+			PendingRestock: pending[sales[0].BookID],
+			WeeklyForecast: forecast(series, q.Method),
+		}
+		sg.LeadTimeDemand = sg.WeeklyForecast * float64(q.LeadTime) / 7
+		sg.CoverDemand = sg.WeeklyForecast * float64(q.Weeks)
+		need := int(math.Ceil(sg.LeadTimeDemand + sg.CoverDemand - 1e-9))
+		sg.SuggestedAmount = need - sg.CurrentAmount - sg.PendingRestock
+		if sg.SuggestedAmount > 0 {
+			rpt.Suggestions = append(rpt.Suggestions, sg)
+		}
+		sales = sales[n:]
+	}
+	sort.SliceStable(rpt.Suggestions, func(i, j int) bool {
+		if rpt.Suggestions[i].SuggestedAmount != rpt.Suggestions[j].SuggestedAmount {
+			return rpt.Suggestions[i].SuggestedAmount > rpt.Suggestions[j].SuggestedAmount
+		}
+		return rpt.Suggestions[i].Title < rpt.Suggestions[j].Title
+	})
+	return &rpt, nil
+}
+
+// CreateDraftRestock turn reorder suggestion of the book into draft restock, quantity
+// 0 takes suggested amount
+func (s *ReportService) CreateDraftRestock(q query.ReorderQuery, bookID string, quantity int) (*model.Restock, error) {
+	if quantity < 0 {
+		return nil, &bserror.BadParameterError{Msg: "quantity must not be negative"}
+	}
+	if quantity == 0 {
+		rpt, err := s.GetReorderSuggestions(q)
+		if err != nil {
+			return nil, err
+		}
+		for _, sg := range rpt.Suggestions {
+			if sg.BookID == bookID {
+				quantity = sg.SuggestedAmount
+			}
+		}
+		if quantity == 0 {
+			return nil, &bserror.BadParameterError{Msg: fmt.Sprintf("book id %s needs no restock", bookID)}
+		}
+	}
+	rs := model.Restock{BookID: bookID, Quantity: quantity, Status: model.RestockDraft}
+	created, err := s.stockRepo.CreateRestock(rs)
+	if err != nil {
+		log.Error(fmt.Sprintf("create restock of book id %s error, %s", bookID, err.Error()))
+		return nil, err
+	}
+	return created, nil
+}
+
+// GetRestocks return restocks in given status, draft when not given
+func (s *ReportService) GetRestocks(status string) ([]model.Restock, error) {
+	if status == "" {
+		status = model.RestockDraft
+	}
+	if !restockStatuses[status] {
+		return nil, &bserror.BadParameterError{Msg: "status must be draft, ordered, received or cancelled"}
+	}
+	return s.stockRepo.GetRestocks(status)
+}
+
+// UpdateRestockStatus move restock from draft to ordered and from ordered to received,
+// either can be cancelled. Received restock adds its quantity to stock of the book
+func (s *ReportService) UpdateRestockStatus(id string, status string, version int) (*model.Restock, error) {
+	rs, err := s.stockRepo.GetRestock(id)
+	if err != nil {
+		return nil, err
+	}
+	allowed := false
+	for _, next := range restockTransitions[rs.Status] {
+		allowed = allowed || next == status
+	}
+	if !allowed {
+		msg := fmt.Sprintf("restock id %s can not move from %s to %s", id, rs.Status, status)
+		return nil, &bserror.BadParameterError{Msg: msg}
+	}
+	rs.Status = status
+	rs.Version = version
+	updated, err := s.stockRepo.UpdateRestockStatus(*rs)
+	if err != nil {
+		log.Error(fmt.Sprintf("update restock id %s error, %s", id, err.Error()))
+		return nil, err
+	}
+	return updated, nil
+}
+
+// pendingRestock sum quantity of draft and ordered restocks by book id
+func (s *ReportService) pendingRestock() (map[string]int, error) {
+	pending := map[string]int{}
+	for _, status := range pendingRestockStatuses {
+		restocks, err := s.stockRepo.GetRestocks(status)
+		if err != nil {
+			return nil, err
+		}
+		for _, rs := range restocks {
+			pending[rs.BookID] += rs.Quantity
+		}
+	}
+	return pending, nil
+}
+
+// forecast return expected units of next week from weekly series, oldest first.
+// Smoothing starts from mean of the first weeks so one unusual first week does not
+// carry through the whole series
+func forecast(series []float64, method string) float64 {
+	if len(series) == 0 {
+		return 0
+	}
+	if method == ForecastAverage {
+		return mean(series)
+	}
+	seed := smoothingSeedWeeks
+	if seed > len(series) {
+		seed = len(series)
+	}
+	level := mean(series[:seed])
+	for _, v := range series[seed:] {
+		level = smoothingAlpha*v + (1-smoothingAlpha)*level
+	}
+	return level
+}
+
+func mean(series []float64) float64 {
+	sum := 0.0
+	for _, v := range series {
+		sum += v
+	}
+	return sum / float64(len(series))
+}
+
+// startOfWeek return Monday midnight of the week of t
+func startOfWeek(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// GetReviewAnalytics return top and worst rated books among books with at least
+// minimum reviews, average score per category and publisher, review volume per
+// bucket and books without reviews, counting reviews created within query range
+func (s *ReportService) GetReviewAnalytics(q query.ReviewReportQuery) (*report.ReviewAnalytics, error) {
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return nil, &bserror.BadParameterError{Msg: "from must be before to"}
+	}
+	if q.Bucket == "" {
+		q.Bucket = DefaultReviewBucket
+	}
+	if q.Bucket != BucketDay && q.Bucket != BucketWeek && q.Bucket != BucketMonth {
+		return nil, &bserror.BadParameterError{Msg: "bucket must be day, week or month"}
+	}
+	if q.MinReviews < 0 || q.Limit < 0 {
+		return nil, &bserror.BadParameterError{Msg: "min reviews and limit must not be negative"}
+	}
+	books, err := s.repo.GetBookReviewStats(q)
+	if err != nil {
+		return nil, err
+	}
+	volume, err := s.repo.GetReviewVolume(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rpt := report.ReviewAnalytics{Volume: volume, Unreviewed: []report.BookScore{}}
+	rated := []report.BookScore{}
+	for _, b := range books {
+		if b.ReviewCount == 0 {
+			rpt.Unreviewed = append(rpt.Unreviewed, b)
+		} else if b.ReviewCount >= q.MinReviews {
+			rated = append(rated, b)
+		}
+	}
+	rpt.TopRated = rankByScore(rated, q.Limit, func(a, b float64) bool { return a > b })
+	rpt.WorstRated = rankByScore(rated, q.Limit, func(a, b float64) bool { return a < b })
+	rpt.Categories = scoreSubtotals(books, func(b report.BookScore) string { return b.Category })
+	rpt.Publishers = scoreSubtotals(books, func(b report.BookScore) string { return b.Publisher })
+	return &rpt, nil
+}
+
+// rankByScore return copy of books ordered by average score, more reviews first on
+// the same score, cut to limit when limit is set
+func rankByScore(books []report.BookScore, limit int, better func(a, b float64) bool) []report.BookScore {
+	ranked := append([]report.BookScore{}, books...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].AverageScore != ranked[j].AverageScore {
+			return better(ranked[i].AverageScore, ranked[j].AverageScore)
+		}
+		return ranked[i].ReviewCount > ranked[j].ReviewCount
+	})
+	if limit > 0 && len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// scoreSubtotals average score of all reviews of reviewed books by key, ordered by key
+func scoreSubtotals(books []report.BookScore, key func(report.BookScore) string) []report.ScoreSubtotal {
+	sums := map[string]float64{}
+	counts := map[string]int{}
+	names := []string{}
+	for _, b := range books {
+		if b.ReviewCount == 0 {
+			continue
+		}
+		if _, ok := counts[key(b)]; !ok {
+			names = append(names, key(b))
+		}
+		sums[key(b)] += b.AverageScore * float64(b.ReviewCount)
+		counts[key(b)] += b.ReviewCount
+	}
+	sort.Strings(names)
+	subs := []report.ScoreSubtotal{}
+	for _, n := range names {
+		subs = append(subs, report.ScoreSubtotal{Name: n, ReviewCount: counts[n], AverageScore: sums[n] / float64(counts[n])})
+	}
+	return subs
+}
+
+// abcClass classify book by share of revenue of better selling books, so the
+// book crossing a limit still belongs to the higher class
+func abcClass(revenue float64, before float64) string {
+	switch {
+	case revenue > 0 && before < abcLimitA:
+		return "A"
+	case revenue > 0 && before < abcLimitB:
+		return "B"
+	default:
+		return "C"
+	}
+}
+
+// inventorySubtotals sum valued books by key, ordered by key
+func inventorySubtotals(books []report.InventoryBook, key func(report.InventoryBook) string) []report.InventorySubtotal {
+	byKey := map[string]*report.InventorySubtotal{}
+	names := []string{}
+	for _, b := range books {
+		sub, ok := byKey[key(b)]
+		if !ok {
+			sub = &report.InventorySubtotal{Name: key(b)}
+			byKey[sub.Name] = sub
+			names = append(names, sub.Name)
+		}
+		sub.Amount += b.Amount
+		sub.Value += b.Value
+	}
+	sort.Strings(names)
+	subs := []report.InventorySubtotal{}
+	for _, n := range names {
+		subs = append(subs, *byKey[n])
+	}
+	return subs
+}
+
+func validateSaleReportQuery(q query.SaleReportQuery) error {
+	if q.From != nil && q.To != nil && !q.From.Before(*q.To) {
+		return &bserror.BadParameterError{Msg: "from must be before to"}
+	}
+	if q.Limit < 0 {
+		return &bserror.BadParameterError{Msg: "limit must not be negative"}
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
+)
+
+// start mocking report repository //
+type MockReportRepository struct {
+	mock.Mock
+}
+
+func (m *MockReportRepository) GetBestSaller(q query.SaleReportQuery) ([]report.BestSallerBook, error) {
+	args := m.Called(q)
+	return args.Get(0).([]report.BestSallerBook), args.Error(1)
+}
+
+func (m *MockReportRepository) GetBestSallerByCategory(q query.SaleReportQuery) ([]report.BestSallerCategory, error) {
+	args := m.Called(q)
+	return args.Get(0).([]report.BestSallerCategory), args.Error(1)
+}
+
+func (m *MockReportRepository) GetRevenue(q query.RevenueReportQuery) ([]report.Revenue, error) {
+	args := m.Called(q)
+	return args.Get(0).([]report.Revenue), args.Error(1)
+}
+
+func (m *MockReportRepository) GetInventory(asOf *time.Time) ([]report.InventoryBook, error) {
+	args := m.Called(asOf)
+	return args.Get(0).([]report.InventoryBook), args.Error(1)
+}
+
+func (m *MockReportRepository) GetStockAging(since time.Time) ([]report.StockAging, error) {
+	args := m.Called(since)
+	return args.Get(0).([]report.StockAging), args.Error(1)
+}
+
+func (m *MockReportRepository) GetWeeklySales(from time.Time, to time.Time) ([]report.WeeklySale, error) {
+	args := m.Called(from, to)
+	return args.Get(0).([]report.WeeklySale), args.Error(1)
+}
+
+func (m *MockReportRepository) GetBookReviewStats(q query.ReviewReportQuery) ([]report.BookScore, error) {
+	args := m.Called(q)
+	return args.Get(0).([]report.BookScore), args.Error(1)
+}
+
+func (m *MockReportRepository) GetReviewVolume(q query.ReviewReportQuery) ([]report.ReviewVolume, error) {
+	args := m.Called(q)
+	return args.Get(0).([]report.ReviewVolume), args.Error(1)
+}
+
+// end mocking report repository //
+
+func TestGetBestSallBooks(t *testing.T) {
+	rpt := []report.BestSallerBook{
+		{Ttile: "Go is good", TotalSaleAmount: 5000},
+		{Ttile: "NodeJS is the best", TotalSaleAmount: 50},
+	}
+	q := query.SaleReportQuery{Limit: 2}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetBestSaller", q).Return(rpt, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	bsrpt, err := sev.GetBestSallBooks(q)
+	assert.Nil(t, err, "Should not get any error")
+	assert.Equal(t, 2, len(bsrpt), "report contain 2 entry")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBestSallCategory(t *testing.T) {
+	rpt := []report.BestSallerCategory{
+		{Category: "Programming", TotalSaleAmount: 5000},
+		{Category: "How to get rich", TotalSaleAmount: 50},
+	}
+	q := query.SaleReportQuery{Publisher: "O'Reilly"}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetBestSallerByCategory", q).Return(rpt, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	bsrpt, err := sev.GetBestSallCategory(q)
+	assert.Nil(t, err, "Should not get any error")
+	assert.Equal(t, 2, len(bsrpt), "report contain 2 entry")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetBestSallBooksWithInvalidRange(t *testing.T) {
+	from := time.Date(2019, 12, 8, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2019, 12, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo := new(MockReportRepository)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	bsrpt, err := sev.GetBestSallBooks(query.SaleReportQuery{From: &from, To: &to})
+
+	assert.Nil(t, bsrpt, "should not get report")
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "GetBestSaller", mock.Anything)
+}
+
+func TestGetRevenueGroupByBookByDefault(t *testing.T) {
+	rpt := []report.Revenue{{Group: "a432eee1-be54-44e6-a5ef-8a0455306f4f", GroupName: "Go is good", TotalRevenue: 100}}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetRevenue", query.RevenueReportQuery{GroupBy: RevenueByBook, Bucket: BucketMonth}).Return(rpt, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	revenue, err := sev.GetRevenue(query.RevenueReportQuery{Bucket: BucketMonth})
+
+	assert.Nil(t, err, "Should not get any error")
+	assert.Equal(t, 1, len(revenue), "report contain 1 entry")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetRevenueWithUnknownGroup(t *testing.T) {
+	mockRepo := new(MockReportRepository)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	revenue, err := sev.GetRevenue(query.RevenueReportQuery{GroupBy: "isbn"})
+
+	assert.Nil(t, revenue, "should not get report")
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "GetRevenue", mock.Anything)
+}
+
+func TestGetInventoryValuation(t *testing.T) {
+	cost := 300.0
+	retail := 500.0
+	books := []report.InventoryBook{
+		{BookID: "b1", Category: "Programming", Publisher: "O'Reilly", Amount: 2, CostPrice: &cost, RetailPrice: &retail},
+		{BookID: "b2", Category: "Programming", Publisher: "Manning", Amount: 3, RetailPrice: &retail},
+		{BookID: "b3", Category: "Cooking", Publisher: "Manning", Amount: 4},
+	}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetInventory", (*time.Time)(nil)).Return(books, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	rpt, err := sev.GetInventoryValuation(nil)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, CostSourceCost, rpt.Books[0].CostSource, "cost price must be used when known")
+	assert.Equal(t, 1500.0, rpt.Books[1].Value, "retail price must be used when cost is unknown")
+	assert.Equal(t, CostSourceNone, rpt.Books[2].CostSource, "book without any price has no value")
+	assert.Equal(t, []report.InventorySubtotal{
+		{Name: "Cooking", Amount: 4, Value: 0},
+		{Name: "Programming", Amount: 5, Value: 2100},
+	}, rpt.Categories)
+	assert.Equal(t, []report.InventorySubtotal{
+		{Name: "Manning", Amount: 7, Value: 1500},
+		{Name: "O'Reilly", Amount: 2, Value: 600},
+	}, rpt.Publishers)
+	assert.Equal(t, 9, rpt.TotalAmount)
+	assert.Equal(t, 2100.0, rpt.TotalValue)
+}
+
+func TestGetInventoryValuationAsOf(t *testing.T) {
+	asOf := time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC)
+	before := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetInventory", &before).Return([]report.InventoryBook{}, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	rpt, err := sev.GetInventoryValuation(&asOf)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "2019-12-31", rpt.AsOf)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetInventoryAging(t *testing.T) {
+	now := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	longAgo := now.AddDate(0, 0, -120)
+	lastWeek := now.AddDate(0, 0, -7)
+	lastYear := now.AddDate(-1, 0, -1)
+	books := []report.StockAging{
+		{BookID: "dead", Title: "Dead", CurrentAmount: 5, LastSaleTime: &longAgo, CreatedTime: &longAgo},
+		{BookID: "top", Title: "Top", CurrentAmount: 100, LastSaleTime: &lastWeek, CreatedTime: &lastYear,
+			WindowSoldAmount: 365, WindowRevenue: 9000},
+		{BookID: "mid", Title: "Mid", CurrentAmount: 0, LastSaleTime: &lastWeek, CreatedTime: &longAgo,
+			WindowSoldAmount: 10, WindowRevenue: 900},
+		{BookID: "tail", Title: "Tail", CurrentAmount: 3, LastSaleTime: &lastWeek, CreatedTime: &longAgo,
+			WindowSoldAmount: 1, WindowRevenue: 100},
+	}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetStockAging", now.AddDate(0, 0, -365)).Return(books, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	sev.now = func() time.Time { return now }
+	rpt, err := sev.GetInventoryAging(query.InventoryAgingQuery{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 90, rpt.Days, "days must default to 90")
+	assert.Equal(t, 1, len(rpt.DeadStock), "only book with stock and no recent sale is dead")
+	assert.Equal(t, "dead", rpt.DeadStock[0].BookID)
+	assert.Equal(t, 120, rpt.DeadStock[0].IdleDays)
+	assert.Nil(t, rpt.DeadStock[0].DaysOfCover, "no days of cover without sales")
+	assert.Equal(t, "top", rpt.Books[0].BookID, "books are ordered by revenue")
+	assert.Equal(t, 100.0, *rpt.Books[0].DaysOfCover, "100 in stock selling 1 a day")
+	classes := []string{}
+	for _, b := range rpt.Books {
+		classes = append(classes, b.Class)
+	}
+	assert.Equal(t, []string{"A", "B", "C", "C"}, classes)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetInventoryAgingOfNewBook(t *testing.T) {
+	now := time.Date(2020, 1, 31, 0, 0, 0, 0, time.UTC)
+	created := now.AddDate(0, 0, -10)
+	books := []report.StockAging{
+		{BookID: "new", Title: "New", CurrentAmount: 40, LastSaleTime: &now, CreatedTime: &created, WindowSoldAmount: 20},
+	}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetStockAging", now.AddDate(0, 0, -365)).Return(books, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	sev.now = func() time.Time { return now }
+	rpt, err := sev.GetInventoryAging(query.InventoryAgingQuery{})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2.0, rpt.Books[0].AverageDailySales, "20 sold in 10 days since created")
+	assert.Equal(t, 20.0, *rpt.Books[0].DaysOfCover, "40 in stock selling 2 a day")
+}
+
+func TestGetInventoryAgingWithNegativeDays(t *testing.T) {
+	sev := NewReportService(new(MockReportRepository), new(MockStockRepository))
+	_, err := sev.GetInventoryAging(query.InventoryAgingQuery{Days: -1})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+}
+
+func TestGetReorderSuggestions(t *testing.T) {
+	now := time.Date(2020, 1, 30, 15, 0, 0, 0, time.UTC) // Thursday
+	monday := time.Date(2020, 1, 27, 0, 0, 0, 0, time.UTC)
+	w1, w2 := "2020-01-13", "2020-01-20"
+	sales := []report.WeeklySale{
+		{BookID: "fast", Title: "Fast", CurrentAmount: 5, Week: &w1, Amount: 10},
+		{BookID: "fast", Title: "Fast", CurrentAmount: 5, Week: &w2, Amount: 10},
+		{BookID: "never", Title: "Never", CurrentAmount: 0},
+		{BookID: "stocked", Title: "Stocked", CurrentAmount: 500, Week: &w2, Amount: 20},
+	}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetWeeklySales", monday.AddDate(0, 0, -14), monday).Return(sales, nil)
+	mockStockRepo := new(MockStockRepository)
+	mockStockRepo.On("GetRestocks", model.RestockDraft).Return([]model.Restock{{BookID: "fast", Quantity: 15}}, nil)
+	mockStockRepo.On("GetRestocks", model.RestockOrdered).Return([]model.Restock{{BookID: "fast", Quantity: 5}}, nil)
+
+	sev := NewReportService(mockRepo, mockStockRepo)
+	sev.now = func() time.Time { return now }
+	rpt, err := sev.GetReorderSuggestions(query.ReorderQuery{Method: ForecastAverage, History: 2, Weeks: 4, LeadTime: 7})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(rpt.Suggestions), "only fast book needs restock")
+	sg := rpt.Suggestions[0]
+	assert.Equal(t, 10.0, sg.WeeklyForecast, "average of 2 weeks with 10 each")
+	assert.Equal(t, 25, sg.SuggestedAmount, "50 needed for 5 weeks less 5 in stock, 15 in draft and 5 ordered")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestForecastSmoothing(t *testing.T) {
+	assert.InDelta(t, 5.8, forecast([]float64{4, 4, 4, 4, 10}, ForecastSmoothing), 1e-9, "latest week weighs alpha")
+	assert.InDelta(t, 5.5, forecast([]float64{20, 0, 0, 2}, ForecastSmoothing), 1e-9, "first weeks seed with their mean")
+	assert.Equal(t, 0.0, forecast([]float64{}, ForecastSmoothing), "no history forecasts nothing")
+}
+
+func TestCreateDraftRestockWithSuggestedAmount(t *testing.T) {
+	now := time.Date(2020, 1, 30, 15, 0, 0, 0, time.UTC)
+	week := "2020-01-20"
+	sales := []report.WeeklySale{{BookID: "fast", Title: "Fast", CurrentAmount: 0, Week: &week, Amount: 7}}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetWeeklySales", mock.Anything, mock.Anything).Return(sales, nil)
+	draft := model.Restock{BookID: "fast", Quantity: 7, Status: model.RestockDraft}
+	mockStockRepo := new(MockStockRepository)
+	mockStockRepo.On("GetRestocks", model.RestockDraft).Return([]model.Restock{}, nil)
+	mockStockRepo.On("GetRestocks", model.RestockOrdered).Return([]model.Restock{}, nil)
+	mockStockRepo.On("CreateRestock", draft).Return(&draft, nil)
+
+	sev := NewReportService(mockRepo, mockStockRepo)
+	sev.now = func() time.Time { return now }
+	_, err := sev.CreateDraftRestock(query.ReorderQuery{Method: ForecastAverage, History: 1, Weeks: 1}, "fast", 0)
+
+	assert.Nil(t, err, "should not get any error")
+	mockStockRepo.AssertExpectations(t)
+}
+
+func TestUpdateRestockStatus(t *testing.T) {
+	draft := model.Restock{ID: "r1", BookID: "fast", Quantity: 7, Status: model.RestockDraft, Version: 1}
+	ordered := draft
+	ordered.Status = model.RestockOrdered
+	mockStockRepo := new(MockStockRepository)
+	mockStockRepo.On("GetRestock", "r1").Return(&draft, nil)
+	mockStockRepo.On("UpdateRestockStatus", ordered).Return(&ordered, nil)
+
+	sev := NewReportService(new(MockReportRepository), mockStockRepo)
+	updated, err := sev.UpdateRestockStatus("r1", model.RestockOrdered, 1)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, model.RestockOrdered, updated.Status)
+	mockStockRepo.AssertExpectations(t)
+}
+
+func TestReceiveDraftRestock(t *testing.T) {
+	draft := model.Restock{ID: "r1", BookID: "fast", Quantity: 7, Status: model.RestockDraft, Version: 1}
+	mockStockRepo := new(MockStockRepository)
+	mockStockRepo.On("GetRestock", "r1").Return(&draft, nil)
+
+	sev := NewReportService(new(MockReportRepository), mockStockRepo)
+	_, err := sev.UpdateRestockStatus("r1", model.RestockReceived, 1)
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "draft must be ordered before received")
+	mockStockRepo.AssertNotCalled(t, "UpdateRestockStatus", mock.Anything)
+}
+
+func TestGetReviewAnalytics(t *testing.T) {
+	books := []report.BookScore{
+		{BookID: "good", Title: "Good", Category: "Programming", Publisher: "Manning", ReviewCount: 4, AverageScore: 4.5},
+		{BookID: "bad", Title: "Bad", Category: "Programming", Publisher: "Manning", ReviewCount: 4, AverageScore: 2},
+		{BookID: "few", Title: "Few", Category: "Cooking", Publisher: "Penguin", ReviewCount: 1, AverageScore: 5},
+		{BookID: "none", Title: "None", Category: "Cooking", Publisher: "Penguin"},
+	}
+	q := query.ReviewReportQuery{MinReviews: 3, Limit: 1, Bucket: BucketMonth}
+	mockRepo := new(MockReportRepository)
+	mockRepo.On("GetBookReviewStats", q).Return(books, nil)
+	mockRepo.On("GetReviewVolume", q).Return([]report.ReviewVolume{{Period: "2020-01-01", ReviewCount: 9}}, nil)
+
+	sev := NewReportService(mockRepo, new(MockStockRepository))
+	rpt, err := sev.GetReviewAnalytics(query.ReviewReportQuery{MinReviews: 3, Limit: 1})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "good", rpt.TopRated[0].BookID, "book with too few reviews must not be top rated")
+	assert.Equal(t, 1, len(rpt.TopRated), "top rated must be cut to limit")
+	assert.Equal(t, "bad", rpt.WorstRated[0].BookID)
+	assert.Equal(t, []report.ScoreSubtotal{
+		{Name: "Cooking", ReviewCount: 1, AverageScore: 5},
+		{Name: "Programming", ReviewCount: 8, AverageScore: 3.25},
+	}, rpt.Categories)
+	assert.Equal(t, "none", rpt.Unreviewed[0].BookID)
+	assert.Equal(t, 1, len(rpt.Volume))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetReviewAnalyticsWithUnknownBucket(t *testing.T) {
+	sev := NewReportService(new(MockReportRepository), new(MockStockRepository))
+	_, err := sev.GetReviewAnalytics(query.ReviewReportQuery{Bucket: "year"})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+}
@@ -1,69 +1,292 @@
 package service
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
 	"github.com/tsongpon/backend-challenge-2019/repository"
+	"github.com/tsongpon/backend-challenge-2019/screening"
+	"github.com/tsongpon/backend-challenge-2019/sentiment"
 )
 
+// DefaultMismatchThreshold flag reviews like 5 stars with neutral or negative text
+const DefaultMismatchThreshold = 1.0
+
+// SortByHelpfulness order reviews by helpfulness score, most helpful first
+const SortByHelpfulness = "helpfulness"
+
+// helpfulnessZ is z score of 95% confidence used for helpfulness score
+const helpfulnessZ = 1.96
+
 type ReviewService struct {
-	repo repository.ReviewRepository
+	repo     repository.ReviewRepository
+	saleRepo repository.SaleRepository
+	screener *screening.Pipeline
 }
 
-func NewReviewService(reviewRepo repository.ReviewRepository) *ReviewService {
+func NewReviewService(reviewRepo repository.ReviewRepository, saleRepo repository.SaleRepository,
+	screener *screening.Pipeline) *ReviewService {
 	s := new(ReviewService)
 	s.repo = reviewRepo
+	s.saleRepo = saleRepo
+	s.screener = screener
 	return s
 }
 
-func (s *ReviewService) GetReview(id string) (*model.Review, error) {
-	review, err := s.repo.GetReview(id)
+// GetReview return review only when it belongs to given book
+func (s *ReviewService) GetReview(bookID string, id string) (*model.Review, error) {
+	review, err := reviewOfBook(s.repo, bookID, id)
+	if err != nil {
+		return nil, err
+	}
+	verdicts, err := s.repo.GetVerdicts(id)
 	if err != nil {
 		return nil, err
 	}
+	review.Verdicts = verdicts
+	review.HelpfulnessScore = helpfulnessScore(review.HelpfulCount, review.UnhelpfulCount)
 	return review, nil
 }
 
 func (s *ReviewService) CreateRevirw(r model.Review) (*model.Review, error) {
+	if err := s.screen(&r); err != nil {
+		return nil, err
+	}
+	r.Sentiment = scoreSentiment(r.Description)
+	verified, err := s.saleRepo.HasPurchased(r.ReviewerID, r.BookID)
+	if err != nil {
+		return nil, err
+	}
+	r.VerifiedPurchase = verified
 	created, err := s.repo.CreateReview(r)
 	if err != nil {
 		log.Info("creave review error")
 		return nil, err
 	}
-	fromDB, err := s.repo.GetReview(created.ID)
+	return s.GetReview(created.BookID, created.ID)
+}
+
+func (s *ReviewService) GetBookReviews(q query.ReviewQuery) ([]model.Review, error) {
+	reviews, err := s.repo.GetReviewByBook(q)
 	if err != nil {
+		log.Error("get reviews error, bookID", q.BookID)
 		return nil, err
 	}
-	return fromDB, nil
+	scoreHelpfulness(reviews)
+	if q.SortBy == SortByHelpfulness {
+		sort.SliceStable(reviews, func(i, j int) bool {
+			return reviews[i].HelpfulnessScore > reviews[j].HelpfulnessScore
+		})
+	}
+	return reviews, nil
+}
+
+// GetReviewFeed return one page of reviews across all books and cursor of
+// next page, cursor is empty on the last page
+func (s *ReviewService) GetReviewFeed(q query.ReviewFeedQuery) ([]model.Review, string, error) {
+	if q.Cursor != "" {
+		after, id, err := decodeFeedCursor(q.Cursor)
+		if err != nil {
+			return nil, "", &bserror.BadParameterError{Msg: "invalid cursor"}
+		}
+		q.AfterTime = &after
+		q.AfterID = id
+	}
+	limit := q.Limit
+	q.Limit = limit + 1
+	reviews, err := s.repo.GetReviewFeed(q)
+	if err != nil {
+		log.Error("get review feed error", err.Error())
+		return nil, "", err
+	}
+	next := ""
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		last := reviews[limit-1]
+		next = encodeFeedCursor(*last.CreatedTime, last.ID)
+	}
+	scoreHelpfulness(reviews)
+	return reviews, next, nil
 }
 
-func (s *ReviewService) GetBookReviews(bookID string) ([]model.Review, error) {
-	reviews, err := s.repo.GetReviewByBook(bookID)
+func (s *ReviewService) GetReviewerReviews(reviewerID string) ([]model.Review, error) {
+	reviews, err := s.repo.GetReviewByReviewer(reviewerID)
 	if err != nil {
-		log.Error("get reviews error, bookID", bookID)
+		log.Error("get reviews error, reviewerID", reviewerID)
 		return nil, err
 	}
+	scoreHelpfulness(reviews)
 	return reviews, nil
 }
 
-func (s *ReviewService) UpdateReview(r model.Review) (*model.Review, error) {
-	_, err := s.repo.GetReview(r.ID)
+// UpdateReview update review by its reviewer, staff given by staffID can update
+// review without reviewer
+func (s *ReviewService) UpdateReview(r model.Review, staffID string) (*model.Review, error) {
+	existing, err := reviewOfBook(s.repo, r.BookID, r.ID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkReviewOwner(*existing, r.ReviewerID, staffID); err != nil {
+		return nil, err
+	}
+	if err := s.screen(&r); err != nil {
+		return nil, err
+	}
+	r.Sentiment = scoreSentiment(r.Description)
 	updated, err := s.repo.UpdateReview(r)
 	if err != nil {
 		return nil, err
 	}
-	return s.repo.GetReview(updated.ID)
+	return s.GetReview(updated.BookID, updated.ID)
+}
+
+// GetReviewHistory return prior revisions of review, latest first
+func (s *ReviewService) GetReviewHistory(bookID string, id string) ([]model.ReviewRevision, error) {
+	if _, err := reviewOfBook(s.repo, bookID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReviewHistory(id)
+}
+
+// GetSentimentMismatches return reviews which star score and text sentiment disagree
+// by at least threshold, on -1..1 scale
+func (s *ReviewService) GetSentimentMismatches(threshold float64) ([]report.SentimentMismatch, error) {
+	return s.repo.GetSentimentMismatches(threshold)
+}
+
+// VoteReview record helpful or not helpful vote, each voter has one vote per review
+func (s *ReviewService) VoteReview(bookID string, vote model.ReviewVote) (*model.Review, error) {
+	review, err := reviewOfBook(s.repo, bookID, vote.ReviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.ReviewerID != "" && review.ReviewerID == vote.VoterID {
+		return nil, &bserror.BadParameterError{Msg: "reviewer cannot vote on own review"}
+	}
+	if err := s.repo.VoteReview(vote); err != nil {
+		return nil, err
+	}
+	return s.GetReview(bookID, vote.ReviewID)
 }
 
-func (s *ReviewService) Delete(id string) error {
+// Delete delete review of given book, only its reviewer can delete it, or staff
+// given by staffID when the review has no reviewer
+func (s *ReviewService) Delete(bookID string, id string, reviewerID string, staffID string) error {
+	review, err := reviewOfBook(s.repo, bookID, id)
+	if err != nil {
+		return err
+	}
+	if err := checkReviewOwner(*review, reviewerID, staffID); err != nil {
+		return err
+	}
 	if err := s.repo.DeleteReview(id); err != nil {
 		log.Error(fmt.Sprintf("review book id %s error, %s", id, err.Error()))
 		return err
 	}
 	return nil
 }
+
+// screen run review through screening pipeline, rejected review is returned
+// as error, otherwise verdicts and screening status are set on the review
+func (s *ReviewService) screen(r *model.Review) error {
+	status, verdicts, err := s.screener.Screen(*r)
+	if err != nil {
+		return err
+	}
+	if status == screening.Reject {
+		reasons := []string{}
+		for _, v := range verdicts {
+			if v.Verdict == screening.Reject {
+				reasons = append(reasons, v.Reason)
+			}
+		}
+		log.Info(fmt.Sprintf("review of book id %s rejected, %s", r.BookID, strings.Join(reasons, ", ")))
+		return &bserror.ReviewRejectedError{Msg: "review rejected, " + strings.Join(reasons, ", ")}
+	}
+	r.Fingerprint = screening.Fingerprint(r.Description)
+	r.ScreeningStatus = status
+	r.Verdicts = verdicts
+	return nil
+}
+
+// reviewOfBook return review from repository, review of another book is
+// reported as not found so review routes can not reach across books
+func reviewOfBook(repo repository.ReviewRepository, bookID string, id string) (*model.Review, error) {
+	review, err := repo.GetReview(id)
+	if err != nil {
+		return nil, err
+	}
+	if review.BookID != bookID {
+		return nil, &bserror.NotFoundError{Msg: fmt.Sprintf("review id %s is not found in book id %s", id, bookID)}
+	}
+	return review, nil
+}
+
+// checkReviewOwner reject caller who is not reviewer of the review, review
+// without reviewer was written before reviewers were recorded and is left to staff
+func checkReviewOwner(review model.Review, reviewerID string, staffID string) error {
+	if review.ReviewerID == "" {
+		if staffID == "" {
+			return &bserror.ForbiddenError{Msg: fmt.Sprintf("review id %s has no reviewer, only staff can change it", review.ID)}
+		}
+		return nil
+	}
+	if review.ReviewerID != reviewerID {
+		return &bserror.ForbiddenError{Msg: fmt.Sprintf("review id %s belongs to another reviewer", review.ID)}
+	}
+	return nil
+}
+
+func encodeFeedCursor(t time.Time, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.UTC().Format(time.RFC3339Nano) + "|" + id))
+}
+
+func decodeFeedCursor(cursor string) (time.Time, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("malformed cursor %q", cursor)
+	}
+	t, err := time.Parse(time.RFC3339Nano, parts[0])
+	return t, parts[1], err
+}
+
+// scoreSentiment return sentiment of text, nil when text has no lexicon word so
+// unscored reviews are not taken as neutral
+func scoreSentiment(text string) *float64 {
+	score, ok := sentiment.Score(text)
+	if !ok {
+		return nil
+	}
+	return &score
+}
+
+func scoreHelpfulness(reviews []model.Review) {
+	for i := range reviews {
+		reviews[i].HelpfulnessScore = helpfulnessScore(reviews[i].HelpfulCount, reviews[i].UnhelpfulCount)
+	}
+}
+
+// helpfulnessScore return lower bound of Wilson score interval of helpful ratio,
+// review with few votes ranks below review with many votes of the same ratio
+func helpfulnessScore(helpful int, unhelpful int) float64 {
+	n := float64(helpful + unhelpful)
+	if n == 0 {
+		return 0
+	}
+	p := float64(helpful) / n
+	z2 := helpfulnessZ * helpfulnessZ
+	return (p + z2/(2*n) - helpfulnessZ*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
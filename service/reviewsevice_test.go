@@ -7,7 +7,11 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
 	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/report"
+	"github.com/tsongpon/backend-challenge-2019/screening"
 )
 
 // start mocking book review repository //
@@ -19,8 +23,16 @@ func (m *MockReviewRepository) GetReview(id string) (*model.Review, error) {
 	args := m.Called(id)
 	return args.Get(0).(*model.Review), args.Error(1)
 }
-func (m *MockReviewRepository) GetReviewByBook(bookID string) ([]model.Review, error) {
-	args := m.Called(bookID)
+func (m *MockReviewRepository) GetReviewByBook(q query.ReviewQuery) ([]model.Review, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+func (m *MockReviewRepository) GetReviewByReviewer(reviewerID string) ([]model.Review, error) {
+	args := m.Called(reviewerID)
+	return args.Get(0).([]model.Review), args.Error(1)
+}
+func (m *MockReviewRepository) GetReviewFeed(q query.ReviewFeedQuery) ([]model.Review, error) {
+	args := m.Called(q)
 	return args.Get(0).([]model.Review), args.Error(1)
 }
 func (m *MockReviewRepository) CreateReview(rev model.Review) (*model.Review, error) {
@@ -31,10 +43,35 @@ func (m *MockReviewRepository) UpdateReview(rev model.Review) (*model.Review, er
 	args := m.Called(rev)
 	return args.Get(0).(*model.Review), args.Error(1)
 }
+func (m *MockReviewRepository) GetReviewHistory(id string) ([]model.ReviewRevision, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.ReviewRevision), args.Error(1)
+}
 func (m *MockReviewRepository) DeleteReview(id string) error {
 	args := m.Called(id)
 	return args.Error(0)
 }
+func (m *MockReviewRepository) CountReviewByFingerprint(fingerprint string, excludeID string) (int, error) {
+	args := m.Called(fingerprint, excludeID)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockReviewRepository) GetVerdicts(id string) ([]model.ScreeningVerdict, error) {
+	args := m.Called(id)
+	return args.Get(0).([]model.ScreeningVerdict), args.Error(1)
+}
+func (m *MockReviewRepository) CountReviewByReviewer(reviewerID string, since time.Time) (int, error) {
+	args := m.Called(reviewerID, since)
+	return args.Int(0), args.Error(1)
+}
+func (m *MockReviewRepository) VoteReview(vote model.ReviewVote) error {
+	args := m.Called(vote)
+	return args.Error(0)
+}
+func (m *MockReviewRepository) GetSentimentMismatches(threshold float64) ([]report.SentimentMismatch, error) {
+	args := m.Called(threshold)
+	return args.Get(0).([]report.SentimentMismatch), args.Error(1)
+}
+
 // end mocking book review repository //
 
 func TestGetReview(t *testing.T) {
@@ -48,12 +85,14 @@ func TestGetReview(t *testing.T) {
 		ModifiedTime: &now,
 		Version:      1,
 	}
+	verdicts := []model.ScreeningVerdict{{Rule: "link", Verdict: "passed"}}
 	mockRepo := new(MockReviewRepository)
 	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&mockReview, nil)
+	mockRepo.On("GetVerdicts", "63ce552e-b750-4665-acf3-568a2e844a83").Return(verdicts, nil)
 
-	sev := NewReviewService(mockRepo)
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
 
-	rev, err := sev.GetReview("63ce552e-b750-4665-acf3-568a2e844a83")
+	rev, err := sev.GetReview("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83")
 
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, "63ce552e-b750-4665-acf3-568a2e844a83", rev.ID, "review ID should be the one that return from repo")
@@ -61,6 +100,7 @@ func TestGetReview(t *testing.T) {
 	assert.Equal(t, mockReview.CreatedTime, rev.CreatedTime, "CreatedTime should be the one that return from repo")
 	assert.Equal(t, mockReview.ModifiedTime, rev.ModifiedTime, "ModifiedTime should be the one that return from repo")
 	assert.Equal(t, 1, rev.Version, "Version should be the one that return from repo")
+	assert.Equal(t, verdicts, rev.Verdicts, "Verdicts should be the one that return from repo")
 
 	mockRepo.AssertExpectations(t)
 }
@@ -71,21 +111,33 @@ func TestCreateReview(t *testing.T) {
 		Score:       5,
 		Description: "Good!",
 		BookID:      "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:  "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
 	}
 	createdReview := model.Review{
-		ID:           "63ce552e-b750-4665-acf3-568a2e844a83",
-		Score:        5,
-		Description:  "Good!",
-		BookID:       "52b3637a-6984-401e-84d2-2aa6b9d55665",
-		CreatedTime:  &now,
-		ModifiedTime: &now,
-		Version:      1,
+		ID:               "63ce552e-b750-4665-acf3-568a2e844a83",
+		Score:            5,
+		Description:      "Good!",
+		BookID:           "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:       "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		VerifiedPurchase: true,
+		CreatedTime:      &now,
+		ModifiedTime:     &now,
+		Version:          1,
 	}
+	screened := review
+	screened.VerifiedPurchase = true
+	screened.Fingerprint = screening.Fingerprint("Good!")
+	screened.ScreeningStatus = screening.Pass
+	screened.Verdicts = []model.ScreeningVerdict{{Rule: "link", Verdict: screening.Pass}}
+	screened.Sentiment = scoreSentiment("Good!")
+	mockSaleRepo := new(MockSaleRepository)
+	mockSaleRepo.On("HasPurchased", "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "52b3637a-6984-401e-84d2-2aa6b9d55665").Return(true, nil)
 	mockRepo := new(MockReviewRepository)
-	mockRepo.On("CreateReview", review).Return(&createdReview, nil)
+	mockRepo.On("CreateReview", screened).Return(&createdReview, nil)
 	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&createdReview, nil)
+	mockRepo.On("GetVerdicts", "63ce552e-b750-4665-acf3-568a2e844a83").Return(screened.Verdicts, nil)
 
-	sev := NewReviewService(mockRepo)
+	sev := NewReviewService(mockRepo, mockSaleRepo, screening.NewPipeline(screening.LinkRule{}))
 
 	created, err := sev.CreateRevirw(review)
 
@@ -95,8 +147,10 @@ func TestCreateReview(t *testing.T) {
 	assert.Equal(t, created.CreatedTime, &now, "CreatedTime should be the one that return from repo")
 	assert.Equal(t, created.ModifiedTime, &now, "ModifiedTime should be the one that return from repo")
 	assert.Equal(t, 1, created.Version, "Version should be the one that return from repo")
+	assert.True(t, created.VerifiedPurchase, "review of customer who bought the book should be verified")
 
 	mockRepo.AssertExpectations(t)
+	mockSaleRepo.AssertExpectations(t)
 }
 
 func TestGetBookReviews(t *testing.T) {
@@ -122,11 +176,12 @@ func TestGetBookReviews(t *testing.T) {
 		},
 	}
 	mockRepo := new(MockReviewRepository)
-	mockRepo.On("GetReviewByBook", "52b3637a-6984-401e-84d2-2aa6b9d55665").Return(mockReviews, nil)
+	q := query.ReviewQuery{BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665"}
+	mockRepo.On("GetReviewByBook", q).Return(mockReviews, nil)
 
-	sev := NewReviewService(mockRepo)
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
 
-	revs, err := sev.GetBookReviews("52b3637a-6984-401e-84d2-2aa6b9d55665")
+	revs, err := sev.GetBookReviews(q)
 
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, 2, len(revs), "should get 2 review for this book")
@@ -144,6 +199,7 @@ func TestUpdateReview(t *testing.T) {
 		Score:        5,
 		Description:  "Good!",
 		BookID:       "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:   "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
 		CreatedTime:  &someTimeAgo,
 		ModifiedTime: &someTimeAgo,
 		Version:      1,
@@ -157,12 +213,18 @@ func TestUpdateReview(t *testing.T) {
 		ModifiedTime: &now,
 		Version:      2,
 	}
+	screened := toUpdateReview
+	screened.Fingerprint = screening.Fingerprint("Good!")
+	screened.ScreeningStatus = screening.Pass
+	screened.Verdicts = []model.ScreeningVerdict{}
+	screened.Sentiment = scoreSentiment("Good!")
 	mockRepo := new(MockReviewRepository)
 	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&toUpdateReview, nil)
-	mockRepo.On("UpdateReview", toUpdateReview).Return(&updatedReview, nil)
+	mockRepo.On("UpdateReview", screened).Return(&updatedReview, nil)
+	mockRepo.On("GetVerdicts", "63ce552e-b750-4665-acf3-568a2e844a83").Return(screened.Verdicts, nil)
 
-	sev := NewReviewService(mockRepo)
-	updated, err := sev.UpdateReview(toUpdateReview)
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	updated, err := sev.UpdateReview(toUpdateReview, "")
 
 	assert.Nil(t, err, "should not get any error")
 	assert.Equal(t, "63ce552e-b750-4665-acf3-568a2e844a83", updated.ID, "review ID should be the one that return from repo")
@@ -173,19 +235,328 @@ func TestUpdateReview(t *testing.T) {
 }
 
 func TestDeleteReview(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"}
 	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
 	mockRepo.On("DeleteReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(nil)
 
-	sev := NewReviewService(mockRepo)
-	err := sev.Delete("63ce552e-b750-4665-acf3-568a2e844a83")
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83", "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "")
 	assert.Nil(t, err, "should not get any error")
 }
 
 func TestDeleteReviewWithErrorFromRepo(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"}
 	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
 	mockRepo.On("DeleteReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(errors.New("there is something wrong"))
 
-	sev := NewReviewService(mockRepo)
-	err := sev.Delete("63ce552e-b750-4665-acf3-568a2e844a83")
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83", "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "")
 	assert.NotNil(t, err, "should get error whne repository return error")
 }
+
+func TestDeleteReviewOfAnotherReviewer(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83",
+		"0d2b77c6-3b0c-4c4a-9a7e-0b8f3e5f1d2a", "")
+
+	assert.IsType(t, &bserror.ForbiddenError{}, err, "should get forbidden error")
+	mockRepo.AssertNotCalled(t, "DeleteReview", mock.Anything)
+}
+
+func TestDeleteReviewWithoutReviewer(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665"}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
+	mockRepo.On("DeleteReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83",
+		"c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e", "")
+	assert.IsType(t, &bserror.ForbiddenError{}, err, "reviewer can not delete review without reviewer")
+	assert.Contains(t, err.Error(), "has no reviewer")
+	mockRepo.AssertNotCalled(t, "DeleteReview", mock.Anything)
+
+	err = sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83", "", "staff-1")
+	assert.Nil(t, err, "staff can delete review without reviewer")
+	mockRepo.AssertCalled(t, "DeleteReview", "63ce552e-b750-4665-acf3-568a2e844a83")
+}
+
+func TestDeleteReviewOfReviewerByStaff(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e"}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83", "", "staff-1")
+
+	assert.IsType(t, &bserror.ForbiddenError{}, err, "staff only handle reviews without reviewer")
+	mockRepo.AssertNotCalled(t, "DeleteReview", mock.Anything)
+}
+
+func TestCreateRejectedReview(t *testing.T) {
+	review := model.Review{
+		Score:       1,
+		Description: "this book is shit",
+		BookID:      "52b3637a-6984-401e-84d2-2aa6b9d55665",
+	}
+	mockRepo := new(MockReviewRepository)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline(screening.NewBannedWordRule([]string{"shit"})))
+	created, err := sev.CreateRevirw(review)
+
+	assert.Nil(t, created, "rejected review should not be created")
+	assert.IsType(t, &bserror.ReviewRejectedError{}, err, "should get review rejected error")
+	mockRepo.AssertNotCalled(t, "CreateReview", mock.Anything)
+}
+
+func TestGetReviewerReviews(t *testing.T) {
+	now := time.Now()
+	mockReviews := []model.Review{
+		{
+			ID:           "63ce552e-b750-4665-acf3-568a2e844a83",
+			Score:        5,
+			Description:  "Good!",
+			BookID:       "52b3637a-6984-401e-84d2-2aa6b9d55665",
+			ReviewerID:   "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+			CreatedTime:  &now,
+			ModifiedTime: &now,
+			Version:      1,
+		},
+	}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReviewByReviewer", "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e").Return(mockReviews, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	revs, err := sev.GetReviewerReviews("c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e")
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(revs), "should get 1 review of this reviewer")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateReviewOfAnotherReviewer(t *testing.T) {
+	now := time.Now()
+	existing := model.Review{
+		ID:           "63ce552e-b750-4665-acf3-568a2e844a83",
+		Score:        5,
+		Description:  "Good!",
+		BookID:       "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:   "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		CreatedTime:  &now,
+		ModifiedTime: &now,
+		Version:      1,
+	}
+	toUpdate := existing
+	toUpdate.ReviewerID = "0d2b77c6-3b0c-4c4a-9a7e-0b8f3e5f1d2a"
+	toUpdate.Score = 1
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&existing, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	updated, err := sev.UpdateReview(toUpdate, "")
+
+	assert.Nil(t, updated, "should not update review of another reviewer")
+	assert.IsType(t, &bserror.ForbiddenError{}, err, "should get forbidden error")
+	mockRepo.AssertNotCalled(t, "UpdateReview", mock.Anything)
+}
+
+func TestVoteReview(t *testing.T) {
+	now := time.Now()
+	review := model.Review{
+		ID:           "63ce552e-b750-4665-acf3-568a2e844a83",
+		Score:        5,
+		Description:  "Good!",
+		BookID:       "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:   "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+		HelpfulCount: 1,
+		CreatedTime:  &now,
+		ModifiedTime: &now,
+		Version:      1,
+	}
+	vote := model.ReviewVote{ReviewID: review.ID, VoterID: "0d2b77c6-3b0c-4c4a-9a7e-0b8f3e5f1d2a", Helpful: true}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", review.ID).Return(&review, nil)
+	mockRepo.On("GetVerdicts", review.ID).Return([]model.ScreeningVerdict{}, nil)
+	mockRepo.On("VoteReview", vote).Return(nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	voted, err := sev.VoteReview(review.BookID, vote)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.True(t, voted.HelpfulnessScore > 0, "helpfulness score should be calculated")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVoteOwnReview(t *testing.T) {
+	review := model.Review{
+		ID:         "63ce552e-b750-4665-acf3-568a2e844a83",
+		BookID:     "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID: "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+	}
+	vote := model.ReviewVote{ReviewID: review.ID, VoterID: review.ReviewerID, Helpful: true}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", review.ID).Return(&review, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	voted, err := sev.VoteReview(review.BookID, vote)
+
+	assert.Nil(t, voted, "should not vote own review")
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "VoteReview", mock.Anything)
+}
+
+func TestGetBookReviewsSortByHelpfulness(t *testing.T) {
+	mockReviews := []model.Review{
+		model.Review{ID: "few-votes", HelpfulCount: 2},
+		model.Review{ID: "no-votes"},
+		model.Review{ID: "many-votes", HelpfulCount: 90, UnhelpfulCount: 10},
+	}
+	mockRepo := new(MockReviewRepository)
+	q := query.ReviewQuery{BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665", SortBy: SortByHelpfulness}
+	mockRepo.On("GetReviewByBook", q).Return(mockReviews, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	revs, err := sev.GetBookReviews(q)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, "many-votes", revs[0].ID, "many positive votes should rank first")
+	assert.Equal(t, "few-votes", revs[1].ID, "few positive votes should rank second")
+	assert.Equal(t, "no-votes", revs[2].ID, "review without vote should rank last")
+}
+
+func TestScoreSentimentWithoutLexiconWord(t *testing.T) {
+	assert.Nil(t, scoreSentiment("The book has 300 pages"), "text without lexicon word should have no sentiment")
+	assert.NotNil(t, scoreSentiment("Good!"), "text with lexicon word should have sentiment")
+}
+
+func TestHelpfulnessScore(t *testing.T) {
+	assert.Equal(t, 0.0, helpfulnessScore(0, 0), "no vote should score 0")
+	assert.True(t, helpfulnessScore(90, 10) > helpfulnessScore(9, 1), "more votes of same ratio should score higher")
+	assert.True(t, helpfulnessScore(10, 0) < 1, "score should be below 1")
+}
+
+func TestGetReviewHistoryOfMissingReview(t *testing.T) {
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").
+		Return((*model.Review)(nil), &bserror.NotFoundError{Msg: "not found"})
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	revisions, err := sev.GetReviewHistory("52b3637a-6984-401e-84d2-2aa6b9d55665", "63ce552e-b750-4665-acf3-568a2e844a83")
+
+	assert.Nil(t, revisions, "should not get history")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "GetReviewHistory", mock.Anything)
+}
+
+func TestGetReviewOfAnotherBook(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665"}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	rev, err := sev.GetReview("f5b47970-4d64-42e7-97ab-37dc4273c542", "63ce552e-b750-4665-acf3-568a2e844a83")
+
+	assert.Nil(t, rev, "should not get review of another book")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+}
+
+func TestUpdateReviewOfAnotherBook(t *testing.T) {
+	existing := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665", Version: 1}
+	toUpdate := existing
+	toUpdate.BookID = "f5b47970-4d64-42e7-97ab-37dc4273c542"
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&existing, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	updated, err := sev.UpdateReview(toUpdate, "")
+
+	assert.Nil(t, updated, "should not move review to another book")
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "UpdateReview", mock.Anything)
+}
+
+func TestDeleteReviewOfAnotherBook(t *testing.T) {
+	review := model.Review{ID: "63ce552e-b750-4665-acf3-568a2e844a83", BookID: "52b3637a-6984-401e-84d2-2aa6b9d55665"}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReview", "63ce552e-b750-4665-acf3-568a2e844a83").Return(&review, nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	err := sev.Delete("f5b47970-4d64-42e7-97ab-37dc4273c542", "63ce552e-b750-4665-acf3-568a2e844a83", "", "")
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "should get not found error")
+	mockRepo.AssertNotCalled(t, "DeleteReview", mock.Anything)
+}
+
+func TestCreateReviewScoreSentiment(t *testing.T) {
+	review := model.Review{
+		Score:       1,
+		Description: "Terrible, a waste of money",
+		BookID:      "52b3637a-6984-401e-84d2-2aa6b9d55665",
+		ReviewerID:  "c0a8e8a4-5d1e-4bb4-9a51-8a3f1f3b6f0e",
+	}
+	mockSaleRepo := new(MockSaleRepository)
+	mockSaleRepo.On("HasPurchased", mock.Anything, mock.Anything).Return(false, nil)
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("CreateReview", mock.MatchedBy(func(r model.Review) bool {
+		return r.Sentiment != nil && *r.Sentiment < 0
+	})).Return(&review, errors.New("stop here"))
+
+	sev := NewReviewService(mockRepo, mockSaleRepo, screening.NewPipeline())
+	sev.CreateRevirw(review)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetReviewFeed(t *testing.T) {
+	first := time.Date(2019, 12, 31, 10, 0, 0, 0, time.UTC)
+	second := first.Add(-time.Hour)
+	third := second.Add(-time.Hour)
+	mockReviews := []model.Review{
+		{ID: "review-3", CreatedTime: &first},
+		{ID: "review-2", CreatedTime: &second},
+		{ID: "review-1", CreatedTime: &third},
+	}
+	mockRepo := new(MockReviewRepository)
+	mockRepo.On("GetReviewFeed", query.ReviewFeedQuery{Category: "Programming", Limit: 3}).Return(mockReviews, nil)
+	mockRepo.On("GetReviewFeed", query.ReviewFeedQuery{
+		Category: "Programming", Cursor: encodeFeedCursor(second, "review-2"), Limit: 3,
+		AfterTime: &second, AfterID: "review-2",
+	}).Return(mockReviews[2:], nil)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	page, next, err := sev.GetReviewFeed(query.ReviewFeedQuery{Category: "Programming", Limit: 2})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(page), "should get one page of reviews")
+	assert.NotEqual(t, "", next, "should get cursor of next page")
+
+	page, next, err = sev.GetReviewFeed(query.ReviewFeedQuery{Category: "Programming", Cursor: next, Limit: 2})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(page), "should get the rest of reviews")
+	assert.Equal(t, "review-1", page[0].ID)
+	assert.Equal(t, "", next, "last page should not have cursor")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetReviewFeedWithInvalidCursor(t *testing.T) {
+	mockRepo := new(MockReviewRepository)
+
+	sev := NewReviewService(mockRepo, new(MockSaleRepository), screening.NewPipeline())
+	page, _, err := sev.GetReviewFeed(query.ReviewFeedQuery{Cursor: "not-a-cursor", Limit: 2})
+
+	assert.Nil(t, page, "should not get reviews")
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockRepo.AssertNotCalled(t, "GetReviewFeed", mock.Anything)
+}
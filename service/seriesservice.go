@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+type SeriesService struct {
+	seriesRepo repository.SeriesRepository
+	bookRepo   repository.BookRepository
+}
+
+func NewSeriesService(seriesRepo repository.SeriesRepository, bookRepo repository.BookRepository) *SeriesService {
+	s := new(SeriesService)
+	s.seriesRepo = seriesRepo
+	s.bookRepo = bookRepo
+	return s
+}
+
+func (s *SeriesService) GetSeries(id string) (*model.Series, error) {
+	return s.seriesRepo.GetSeries(id)
+}
+
+func (s *SeriesService) QuerySeries(q query.SeriesQuery) ([]model.Series, error) {
+	return s.seriesRepo.QuerySeries(q)
+}
+
+func (s *SeriesService) CountSeries(q query.SeriesQuery) (int, error) {
+	return s.seriesRepo.CountSeries(q)
+}
+
+func (s *SeriesService) Create(series model.Series) (*model.Series, error) {
+	log.Info(fmt.Sprintf("create new series, name %s", series.Name))
+	series.Name = strings.TrimSpace(series.Name)
+	if series.Name == "" {
+		return nil, &bserror.BadParameterError{Msg: "name is required"}
+	}
+	return s.seriesRepo.CreateSeries(series)
+}
+
+func (s *SeriesService) Update(series model.Series) (*model.Series, error) {
+	if _, err := s.seriesRepo.GetSeries(series.ID); err != nil {
+		return nil, err
+	}
+	series.Name = strings.TrimSpace(series.Name)
+	if series.Name == "" {
+		return nil, &bserror.BadParameterError{Msg: "name is required"}
+	}
+	updated, err := s.seriesRepo.UpdateSeries(series)
+	if err != nil {
+		return nil, err
+	}
+	return s.seriesRepo.GetSeries(updated.ID)
+}
+
+// Delete delete series without books
+func (s *SeriesService) Delete(id string) error {
+	if _, err := s.seriesRepo.GetSeries(id); err != nil {
+		return err
+	}
+	return s.seriesRepo.DeleteSeries(id)
+}
+
+// GetSeriesBooks return the series with a page of its books in volume order,
+// unnumbered books come last
+func (s *SeriesService) GetSeriesBooks(id string, limit int, offset int) (*model.Series, []model.Book, error) {
+	series, err := s.seriesRepo.GetSeries(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	books, err := s.bookRepo.QueryBook(query.BookQuery{Limit: limit, Offset: offset, SeriesID: id, SortBy: "series"})
+	if err != nil {
+		return nil, nil, err
+	}
+	return series, books, nil
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking series repository //
+type MockSeriesRepository struct {
+	mock.Mock
+}
+
+func (m *MockSeriesRepository) GetSeries(id string) (*model.Series, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Series), args.Error(1)
+}
+
+func (m *MockSeriesRepository) QuerySeries(q query.SeriesQuery) ([]model.Series, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Series), args.Error(1)
+}
+
+func (m *MockSeriesRepository) CountSeries(q query.SeriesQuery) (int, error) {
+	args := m.Called(q)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockSeriesRepository) CreateSeries(s model.Series) (*model.Series, error) {
+	args := m.Called(s)
+	return args.Get(0).(*model.Series), args.Error(1)
+}
+
+func (m *MockSeriesRepository) UpdateSeries(s model.Series) (*model.Series, error) {
+	args := m.Called(s)
+	return args.Get(0).(*model.Series), args.Error(1)
+}
+
+func (m *MockSeriesRepository) DeleteSeries(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// end mocking series repository //
+
+func TestGetSeriesBooks(t *testing.T) {
+	series := model.Series{ID: "one-piece", Name: "One Piece", BookCount: 2}
+	mockSeriesRepo := new(MockSeriesRepository)
+	mockSeriesRepo.On("GetSeries", "one-piece").Return(&series, nil)
+	mockBookRepo := new(MockBookRepository)
+	q := query.BookQuery{Limit: 100, Offset: 0, SeriesID: "one-piece", SortBy: "series"}
+	mockBookRepo.On("QueryBook", q).Return([]model.Book{{ID: "vol-1"}, {ID: "vol-2"}}, nil)
+
+	sev := NewSeriesService(mockSeriesRepo, mockBookRepo)
+	s, books, err := sev.GetSeriesBooks("one-piece", 100, 0)
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, s.BookCount, "series must be returned")
+	assert.Equal(t, "vol-1", books[0].ID, "books must be in volume order")
+	mockBookRepo.AssertExpectations(t)
+}
+
+func TestCreateBookWithVolumeWithoutSeries(t *testing.T) {
+	volume := 2.5
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "Viz Media"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", publisher.ID).Return(&publisher, nil)
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository),
+		mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(model.Book{Title: "One Piece", PublisherID: publisher.ID, SeriesVolume: &volume})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "volume needs a series")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
+
+func TestCreateBookWithDecimalVolume(t *testing.T) {
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "Viz Media"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", publisher.ID).Return(&publisher, nil)
+	mockSeriesRepo := new(MockSeriesRepository)
+	mockSeriesRepo.On("GetSeries", "one-piece").Return(&model.Series{ID: "one-piece", Name: "One Piece"}, nil)
+	mockRepo := new(MockBookRepository)
+	mockRepo.On("CreateBook", mock.Anything).Return(&model.Book{ID: "op"}, nil)
+	mockRepo.On("GetBook", "op").Return(&model.Book{ID: "op"}, nil)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository),
+		mockPublisherRepo, new(MockCategoryRepository), mockSeriesRepo, new(MockWorkRepository))
+	for _, v := range []float64{1.1, 2.55, 0.29} {
+		volume := v
+		_, err := sev.Create(model.Book{Title: "One Piece", PublisherID: publisher.ID, SeriesID: "one-piece", SeriesVolume: &volume})
+		assert.Nil(t, err, "volume %v has 2 decimals", v)
+	}
+	mockRepo.AssertNumberOfCalls(t, "CreateBook", 3)
+}
+
+func TestCreateBookWithInvalidVolume(t *testing.T) {
+	volume := 2.125
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "Viz Media"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", publisher.ID).Return(&publisher, nil)
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository),
+		mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.Create(model.Book{Title: "One Piece", PublisherID: publisher.ID, SeriesID: "one-piece", SeriesVolume: &volume})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "volume has at most 2 decimals")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// maxTagLength is the longest tag book_tag can keep
+const maxTagLength = 64
+
+type TagService struct {
+	tagRepo  repository.TagRepository
+	bookRepo repository.BookRepository
+}
+
+func NewTagService(tagRepo repository.TagRepository, bookRepo repository.BookRepository) *TagService {
+	s := new(TagService)
+	s.tagRepo = tagRepo
+	s.bookRepo = bookRepo
+	return s
+}
+
+// QueryTag return tag cloud, tags with number of books they are attached to
+func (s *TagService) QueryTag(q query.TagQuery) ([]model.Tag, error) {
+	prefix := normalizeTag(q.Prefix)
+	if prefix == "" && strings.TrimSpace(q.Prefix) != "" {
+		return []model.Tag{}, nil
+	}
+	q.Prefix = prefix
+	return s.tagRepo.QueryTag(q)
+}
+
+// AttachTags attach tags to the book, tags are normalized like "Staff Pick" into
+// "staff-pick"
+func (s *TagService) AttachTags(bookID string, tags []string) (*model.Book, error) {
+	if len(tags) == 0 {
+		return nil, &bserror.BadParameterError{Msg: "tags is required"}
+	}
+	tags, err := normalizeTags(tags)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.bookRepo.GetBook(bookID); err != nil {
+		return nil, err
+	}
+	log.Info(fmt.Sprintf("tag book id %s with %s", bookID, strings.Join(tags, ", ")))
+	if err := s.tagRepo.AddBookTags(bookID, tags); err != nil {
+		return nil, err
+	}
+	return s.bookRepo.GetBook(bookID)
+}
+
+// DetachTag detach tag from the book
+func (s *TagService) DetachTag(bookID string, tag string) (*model.Book, error) {
+	if _, err := s.bookRepo.GetBook(bookID); err != nil {
+		return nil, err
+	}
+	if err := s.tagRepo.RemoveBookTag(bookID, normalizeTag(tag)); err != nil {
+		return nil, err
+	}
+	return s.bookRepo.GetBook(bookID)
+}
+
+// normalizeTags turn tags into lower case words joined by dash, duplicates removed
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return tags, nil
+	}
+	normalized := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		t := normalizeTag(tag)
+		if t == "" {
+			return nil, &bserror.BadParameterError{Msg: fmt.Sprintf("tag %q must have letters or digits", tag)}
+		}
+		if utf8.RuneCountInString(t) > maxTagLength {
+			return nil, &bserror.BadParameterError{Msg: fmt.Sprintf("tag must not be longer than %d characters", maxTagLength)}
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeTag turn tag into lower case words joined by dash, words keep letters of
+// any script with their marks, digits, "+" and "#" so Thai tags, "C++" and "C#" stay
+// apart
+func normalizeTag(tag string) string {
+	words := strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsMark(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking tag repository //
+type MockTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockTagRepository) AddBookTags(bookID string, tags []string) error {
+	args := m.Called(bookID, tags)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) RemoveBookTag(bookID string, tag string) error {
+	args := m.Called(bookID, tag)
+	return args.Error(0)
+}
+
+func (m *MockTagRepository) QueryTag(q query.TagQuery) ([]model.Tag, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Tag), args.Error(1)
+}
+
+// end mocking tag repository //
+
+func TestAttachTags(t *testing.T) {
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	tagged := model.Book{ID: bookID, Tags: []string{"staff-pick", "summer-reading"}}
+	mockBookRepo := new(MockBookRepository)
+	mockBookRepo.On("GetBook", bookID).Return(&tagged, nil)
+	mockTagRepo := new(MockTagRepository)
+	mockTagRepo.On("AddBookTags", bookID, []string{"staff-pick", "summer-reading"}).Return(nil)
+
+	sev := NewTagService(mockTagRepo, mockBookRepo)
+	b, err := sev.AttachTags(bookID, []string{"Staff Pick", " summer-reading", "staff-pick"})
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, tagged.Tags, b.Tags, "tags of the book must be returned")
+	mockTagRepo.AssertExpectations(t)
+}
+
+func TestAttachBlankTag(t *testing.T) {
+	mockTagRepo := new(MockTagRepository)
+
+	sev := NewTagService(mockTagRepo, new(MockBookRepository))
+	_, err := sev.AttachTags("a432eee1-be54-44e6-a5ef-8a0455306f4f", []string{"staff-pick", " & "})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockTagRepo.AssertNotCalled(t, "AddBookTags", mock.Anything, mock.Anything)
+}
+
+func TestAttachTagsKeepScriptAndSymbols(t *testing.T) {
+	bookID := "a432eee1-be54-44e6-a5ef-8a0455306f4f"
+	mockBookRepo := new(MockBookRepository)
+	mockBookRepo.On("GetBook", bookID).Return(&model.Book{ID: bookID}, nil)
+	mockTagRepo := new(MockTagRepository)
+	mockTagRepo.On("AddBookTags", bookID, []string{"นิยาย-แปล", "c++", "c#"}).Return(nil)
+
+	sev := NewTagService(mockTagRepo, mockBookRepo)
+	_, err := sev.AttachTags(bookID, []string{"นิยาย แปล", "C++", "C#"})
+
+	assert.Nil(t, err, "should not get any error")
+	mockTagRepo.AssertExpectations(t)
+}
+
+func TestQueryTagByPrefix(t *testing.T) {
+	mockTagRepo := new(MockTagRepository)
+	mockTagRepo.On("QueryTag", query.TagQuery{Prefix: "นิยาย"}).Return([]model.Tag{{Name: "นิยาย-แปล", BookCount: 2}}, nil)
+
+	sev := NewTagService(mockTagRepo, new(MockBookRepository))
+	tags, err := sev.QueryTag(query.TagQuery{Prefix: " นิยาย"})
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 1, len(tags))
+
+	tags, err = sev.QueryTag(query.TagQuery{Prefix: "!!"})
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 0, len(tags), "prefix without letters must not match every tag")
+	mockTagRepo.AssertNumberOfCalls(t, "QueryTag", 1)
+}
+
+func TestQueryBookByTags(t *testing.T) {
+	q := query.BookQuery{Limit: 5, AnyTags: []string{"Staff Pick"}, AllTags: []string{"Summer Reading", "summer-reading"}}
+	normalized := query.BookQuery{Limit: 5, AnyTags: []string{"staff-pick"}, AllTags: []string{"summer-reading"}}
+	mockRepo := new(MockBookRepository)
+	mockRepo.On("QueryBook", normalized).Return([]model.Book{}, nil)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository),
+		new(MockPublisherRepository), new(MockCategoryRepository), new(MockSeriesRepository), new(MockWorkRepository))
+	_, err := sev.QueryBook(q)
+
+	assert.Nil(t, err, "should not get any error")
+	mockRepo.AssertExpectations(t)
+}
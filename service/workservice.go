@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/labstack/gommon/log"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/repository"
+)
+
+// maxEditions is the most editions listed with a work
+const maxEditions = 500
+
+type WorkService struct {
+	workRepo repository.WorkRepository
+	bookRepo repository.BookRepository
+}
+
+func NewWorkService(workRepo repository.WorkRepository, bookRepo repository.BookRepository) *WorkService {
+	s := new(WorkService)
+	s.workRepo = workRepo
+	s.bookRepo = bookRepo
+	return s
+}
+
+// GetWork return the work with its first maxEditions editions, oldest first, with
+// EditionsTruncated set when there are more
+func (s *WorkService) GetWork(id string) (*model.Work, error) {
+	w, err := s.workRepo.GetWork(id)
+	if err != nil {
+		return nil, err
+	}
+	editions, err := s.bookRepo.QueryBook(query.BookQuery{Limit: maxEditions + 1, WorkID: id, SortBy: "createdtime"})
+	if err != nil {
+		return nil, err
+	}
+	if len(editions) > maxEditions {
+		editions = editions[:maxEditions]
+		w.EditionsTruncated = true
+	}
+	w.Editions = editions
+	return w, nil
+}
+
+func (s *WorkService) QueryWork(q query.WorkQuery) ([]model.Work, error) {
+	return s.workRepo.QueryWork(q)
+}
+
+func (s *WorkService) CountWork(q query.WorkQuery) (int, error) {
+	return s.workRepo.CountWork(q)
+}
+
+func (s *WorkService) Create(w model.Work) (*model.Work, error) {
+	log.Info(fmt.Sprintf("create new work, title %s", w.Title))
+	w.Title = strings.TrimSpace(w.Title)
+	if w.Title == "" {
+		return nil, &bserror.BadParameterError{Msg: "title is required"}
+	}
+	created, err := s.workRepo.CreateWork(w)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWork(created.ID)
+}
+
+func (s *WorkService) Update(w model.Work) (*model.Work, error) {
+	if _, err := s.workRepo.GetWork(w.ID); err != nil {
+		return nil, err
+	}
+	w.Title = strings.TrimSpace(w.Title)
+	if w.Title == "" {
+		return nil, &bserror.BadParameterError{Msg: "title is required"}
+	}
+	updated, err := s.workRepo.UpdateWork(w)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetWork(updated.ID)
+}
+
+// Delete delete work without editions
+func (s *WorkService) Delete(id string) error {
+	if _, err := s.workRepo.GetWork(id); err != nil {
+		return err
+	}
+	return s.workRepo.DeleteWork(id)
+}
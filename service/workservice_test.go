@@ -0,0 +1,110 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+)
+
+// start mocking work repository //
+type MockWorkRepository struct {
+	mock.Mock
+}
+
+func (m *MockWorkRepository) GetWork(id string) (*model.Work, error) {
+	args := m.Called(id)
+	return args.Get(0).(*model.Work), args.Error(1)
+}
+
+func (m *MockWorkRepository) QueryWork(q query.WorkQuery) ([]model.Work, error) {
+	args := m.Called(q)
+	return args.Get(0).([]model.Work), args.Error(1)
+}
+
+func (m *MockWorkRepository) CountWork(q query.WorkQuery) (int, error) {
+	args := m.Called(q)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockWorkRepository) CreateWork(w model.Work) (*model.Work, error) {
+	args := m.Called(w)
+	return args.Get(0).(*model.Work), args.Error(1)
+}
+
+func (m *MockWorkRepository) UpdateWork(w model.Work) (*model.Work, error) {
+	args := m.Called(w)
+	return args.Get(0).(*model.Work), args.Error(1)
+}
+
+func (m *MockWorkRepository) DeleteWork(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// end mocking work repository //
+
+func TestGetWork(t *testing.T) {
+	score := 4.5
+	work := model.Work{ID: "sicp", Title: "Structure and Interpretation of Computer Programs", EditionCount: 2,
+		ReviewCount: 10, AverageScore: &score}
+	mockWorkRepo := new(MockWorkRepository)
+	mockWorkRepo.On("GetWork", "sicp").Return(&work, nil)
+	mockBookRepo := new(MockBookRepository)
+	q := query.BookQuery{Limit: maxEditions + 1, WorkID: "sicp", SortBy: "createdtime"}
+	mockBookRepo.On("QueryBook", q).Return([]model.Book{{ID: "1st", WorkID: "sicp"}, {ID: "2nd", WorkID: "sicp"}}, nil)
+
+	sev := NewWorkService(mockWorkRepo, mockBookRepo)
+	w, err := sev.GetWork("sicp")
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, 2, len(w.Editions), "editions must be listed with the work")
+	assert.Equal(t, 4.5, *w.AverageScore, "average score of all editions must be returned")
+	assert.False(t, w.EditionsTruncated, "every edition is listed")
+	mockBookRepo.AssertExpectations(t)
+}
+
+func TestGetWorkWithTooManyEditions(t *testing.T) {
+	mockWorkRepo := new(MockWorkRepository)
+	mockWorkRepo.On("GetWork", "sicp").Return(&model.Work{ID: "sicp", EditionCount: maxEditions + 3}, nil)
+	editions := make([]model.Book, maxEditions+1)
+	mockBookRepo := new(MockBookRepository)
+	mockBookRepo.On("QueryBook", query.BookQuery{Limit: maxEditions + 1, WorkID: "sicp", SortBy: "createdtime"}).
+		Return(editions, nil)
+
+	sev := NewWorkService(mockWorkRepo, mockBookRepo)
+	w, err := sev.GetWork("sicp")
+
+	assert.Nil(t, err, "should not get any error")
+	assert.Equal(t, maxEditions, len(w.Editions))
+	assert.True(t, w.EditionsTruncated, "cut off editions must be reported")
+}
+
+func TestCreateWorkWithoutTitle(t *testing.T) {
+	mockWorkRepo := new(MockWorkRepository)
+
+	sev := NewWorkService(mockWorkRepo, new(MockBookRepository))
+	_, err := sev.Create(model.Work{Title: " "})
+
+	assert.IsType(t, &bserror.BadParameterError{}, err, "should get bad parameter error")
+	mockWorkRepo.AssertNotCalled(t, "CreateWork", mock.Anything)
+}
+
+func TestCreateBookOfMissingWork(t *testing.T) {
+	publisher := model.Publisher{ID: "9a4d0f2e-7f52-4c36-9a8c-5b0b7e0d6c11", Name: "MIT Press"}
+	mockPublisherRepo := new(MockPublisherRepository)
+	mockPublisherRepo.On("GetPublisher", publisher.ID).Return(&publisher, nil)
+	mockWorkRepo := new(MockWorkRepository)
+	mockWorkRepo.On("GetWork", "missing").Return((*model.Work)(nil), &bserror.NotFoundError{Msg: "work id missing is not found"})
+	mockRepo := new(MockBookRepository)
+
+	sev := NewBookService(mockRepo, new(MockAuthorRepository),
+		mockPublisherRepo, new(MockCategoryRepository), new(MockSeriesRepository), mockWorkRepo)
+	_, err := sev.Create(model.Book{Title: "SICP", PublisherID: publisher.ID, WorkID: "missing"})
+
+	assert.IsType(t, &bserror.NotFoundError{}, err, "book can not be edition of missing work")
+	mockRepo.AssertNotCalled(t, "CreateBook", mock.Anything)
+}
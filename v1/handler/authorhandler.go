@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type AuthorHandler struct {
+	service *service.AuthorService
+}
+
+func NewAuthorHandler(s *service.AuthorService) *AuthorHandler {
+	h := new(AuthorHandler)
+	h.service = s
+	return h
+}
+
+func (h *AuthorHandler) GetAuthor(c echo.Context) error {
+	a, err := h.service.GetAuthor(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToAuthorTransport(*a))
+}
+
+func (h *AuthorHandler) QueryAuthor(c echo.Context) error {
+	var limit int
+	var offset int
+	var err error
+	if limit, err = strconv.Atoi(c.QueryParam("size")); err != nil {
+		limit = defaultLimit
+	}
+	if offset, err = strconv.Atoi(c.QueryParam("offset")); err != nil {
+		offset = defaultOffset
+	}
+	q := query.AuthorQuery{Limit: limit, Offset: offset, Name: c.QueryParam("name")}
+	authors, err := h.service.QueryAuthor(q)
+	if err != nil {
+		return err
+	}
+	total, err := h.service.CountAuthor(q)
+	if err != nil {
+		return err
+	}
+	ats := []transport.AuthorTransport{}
+	for _, e := range authors {
+		ats = append(ats, mapper.ToAuthorTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.AuthorResponseTransport{Data: ats, Size: len(ats), Total: total})
+}
+
+func (h *AuthorHandler) CreateAuthor(c echo.Context) error {
+	t := transport.AuthorTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	created, err := h.service.Create(mapper.ToAuthorModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToAuthorTransport(*created))
+}
+
+func (h *AuthorHandler) UpdateAuthor(c echo.Context) error {
+	t := transport.AuthorTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	t.ID = c.Param("id")
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.Update(mapper.ToAuthorModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToAuthorTransport(*updated))
+}
+
+func (h *AuthorHandler) DeleteAuthor(c echo.Context) error {
+	if err := h.service.Delete(c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
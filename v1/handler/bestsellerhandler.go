@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+)
+
+type BestsellerHandler struct {
+	service *service.BestsellerService
+}
+
+func NewBestsellerHandler(s *service.BestsellerService) *BestsellerHandler {
+	h := new(BestsellerHandler)
+	h.service = s
+	return h
+}
+
+// GetBestsellerList return list of the category for week of period parameter,
+// last finished week when it is not given
+func (h *BestsellerHandler) GetBestsellerList(c echo.Context) error {
+	period, err := dateParam(c, "period")
+	if err != nil {
+		return err
+	}
+	list, err := h.service.GetList(c.Param("category"), period)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToBestsellerListTransport(*list))
+}
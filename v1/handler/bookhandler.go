@@ -47,7 +47,11 @@ func (h *BookHandler) QueryBook(c echo.Context) error {
 	}
 	sort := c.QueryParam("sort")
 	title := c.QueryParam("title")
-	q := query.BookQuery{Limit: limit, Offset: offset, Title: title, SortBy: sort}
+	author := c.QueryParam("author")
+	category := c.QueryParam("category")
+	q := query.BookQuery{Limit: limit, Offset: offset, Title: title, Author: author, Category: category, SortBy: sort,
+		AnyTags: splitList(c.QueryParam("tags_any")), AllTags: splitList(c.QueryParam("tags_all")),
+		SeriesID: c.QueryParam("series_id"), WorkID: c.QueryParam("work_id")}
 	books, err := h.service.QueryBook(q)
 	if err != nil {
 		return err
@@ -119,24 +123,8 @@ func (h *BookHandler) SaleBook(c echo.Context) error {
 	if t.Amount <= 0 {
 		return &bserror.BadParameterError{Msg: "amount must more than 0"}
 	}
-	if err := h.service.SaleBook(id, t.Amount); err != nil {
+	if err := h.service.SaleBook(id, t.Amount, t.CustomerID, t.Format); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusOK)
 }
-
-func (h *BookHandler) GetBastSallBook(c echo.Context) error {
-	rpt, err := h.service.GetBestSallBooks()
-	if err != nil {
-		return err
-	}
-	return c.JSON(http.StatusOK, rpt)
-}
-
-func (h *BookHandler) GetBastSallCategory(c echo.Context) error {
-	rpt, err := h.service.GetBestSallCategory()
-	if err != nil {
-		return err
-	}
-	return c.JSON(http.StatusOK, rpt)
-}
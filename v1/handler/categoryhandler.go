@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type CategoryHandler struct {
+	service *service.CategoryService
+}
+
+func NewCategoryHandler(s *service.CategoryService) *CategoryHandler {
+	h := new(CategoryHandler)
+	h.service = s
+	return h
+}
+
+func (h *CategoryHandler) GetCategory(c echo.Context) error {
+	category, err := h.service.GetCategory(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toLocalizedCategory(*category, c.QueryParam("lang")))
+}
+
+// QueryCategory list categories in tree order, only subtree of root when given
+func (h *CategoryHandler) QueryCategory(c echo.Context) error {
+	categories, err := h.service.QueryCategory(query.CategoryQuery{Root: c.QueryParam("root")})
+	if err != nil {
+		return err
+	}
+	cts := []transport.CategoryTransport{}
+	for _, e := range categories {
+		cts = append(cts, toLocalizedCategory(e, c.QueryParam("lang")))
+	}
+	return c.JSON(http.StatusOK, transport.CategoryResponseTransport{Data: cts, Size: len(cts), Total: len(cts)})
+}
+
+func (h *CategoryHandler) CreateCategory(c echo.Context) error {
+	t := transport.CategoryTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	created, err := h.service.Create(mapper.ToCategoryModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToCategoryTransport(*created))
+}
+
+func (h *CategoryHandler) UpdateCategory(c echo.Context) error {
+	t := transport.CategoryTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	t.ID = c.Param("id")
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.Update(mapper.ToCategoryModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToCategoryTransport(*updated))
+}
+
+func (h *CategoryHandler) DeleteCategory(c echo.Context) error {
+	if err := h.service.Delete(c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// MoveCategory move category with categories below it under parent in body
+func (h *CategoryHandler) MoveCategory(c echo.Context) error {
+	t := transport.MoveCategoryTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	moved, err := h.service.Move(c.Param("id"), t.ParentID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToCategoryTransport(*moved))
+}
+
+// MergeCategories merge categories in body into category of path
+func (h *CategoryHandler) MergeCategories(c echo.Context) error {
+	t := transport.MergeCategoryTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	merged, err := h.service.Merge(c.Param("id"), t.CategoryIDs)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToCategoryTransport(*merged))
+}
+
+// toLocalizedCategory map category with name in given locale when it has one
+func toLocalizedCategory(m model.Category, lang string) transport.CategoryTransport {
+	t := mapper.ToCategoryTransport(m)
+	if name, ok := m.Names[lang]; ok {
+		t.Name = name
+	}
+	return t
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/export"
+)
+
+// respondReport write report as JSON, or as CSV or XLSX download chosen by format
+// query parameter or Accept header, CSV holds the table named by section query
+// parameter or the first table of the report
+func respondReport(c echo.Context, name string, rpt interface{}) error {
+	format := c.QueryParam("format")
+	if format == "" {
+		format = acceptedFormat(c.Request().Header.Get(echo.HeaderAccept))
+	}
+	switch format {
+	case "", "json":
+		return c.JSON(http.StatusOK, rpt)
+	case export.FormatCSV:
+		sheets, err := export.Sheets(rpt)
+		if err != nil {
+			return err
+		}
+		sheet, err := section(sheets, c.QueryParam("section"))
+		if err != nil {
+			return err
+		}
+		download(c, export.ContentTypeCSV, name+".csv")
+		return export.WriteCSV(c.Response(), sheet)
+	case export.FormatXLSX:
+		sheets, err := export.Sheets(rpt)
+		if err != nil {
+			return err
+		}
+		download(c, export.ContentTypeXLSX, name+".xlsx")
+		return export.WriteXLSX(c.Response(), sheets)
+	}
+	return &bserror.BadParameterError{Msg: "format must be json, csv or xlsx"}
+}
+
+// acceptedFormat return export format asked by Accept header, empty for JSON
+func acceptedFormat(accept string) string {
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return export.FormatCSV
+	case strings.Contains(accept, export.ContentTypeXLSX):
+		return export.FormatXLSX
+	}
+	return ""
+}
+
+func section(sheets []export.Sheet, name string) (export.Sheet, error) {
+	if name == "" {
+		return sheets[0], nil
+	}
+	names := []string{}
+	for _, s := range sheets {
+		if s.Name == name {
+			return s, nil
+		}
+		names = append(names, s.Name)
+	}
+	return export.Sheet{}, &bserror.BadParameterError{Msg: "section must be one of " + strings.Join(names, ", ")}
+}
+
+// download start streaming attachment, status can not be changed afterwards so
+// parameters must be checked before
+func download(c echo.Context, contentType string, filename string) {
+	c.Response().Header().Set(echo.HeaderContentType, contentType)
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	c.Response().WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+)
+
+const dateLayout = "2006-01-02"
+
+// dateRangeParam read from and to query parameters as dates, to is inclusive so
+// returned to is start of the next day
+func dateRangeParam(c echo.Context) (*time.Time, *time.Time, error) {
+	from, err := dateParam(c, "from")
+	if err != nil {
+		return nil, nil, err
+	}
+	to, err := dateParam(c, "to")
+	if err != nil {
+		return nil, nil, err
+	}
+	if to != nil {
+		t := to.AddDate(0, 0, 1)
+		to = &t
+	}
+	return from, to, nil
+}
+
+// dateParam read optional date query parameter
+func dateParam(c echo.Context, name string) (*time.Time, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(dateLayout, v)
+	if err != nil {
+		return nil, &bserror.BadParameterError{Msg: name + " must be a date like 2019-12-31"}
+	}
+	return &t, nil
+}
+
+func scoreParam(c echo.Context, name string) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return 0, nil
+	}
+	score, err := strconv.Atoi(v)
+	if err != nil || score < 1 || score > 5 {
+		return 0, &bserror.BadParameterError{Msg: name + " must be a number from 1 to 5"}
+	}
+	return score, nil
+}
+
+// intParam read optional non negative number query parameter
+func intParam(c echo.Context, name string, fallback int) (int, error) {
+	v := c.QueryParam(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, &bserror.BadParameterError{Msg: name + " must be a number of 0 or more"}
+	}
+	return n, nil
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type PublisherHandler struct {
+	service *service.PublisherService
+}
+
+func NewPublisherHandler(s *service.PublisherService) *PublisherHandler {
+	h := new(PublisherHandler)
+	h.service = s
+	return h
+}
+
+func (h *PublisherHandler) GetPublisher(c echo.Context) error {
+	p, err := h.service.GetPublisher(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToPublisherTransport(*p))
+}
+
+func (h *PublisherHandler) QueryPublisher(c echo.Context) error {
+	var limit int
+	var offset int
+	var err error
+	if limit, err = strconv.Atoi(c.QueryParam("size")); err != nil {
+		limit = defaultLimit
+	}
+	if offset, err = strconv.Atoi(c.QueryParam("offset")); err != nil {
+		offset = defaultOffset
+	}
+	q := query.PublisherQuery{Limit: limit, Offset: offset, Name: c.QueryParam("name")}
+	publishers, err := h.service.QueryPublisher(q)
+	if err != nil {
+		return err
+	}
+	total, err := h.service.CountPublisher(q)
+	if err != nil {
+		return err
+	}
+	pts := []transport.PublisherTransport{}
+	for _, e := range publishers {
+		pts = append(pts, mapper.ToPublisherTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.PublisherResponseTransport{Data: pts, Size: len(pts), Total: total})
+}
+
+func (h *PublisherHandler) CreatePublisher(c echo.Context) error {
+	t := transport.PublisherTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	created, err := h.service.Create(mapper.ToPublisherModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToPublisherTransport(*created))
+}
+
+func (h *PublisherHandler) UpdatePublisher(c echo.Context) error {
+	t := transport.PublisherTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	t.ID = c.Param("id")
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.Update(mapper.ToPublisherModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToPublisherTransport(*updated))
+}
+
+func (h *PublisherHandler) DeletePublisher(c echo.Context) error {
+	if err := h.service.Delete(c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// MergePublishers move books of publishers in body to publisher of path and delete them
+func (h *PublisherHandler) MergePublishers(c echo.Context) error {
+	t := transport.MergePublisherTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	merged, err := h.service.Merge(c.Param("id"), t.PublisherIDs)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToPublisherTransport(*merged))
+}
+
+// GetDuplicates list groups of publishers looking like the same publisher
+func (h *PublisherHandler) GetDuplicates(c echo.Context) error {
+	groups, err := h.service.FindDuplicates()
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toDuplicateTransports(groups))
+}
+
+// MergeDuplicates merge groups of duplicated publishers in body, each into its first publisher
+func (h *PublisherHandler) MergeDuplicates(c echo.Context) error {
+	t := transport.MergeDuplicatePublishersTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	groups, err := h.service.MergeDuplicates(t.Groups)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, toDuplicateTransports(groups))
+}
+
+func toDuplicateTransports(groups [][]model.Publisher) []transport.DuplicatePublisherTransport {
+	dts := []transport.DuplicatePublisherTransport{}
+	for _, group := range groups {
+		dt := transport.DuplicatePublisherTransport{Publishers: []transport.PublisherTransport{}}
+		for _, p := range group {
+			dt.Publishers = append(dt.Publishers, mapper.ToPublisherTransport(p))
+		}
+		dts = append(dts, dt)
+	}
+	return dts
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+// staffHeader carry id of staff member authenticated by api gateway, it is only
+// set for staff callers
+const staffHeader = "X-Staff-ID"
+
+type ReplyHandler struct {
+	service *service.ReplyService
+}
+
+func NewReplyHandler(s *service.ReplyService) *ReplyHandler {
+	h := new(ReplyHandler)
+	h.service = s
+	return h
+}
+
+func (h *ReplyHandler) GetReply(c echo.Context) error {
+	reply, err := h.service.GetReply(c.Param("book_id"), c.Param("id"), c.Param("reply_id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToReplyTransport(*reply))
+}
+
+func (h *ReplyHandler) GetReviewReply(c echo.Context) error {
+	replies, err := h.service.GetReviewReplies(c.Param("book_id"), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	rts := []transport.ReplyTransport{}
+	for _, e := range replies {
+		rts = append(rts, mapper.ToReplyTransport(e))
+	}
+	return c.JSON(http.StatusOK, rts)
+}
+
+func (h *ReplyHandler) CreateReply(c echo.Context) error {
+	rt := transport.ReplyTransport{}
+	if err := c.Bind(&rt); err != nil {
+		return err
+	}
+	rt.ReviewID = c.Param("id")
+	id, err := staffID(c)
+	if err != nil {
+		return err
+	}
+	rt.AuthorID = id
+	if err := c.Validate(rt); err != nil {
+		return err
+	}
+	created, err := h.service.CreateReply(c.Param("book_id"), mapper.ToReplyModel(rt))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToReplyTransport(*created))
+}
+
+func (h *ReplyHandler) UpdateReply(c echo.Context) error {
+	rt := transport.ReplyTransport{}
+	if err := c.Bind(&rt); err != nil {
+		return err
+	}
+	rt.ID = c.Param("reply_id")
+	rt.ReviewID = c.Param("id")
+	id, err := staffID(c)
+	if err != nil {
+		return err
+	}
+	rt.AuthorID = id
+	if err := c.Validate(rt); err != nil {
+		return err
+	}
+	updated, err := h.service.UpdateReply(c.Param("book_id"), mapper.ToReplyModel(rt))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToReplyTransport(*updated))
+}
+
+func (h *ReplyHandler) DeleteReply(c echo.Context) error {
+	if _, err := staffID(c); err != nil {
+		return err
+	}
+	if err := h.service.Delete(c.Param("book_id"), c.Param("id"), c.Param("reply_id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// staffID return id of staff member calling, anyone else is rejected
+func staffID(c echo.Context) (string, error) {
+	id := c.Request().Header.Get(staffHeader)
+	if id == "" {
+		return "", &bserror.ForbiddenError{Msg: "only staff can reply to reviews"}
+	}
+	return id, nil
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type ReportHandler struct {
+	service *service.ReportService
+}
+
+func NewReportHandler(s *service.ReportService) *ReportHandler {
+	h := new(ReportHandler)
+	h.service = s
+	return h
+}
+
+func (h *ReportHandler) GetBastSallBook(c echo.Context) error {
+	q, err := saleReportQuery(c)
+	if err != nil {
+		return err
+	}
+	rpt, err := h.service.GetBestSallBooks(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "bestsallbook", rpt)
+}
+
+func (h *ReportHandler) GetBastSallCategory(c echo.Context) error {
+	q, err := saleReportQuery(c)
+	if err != nil {
+		return err
+	}
+	rpt, err := h.service.GetBestSallCategory(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "bestsallcategory", rpt)
+}
+
+func (h *ReportHandler) GetRevenue(c echo.Context) error {
+	sq, err := saleReportQuery(c)
+	if err != nil {
+		return err
+	}
+	q := query.RevenueReportQuery{SaleReportQuery: sq, GroupBy: c.QueryParam("group"), Bucket: c.QueryParam("bucket")}
+	rpt, err := h.service.GetRevenue(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "revenue", rpt)
+}
+
+// GetInventoryValuation return value of stock on hand, today or at end of as_of date
+func (h *ReportHandler) GetInventoryValuation(c echo.Context) error {
+	asOf, err := dateParam(c, "as_of")
+	if err != nil {
+		return err
+	}
+	rpt, err := h.service.GetInventoryValuation(asOf)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "inventory-valuation", rpt)
+}
+
+// GetInventoryAging return dead stock and ABC classification of the catalogue
+func (h *ReportHandler) GetInventoryAging(c echo.Context) error {
+	q := query.InventoryAgingQuery{}
+	var err error
+	if q.Days, err = intParam(c, "days", service.DefaultAgingDays); err != nil {
+		return err
+	}
+	if q.Window, err = intParam(c, "window", service.DefaultAgingWindow); err != nil {
+		return err
+	}
+	rpt, err := h.service.GetInventoryAging(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "inventory-aging", rpt)
+}
+
+// GetReorderSuggestions return books which forecast demand exceeds their stock
+func (h *ReportHandler) GetReorderSuggestions(c echo.Context) error {
+	q, err := reorderQuery(c)
+	if err != nil {
+		return err
+	}
+	rpt, err := h.service.GetReorderSuggestions(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "reorder-suggestions", rpt)
+}
+
+// CreateDraftRestock turn suggestion of a book into draft restock, suggested amount
+// is taken when quantity is not given
+func (h *ReportHandler) CreateDraftRestock(c echo.Context) error {
+	t := transport.RestockTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	q, err := reorderQuery(c)
+	if err != nil {
+		return err
+	}
+	created, err := h.service.CreateDraftRestock(q, t.BookID, t.Quantity)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToRestockTransport(*created))
+}
+
+// GetRestocks list restocks in status query parameter, draft when not given
+func (h *ReportHandler) GetRestocks(c echo.Context) error {
+	restocks, err := h.service.GetRestocks(c.QueryParam("status"))
+	if err != nil {
+		return err
+	}
+	ts := []transport.RestockTransport{}
+	for _, e := range restocks {
+		ts = append(ts, mapper.ToRestockTransport(e))
+	}
+	return c.JSON(http.StatusOK, ts)
+}
+
+// UpdateRestockStatus move restock to next status, like ordered or received
+func (h *ReportHandler) UpdateRestockStatus(c echo.Context) error {
+	t := transport.RestockStatusTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.UpdateRestockStatus(c.Param("id"), t.Status, t.Version)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToRestockTransport(*updated))
+}
+
+// GetReviewAnalytics return aggregate view of reviews within from and to
+func (h *ReportHandler) GetReviewAnalytics(c echo.Context) error {
+	q := query.ReviewReportQuery{Bucket: c.QueryParam("bucket")}
+	var err error
+	if q.From, q.To, err = dateRangeParam(c); err != nil {
+		return err
+	}
+	if q.MinReviews, err = intParam(c, "min_reviews", service.DefaultMinReviews); err != nil {
+		return err
+	}
+	if q.Limit, err = intParam(c, "limit", service.DefaultRatedLimit); err != nil {
+		return err
+	}
+	rpt, err := h.service.GetReviewAnalytics(q)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "reviews", rpt)
+}
+
+func reorderQuery(c echo.Context) (query.ReorderQuery, error) {
+	q := query.ReorderQuery{Method: c.QueryParam("method")}
+	var err error
+	if q.History, err = intParam(c, "history", service.DefaultForecastHistory); err != nil {
+		return q, err
+	}
+	if q.Weeks, err = intParam(c, "weeks", service.DefaultForecastWeeks); err != nil {
+		return q, err
+	}
+	if q.LeadTime, err = intParam(c, "lead_time", service.DefaultLeadTime); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+func saleReportQuery(c echo.Context) (query.SaleReportQuery, error) {
+	q := query.SaleReportQuery{
+		Category:  c.QueryParam("category"),
+		Publisher: c.QueryParam("publisher"),
+	}
+	var err error
+	if q.From, q.To, err = dateRangeParam(c); err != nil {
+		return q, err
+	}
+	if q.Limit, err = intParam(c, "limit", 0); err != nil {
+		return q, err
+	}
+	return q, nil
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+// defaultRunSize is number of runs listed when size is not given
+const defaultRunSize = 50
+
+type ReportRunHandler struct {
+	service *service.ReportRunService
+}
+
+func NewReportRunHandler(s *service.ReportRunService) *ReportRunHandler {
+	h := new(ReportRunHandler)
+	h.service = s
+	return h
+}
+
+func (h *ReportRunHandler) GetReportRuns(c echo.Context) error {
+	size, err := intParam(c, "size", defaultRunSize)
+	if err != nil {
+		return err
+	}
+	runs, err := h.service.GetRuns(c.QueryParam("job"), size)
+	if err != nil {
+		return err
+	}
+	ts := []transport.ReportRunTransport{}
+	for _, e := range runs {
+		ts = append(ts, mapper.ToReportRunTransport(e))
+	}
+	return c.JSON(http.StatusOK, ts)
+}
+
+func (h *ReportRunHandler) GetReportRun(c echo.Context) error {
+	run, err := h.service.GetRun(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToReportRunTransport(*run))
+}
+
+func (h *ReportRunHandler) DownloadReportRun(c echo.Context) error {
+	path, err := h.service.GetRunFile(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.Attachment(path, filepath.Base(path))
+}
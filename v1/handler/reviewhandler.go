@@ -2,29 +2,49 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/bserror"
+	"github.com/tsongpon/backend-challenge-2019/model"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/screening"
 	"github.com/tsongpon/backend-challenge-2019/service"
 	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
 	"github.com/tsongpon/backend-challenge-2019/v1/transport"
 )
 
+const (
+	defaultFeedLimit = 20
+	maxFeedLimit     = 100
+)
+
+// reviewerHeader carry id of caller authenticated by api gateway
+const reviewerHeader = "X-Reviewer-ID"
+
 type ReviewHandler struct {
-	service *service.ReviewService
+	service      *service.ReviewService
+	replyService *service.ReplyService
 }
 
-func NewReviewHandler(s *service.ReviewService) *ReviewHandler {
+func NewReviewHandler(s *service.ReviewService, replyService *service.ReplyService) *ReviewHandler {
 	h := new(ReviewHandler)
 	h.service = s
+	h.replyService = replyService
 	return h
 }
 
 func (h *ReviewHandler) GetReview(c echo.Context) error {
-	review, err := h.service.GetReview(c.Param("id"))
+	review, err := h.service.GetReview(c.Param("book_id"), c.Param("id"))
 	if err != nil {
 		return err
 	}
-	return c.JSON(http.StatusOK, mapper.ToReviewTransport(*review))
+	reviews := []model.Review{*review}
+	if err := h.include(c, reviews); err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToReviewTransport(reviews[0]))
 }
 
 func (h *ReviewHandler) UpdateReview(c echo.Context) error {
@@ -34,7 +54,13 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 		return err
 	}
 	rt.BookID = bookID
-	updated, err := h.service.UpdateReview(mapper.ToReviewModel(rt))
+	rt.ID = c.Param("id")
+	id, staffID, err := ownerIDs(c)
+	if err != nil {
+		return err
+	}
+	rt.ReviewerID = id
+	updated, err := h.service.UpdateReview(mapper.ToReviewModel(rt), staffID)
 	if err != nil {
 		return err
 	}
@@ -42,10 +68,45 @@ func (h *ReviewHandler) UpdateReview(c echo.Context) error {
 }
 
 func (h *ReviewHandler) GetBookReview(c echo.Context) error {
-	reviews, err := h.service.GetBookReviews(c.Param("book_id"))
+	return h.queryReviews(c, query.ReviewQuery{BookID: c.Param("book_id"), SortBy: c.QueryParam("sort")})
+}
+
+// GetWorkReview list reviews of every edition of the work
+func (h *ReviewHandler) GetWorkReview(c echo.Context) error {
+	return h.queryReviews(c, query.ReviewQuery{WorkID: c.Param("id"), SortBy: c.QueryParam("sort")})
+}
+
+// queryReviews list reviews of book or work, filtered by verified query parameter
+func (h *ReviewHandler) queryReviews(c echo.Context, q query.ReviewQuery) error {
+	if v := c.QueryParam("verified"); v != "" {
+		verified, err := strconv.ParseBool(v)
+		if err != nil {
+			return &bserror.BadParameterError{Msg: "verified must be true or false"}
+		}
+		q.VerifiedPurchase = &verified
+	}
+	reviews, err := h.service.GetBookReviews(q)
+	if err != nil {
+		return err
+	}
+	if err := h.include(c, reviews); err != nil {
+		return err
+	}
+	rts := []transport.ReviewTransport{}
+	for _, e := range reviews {
+		rts = append(rts, mapper.ToReviewTransport(e))
+	}
+	return c.JSON(http.StatusOK, rts)
+}
+
+func (h *ReviewHandler) GetReviewerReview(c echo.Context) error {
+	reviews, err := h.service.GetReviewerReviews(c.Param("id"))
 	if err != nil {
 		return err
 	}
+	if err := h.include(c, reviews); err != nil {
+		return err
+	}
 	rts := []transport.ReviewTransport{}
 	for _, e := range reviews {
 		rts = append(rts, mapper.ToReviewTransport(e))
@@ -53,6 +114,83 @@ func (h *ReviewHandler) GetBookReview(c echo.Context) error {
 	return c.JSON(http.StatusOK, rts)
 }
 
+// GetReviewFeed list reviews across all books, newest first, filtered by
+// score, created date, book category and screening status
+func (h *ReviewHandler) GetReviewFeed(c echo.Context) error {
+	q := query.ReviewFeedQuery{
+		Category:        c.QueryParam("category"),
+		ScreeningStatus: c.QueryParam("status"),
+		Cursor:          c.QueryParam("cursor"),
+		Limit:           defaultFeedLimit,
+	}
+	if v := c.QueryParam("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < 1 || size > maxFeedLimit {
+			return &bserror.BadParameterError{Msg: "size must be a number from 1 to 100"}
+		}
+		q.Limit = size
+	}
+	if q.ScreeningStatus != "" && q.ScreeningStatus != screening.Pass && q.ScreeningStatus != screening.Flag {
+		return &bserror.BadParameterError{Msg: "status must be passed or flagged"}
+	}
+	var err error
+	if q.MinScore, err = scoreParam(c, "min_score"); err != nil {
+		return err
+	}
+	if q.MaxScore, err = scoreParam(c, "max_score"); err != nil {
+		return err
+	}
+	if c.QueryParam("score") != "" {
+		if q.MinScore, err = scoreParam(c, "score"); err != nil {
+			return err
+		}
+		q.MaxScore = q.MinScore
+	}
+	if q.From, q.To, err = dateRangeParam(c); err != nil {
+		return err
+	}
+	reviews, next, err := h.service.GetReviewFeed(q)
+	if err != nil {
+		return err
+	}
+	if err := h.include(c, reviews); err != nil {
+		return err
+	}
+	rts := []transport.ReviewTransport{}
+	for _, e := range reviews {
+		rts = append(rts, mapper.ToReviewTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.ReviewFeedTransport{Size: len(rts), NextCursor: next, Data: rts})
+}
+
+func (h *ReviewHandler) GetReviewHistory(c echo.Context) error {
+	revisions, err := h.service.GetReviewHistory(c.Param("book_id"), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	rts := []transport.ReviewRevisionTransport{}
+	for _, e := range revisions {
+		rts = append(rts, mapper.ToReviewRevisionTransport(e))
+	}
+	return c.JSON(http.StatusOK, rts)
+}
+
+func (h *ReviewHandler) GetSentimentMismatch(c echo.Context) error {
+	threshold := service.DefaultMismatchThreshold
+	if v := c.QueryParam("threshold"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 2 {
+			return &bserror.BadParameterError{Msg: "threshold must be a number from 0 to 2"}
+		}
+		threshold = t
+	}
+	rpt, err := h.service.GetSentimentMismatches(threshold)
+	if err != nil {
+		return err
+	}
+	return respondReport(c, "sentimentmismatch", rpt)
+}
+
 func (h *ReviewHandler) CreateReview(c echo.Context) error {
 	bookID := c.Param("book_id")
 	rt := transport.ReviewTransport{}
@@ -60,6 +198,11 @@ func (h *ReviewHandler) CreateReview(c echo.Context) error {
 		return err
 	}
 	rt.BookID = bookID
+	id, err := reviewerID(c)
+	if err != nil {
+		return err
+	}
+	rt.ReviewerID = id
 	created, err := h.service.CreateRevirw(mapper.ToReviewModel(rt))
 	if err != nil {
 		return err
@@ -67,9 +210,72 @@ func (h *ReviewHandler) CreateReview(c echo.Context) error {
 	return c.JSON(http.StatusCreated, mapper.ToReviewTransport(*created))
 }
 
+func (h *ReviewHandler) VoteReview(c echo.Context) error {
+	vt := transport.VoteTransport{}
+	if err := c.Bind(&vt); err != nil {
+		return &bserror.BadParameterError{Msg: "invalid payload, please check"}
+	}
+	if vt.Helpful == nil {
+		return &bserror.BadParameterError{Msg: "helpful is required"}
+	}
+	voterID, err := reviewerID(c)
+	if err != nil {
+		return err
+	}
+	vote := model.ReviewVote{ReviewID: c.Param("id"), VoterID: voterID, Helpful: *vt.Helpful}
+	updated, err := h.service.VoteReview(c.Param("book_id"), vote)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToReviewTransport(*updated))
+}
+
 func (h *ReviewHandler) DeleteReview(c echo.Context) error {
-	if err := h.service.Delete(c.Param("id")); err != nil {
+	id, staffID, err := ownerIDs(c)
+	if err != nil {
+		return err
+	}
+	if err := h.service.Delete(c.Param("book_id"), c.Param("id"), id, staffID); err != nil {
 		return err
 	}
 	return c.NoContent(http.StatusOK)
 }
+
+// include nest related data asked by include query parameter,
+// e.g. include=replies, into given reviews
+func (h *ReviewHandler) include(c echo.Context, reviews []model.Review) error {
+	for _, name := range strings.Split(c.QueryParam("include"), ",") {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "replies":
+			if err := h.replyService.AttachReplies(reviews); err != nil {
+				return err
+			}
+		default:
+			return &bserror.BadParameterError{Msg: "unknown include " + name}
+		}
+	}
+	return nil
+}
+
+// reviewerID return id of caller authenticated by api gateway, request without
+// it is rejected so nobody can act as another reviewer
+func reviewerID(c echo.Context) (string, error) {
+	id := c.Request().Header.Get(reviewerHeader)
+	if id == "" {
+		return "", &bserror.UnauthorizedError{Msg: reviewerHeader + " header is required"}
+	}
+	return id, nil
+}
+
+// ownerIDs return reviewer and staff id of caller authenticated by api gateway for
+// changing a review, staff change reviews without reviewer, request with neither
+// is rejected
+func ownerIDs(c echo.Context) (string, string, error) {
+	reviewer := c.Request().Header.Get(reviewerHeader)
+	staff := c.Request().Header.Get(staffHeader)
+	if reviewer == "" && staff == "" {
+		return "", "", &bserror.UnauthorizedError{Msg: reviewerHeader + " or " + staffHeader + " header is required"}
+	}
+	return reviewer, staff, nil
+}
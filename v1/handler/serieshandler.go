@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+// defaultSeriesLimit is page size of series books, long enough for most series in one page
+const defaultSeriesLimit = 100
+
+type SeriesHandler struct {
+	service *service.SeriesService
+}
+
+func NewSeriesHandler(s *service.SeriesService) *SeriesHandler {
+	h := new(SeriesHandler)
+	h.service = s
+	return h
+}
+
+func (h *SeriesHandler) GetSeries(c echo.Context) error {
+	s, err := h.service.GetSeries(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToSeriesTransport(*s))
+}
+
+func (h *SeriesHandler) QuerySeries(c echo.Context) error {
+	var limit int
+	var offset int
+	var err error
+	if limit, err = strconv.Atoi(c.QueryParam("size")); err != nil {
+		limit = defaultLimit
+	}
+	if offset, err = strconv.Atoi(c.QueryParam("offset")); err != nil {
+		offset = defaultOffset
+	}
+	q := query.SeriesQuery{Limit: limit, Offset: offset, Name: c.QueryParam("name")}
+	series, err := h.service.QuerySeries(q)
+	if err != nil {
+		return err
+	}
+	total, err := h.service.CountSeries(q)
+	if err != nil {
+		return err
+	}
+	sts := []transport.SeriesTransport{}
+	for _, e := range series {
+		sts = append(sts, mapper.ToSeriesTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.SeriesResponseTransport{Data: sts, Size: len(sts), Total: total})
+}
+
+func (h *SeriesHandler) CreateSeries(c echo.Context) error {
+	t := transport.SeriesTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	created, err := h.service.Create(mapper.ToSeriesModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToSeriesTransport(*created))
+}
+
+func (h *SeriesHandler) UpdateSeries(c echo.Context) error {
+	t := transport.SeriesTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	t.ID = c.Param("id")
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.Update(mapper.ToSeriesModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToSeriesTransport(*updated))
+}
+
+func (h *SeriesHandler) DeleteSeries(c echo.Context) error {
+	if err := h.service.Delete(c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
+
+// GetSeriesBooks list books of the series in volume order
+func (h *SeriesHandler) GetSeriesBooks(c echo.Context) error {
+	var limit int
+	var offset int
+	var err error
+	if limit, err = strconv.Atoi(c.QueryParam("size")); err != nil {
+		limit = defaultSeriesLimit
+	}
+	if offset, err = strconv.Atoi(c.QueryParam("offset")); err != nil {
+		offset = defaultOffset
+	}
+	series, books, err := h.service.GetSeriesBooks(c.Param("id"), limit, offset)
+	if err != nil {
+		return err
+	}
+	bts := []transport.BookTransport{}
+	for _, e := range books {
+		bts = append(bts, mapper.ToBookTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.ResponseTransport{Data: bts, Size: len(bts), Total: series.BookCount})
+}
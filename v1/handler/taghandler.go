@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type TagHandler struct {
+	service *service.TagService
+}
+
+func NewTagHandler(s *service.TagService) *TagHandler {
+	h := new(TagHandler)
+	h.service = s
+	return h
+}
+
+// QueryTag return tag cloud, tags with number of books they are attached to
+func (h *TagHandler) QueryTag(c echo.Context) error {
+	limit, _ := strconv.Atoi(c.QueryParam("size"))
+	minCount, _ := strconv.Atoi(c.QueryParam("min_count"))
+	q := query.TagQuery{Limit: limit, Prefix: c.QueryParam("prefix"), MinCount: minCount}
+	tags, err := h.service.QueryTag(q)
+	if err != nil {
+		return err
+	}
+	tts := []transport.TagTransport{}
+	for _, e := range tags {
+		tts = append(tts, mapper.ToTagTransport(e))
+	}
+	return c.JSON(http.StatusOK, tts)
+}
+
+// AttachTags attach tags in body to the book
+func (h *TagHandler) AttachTags(c echo.Context) error {
+	t := transport.AttachTagTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	b, err := h.service.AttachTags(c.Param("id"), t.Tags)
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToBookTransport(*b))
+}
+
+// DetachTag detach tag of path from the book
+func (h *TagHandler) DetachTag(c echo.Context) error {
+	b, err := h.service.DetachTag(c.Param("id"), c.Param("tag"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToBookTransport(*b))
+}
+
+// splitList split comma separated query parameter, blank items are dropped
+func splitList(param string) []string {
+	items := []string{}
+	for _, item := range strings.Split(param, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo"
+	"github.com/tsongpon/backend-challenge-2019/query"
+	"github.com/tsongpon/backend-challenge-2019/service"
+	"github.com/tsongpon/backend-challenge-2019/v1/mapper"
+	"github.com/tsongpon/backend-challenge-2019/v1/transport"
+)
+
+type WorkHandler struct {
+	service *service.WorkService
+}
+
+func NewWorkHandler(s *service.WorkService) *WorkHandler {
+	h := new(WorkHandler)
+	h.service = s
+	return h
+}
+
+func (h *WorkHandler) GetWork(c echo.Context) error {
+	w, err := h.service.GetWork(c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToWorkTransport(*w))
+}
+
+func (h *WorkHandler) QueryWork(c echo.Context) error {
+	var limit int
+	var offset int
+	var err error
+	if limit, err = strconv.Atoi(c.QueryParam("size")); err != nil {
+		limit = defaultLimit
+	}
+	if offset, err = strconv.Atoi(c.QueryParam("offset")); err != nil {
+		offset = defaultOffset
+	}
+	q := query.WorkQuery{Limit: limit, Offset: offset, Title: c.QueryParam("title")}
+	works, err := h.service.QueryWork(q)
+	if err != nil {
+		return err
+	}
+	total, err := h.service.CountWork(q)
+	if err != nil {
+		return err
+	}
+	wts := []transport.WorkTransport{}
+	for _, e := range works {
+		wts = append(wts, mapper.ToWorkTransport(e))
+	}
+	return c.JSON(http.StatusOK, transport.WorkResponseTransport{Data: wts, Size: len(wts), Total: total})
+}
+
+func (h *WorkHandler) CreateWork(c echo.Context) error {
+	t := transport.WorkTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	created, err := h.service.Create(mapper.ToWorkModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusCreated, mapper.ToWorkTransport(*created))
+}
+
+func (h *WorkHandler) UpdateWork(c echo.Context) error {
+	t := transport.WorkTransport{}
+	if err := c.Bind(&t); err != nil {
+		return err
+	}
+	t.ID = c.Param("id")
+	if err := c.Validate(t); err != nil {
+		return err
+	}
+	updated, err := h.service.Update(mapper.ToWorkModel(t))
+	if err != nil {
+		return err
+	}
+	return c.JSON(http.StatusOK, mapper.ToWorkTransport(*updated))
+}
+
+func (h *WorkHandler) DeleteWork(c echo.Context) error {
+	if err := h.service.Delete(c.Param("id")); err != nil {
+		return err
+	}
+	return c.NoContent(http.StatusOK)
+}
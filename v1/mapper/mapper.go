@@ -8,58 +8,264 @@ import (
 func ToBookModel(t transport.BookTransport) model.Book {
 	m := model.Book{
 		ID:             t.ID,
+		WorkID:         t.WorkID,
 		Title:          t.Title,
 		Synopsis:       t.Synopsis,
 		ISBN10:         t.ISBN10,
 		ISBN13:         t.ISBN13,
 		Language:       t.Language,
+		PublisherID:    t.PublisherID,
 		Publisher:      t.Publisher,
+		SeriesID:       t.SeriesID,
+		SeriesVolume:   t.SeriesVolume,
 		Category:       t.Category,
 		Edition:        t.Edition,
 		SoldAmount:     t.SoldAmount,
 		CurrentAmount:  t.CurrentAmount,
 		PaperbackPrice: t.PaperbackPrice,
 		EbookPrice:     t.EbookPrice,
+		CostPrice:      t.CostPrice,
 		CreatedTime:    t.CreatedTime,
 		ModifiedTime:   t.ModifiedTime,
 		Version:        t.Version,
 	}
+	if t.Authors != nil {
+		m.Authors = []model.BookAuthor{}
+		for _, a := range t.Authors {
+			m.Authors = append(m.Authors, model.BookAuthor{AuthorID: a.AuthorID, Role: a.Role})
+		}
+	}
+	if t.Categories != nil {
+		m.Categories = []model.BookCategory{}
+		for _, c := range t.Categories {
+			m.Categories = append(m.Categories, model.BookCategory{CategoryID: c.CategoryID})
+		}
+	}
 	return m
 }
 
 func ToBookTransport(m model.Book) transport.BookTransport {
 	t := transport.BookTransport{
-		ID:             m.ID,
-		Title:          m.Title,
-		Synopsis:       m.Synopsis,
-		ISBN10:         m.ISBN10,
-		ISBN13:         m.ISBN13,
-		Language:       m.Language,
-		Publisher:      m.Publisher,
-		Category:       m.Category,
-		Edition:        m.Edition,
-		SoldAmount:     m.SoldAmount,
-		CurrentAmount:  m.CurrentAmount,
-		PaperbackPrice: m.PaperbackPrice,
-		EbookPrice:     m.EbookPrice,
-		CreatedTime:    m.CreatedTime,
-		ModifiedTime:   m.ModifiedTime,
-		Version:        m.Version,
-		AverageScore:   m.AverageScore,
+		ID:                       m.ID,
+		WorkID:                   m.WorkID,
+		Title:                    m.Title,
+		Synopsis:                 m.Synopsis,
+		ISBN10:                   m.ISBN10,
+		ISBN13:                   m.ISBN13,
+		Language:                 m.Language,
+		PublisherID:              m.PublisherID,
+		Publisher:                m.Publisher,
+		SeriesID:                 m.SeriesID,
+		Series:                   m.Series,
+		SeriesVolume:             m.SeriesVolume,
+		PreviousInSeries:         toSeriesBookTransport(m.PreviousInSeries),
+		NextInSeries:             toSeriesBookTransport(m.NextInSeries),
+		Category:                 m.Category,
+		Edition:                  m.Edition,
+		SoldAmount:               m.SoldAmount,
+		CurrentAmount:            m.CurrentAmount,
+		PaperbackPrice:           m.PaperbackPrice,
+		EbookPrice:               m.EbookPrice,
+		CostPrice:                m.CostPrice,
+		CreatedTime:              m.CreatedTime,
+		ModifiedTime:             m.ModifiedTime,
+		Version:                  m.Version,
+		AverageScore:             m.AverageScore,
+		VerifiedAverageScore:     m.VerifiedAverageScore,
+		WorkAverageScore:         m.WorkAverageScore,
+		WorkVerifiedAverageScore: m.WorkVerifiedAverageScore,
+		Authors:                  []transport.BookAuthorTransport{},
+		Categories:               []transport.BookCategoryTransport{},
+		Tags:                     []string{},
+	}
+	t.Tags = append(t.Tags, m.Tags...)
+	for _, a := range m.Authors {
+		t.Authors = append(t.Authors, transport.BookAuthorTransport{
+			AuthorID: a.AuthorID,
+			Name:     a.Name,
+			SortName: a.SortName,
+			Role:     a.Role,
+		})
+	}
+	for _, c := range m.Categories {
+		t.Categories = append(t.Categories, transport.BookCategoryTransport{
+			CategoryID: c.CategoryID,
+			Slug:       c.Slug,
+			Name:       c.Name,
+		})
 	}
 	return t
 }
 
-func ToReviewTransport(m model.Review) transport.ReviewTransport {
-	t := transport.ReviewTransport{
+func ToAuthorModel(t transport.AuthorTransport) model.Author {
+	return model.Author{
+		ID:           t.ID,
+		Name:         t.Name,
+		SortName:     t.SortName,
+		Bio:          t.Bio,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+}
+
+func ToAuthorTransport(m model.Author) transport.AuthorTransport {
+	return transport.AuthorTransport{
 		ID:           m.ID,
-		Score:        m.Score,
-		Description:  m.Description,
-		BookID:       m.BookID,
+		Name:         m.Name,
+		SortName:     m.SortName,
+		Bio:          m.Bio,
+		CreatedTime:  m.CreatedTime,
+		ModifiedTime: m.ModifiedTime,
+		Version:      m.Version,
+	}
+}
+
+func ToCategoryModel(t transport.CategoryTransport) model.Category {
+	return model.Category{
+		ID:           t.ID,
+		ParentID:     t.ParentID,
+		Slug:         t.Slug,
+		Name:         t.Name,
+		Names:        t.Names,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+}
+
+func ToCategoryTransport(m model.Category) transport.CategoryTransport {
+	t := transport.CategoryTransport{
+		ID:           m.ID,
+		ParentID:     m.ParentID,
+		Slug:         m.Slug,
+		Name:         m.Name,
+		Names:        m.Names,
+		Path:         m.Path,
+		Depth:        m.Depth,
+		BookCount:    m.BookCount,
 		CreatedTime:  m.CreatedTime,
 		ModifiedTime: m.ModifiedTime,
 		Version:      m.Version,
 	}
+	if t.Names == nil {
+		t.Names = map[string]string{}
+	}
+	return t
+}
+
+func ToTagTransport(m model.Tag) transport.TagTransport {
+	return transport.TagTransport{Name: m.Name, BookCount: m.BookCount}
+}
+
+func ToWorkModel(t transport.WorkTransport) model.Work {
+	return model.Work{
+		ID:           t.ID,
+		Title:        t.Title,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+}
+
+// ToWorkTransport map work, editions are included when they are loaded
+func ToWorkTransport(m model.Work) transport.WorkTransport {
+	t := transport.WorkTransport{
+		ID:                   m.ID,
+		Title:                m.Title,
+		EditionCount:         m.EditionCount,
+		ReviewCount:          m.ReviewCount,
+		AverageScore:         m.AverageScore,
+		VerifiedAverageScore: m.VerifiedAverageScore,
+		CreatedTime:          m.CreatedTime,
+		ModifiedTime:         m.ModifiedTime,
+		Version:              m.Version,
+	}
+	if m.Editions != nil {
+		t.EditionsTruncated = m.EditionsTruncated
+		t.Editions = []transport.BookTransport{}
+		for _, b := range m.Editions {
+			t.Editions = append(t.Editions, ToBookTransport(b))
+		}
+	}
+	return t
+}
+
+func ToSeriesModel(t transport.SeriesTransport) model.Series {
+	return model.Series{
+		ID:           t.ID,
+		Name:         t.Name,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+}
+
+func ToSeriesTransport(m model.Series) transport.SeriesTransport {
+	return transport.SeriesTransport{
+		ID:           m.ID,
+		Name:         m.Name,
+		BookCount:    m.BookCount,
+		CreatedTime:  m.CreatedTime,
+		ModifiedTime: m.ModifiedTime,
+		Version:      m.Version,
+	}
+}
+
+func toSeriesBookTransport(m *model.SeriesBook) *transport.SeriesBookTransport {
+	if m == nil {
+		return nil
+	}
+	return &transport.SeriesBookTransport{ID: m.ID, Title: m.Title, Volume: m.Volume, Href: "/v1/books/" + m.ID}
+}
+
+func ToPublisherModel(t transport.PublisherTransport) model.Publisher {
+	return model.Publisher{
+		ID:           t.ID,
+		Name:         t.Name,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+}
+
+func ToPublisherTransport(m model.Publisher) transport.PublisherTransport {
+	return transport.PublisherTransport{
+		ID:           m.ID,
+		Name:         m.Name,
+		BookCount:    m.BookCount,
+		CreatedTime:  m.CreatedTime,
+		ModifiedTime: m.ModifiedTime,
+		Version:      m.Version,
+	}
+}
+
+func ToReviewTransport(m model.Review) transport.ReviewTransport {
+	t := transport.ReviewTransport{
+		ID:               m.ID,
+		Score:            m.Score,
+		Description:      m.Description,
+		BookID:           m.BookID,
+		ReviewerID:       m.ReviewerID,
+		VerifiedPurchase: m.VerifiedPurchase,
+		ScreeningStatus:  m.ScreeningStatus,
+		HelpfulCount:     m.HelpfulCount,
+		UnhelpfulCount:   m.UnhelpfulCount,
+		HelpfulnessScore: m.HelpfulnessScore,
+		Sentiment:        m.Sentiment,
+		CreatedTime:      m.CreatedTime,
+		ModifiedTime:     m.ModifiedTime,
+		Version:          m.Version,
+	}
+	for _, v := range m.Verdicts {
+		t.Verdicts = append(t.Verdicts, transport.VerdictTransport{Rule: v.Rule, Verdict: v.Verdict, Reason: v.Reason})
+	}
+	for _, r := range m.Replies {
+		t.Replies = append(t.Replies, ToReplyTransport(r))
+	}
+	if m.Book != nil {
+		t.Book = &transport.BookSummaryTransport{ID: m.Book.ID, Title: m.Book.Title, Category: m.Book.Category}
+	}
 	return t
 }
 
@@ -69,9 +275,112 @@ func ToReviewModel(t transport.ReviewTransport) model.Review {
 		Score:        t.Score,
 		Description:  t.Description,
 		BookID:       t.BookID,
+		ReviewerID:   t.ReviewerID,
+		CreatedTime:  t.CreatedTime,
+		ModifiedTime: t.ModifiedTime,
+		Version:      t.Version,
+	}
+	return m
+}
+
+func ToReviewRevisionTransport(m model.ReviewRevision) transport.ReviewRevisionTransport {
+	t := transport.ReviewRevisionTransport{
+		Version:      m.Version,
+		Score:        m.Score,
+		Description:  m.Description,
+		ModifiedTime: m.ModifiedTime,
+	}
+	return t
+}
+
+func ToReplyTransport(m model.Reply) transport.ReplyTransport {
+	t := transport.ReplyTransport{
+		ID:           m.ID,
+		ReviewID:     m.ReviewID,
+		AuthorID:     m.AuthorID,
+		Description:  m.Description,
+		CreatedTime:  m.CreatedTime,
+		ModifiedTime: m.ModifiedTime,
+		Version:      m.Version,
+	}
+	return t
+}
+
+func ToReplyModel(t transport.ReplyTransport) model.Reply {
+	m := model.Reply{
+		ID:           t.ID,
+		ReviewID:     t.ReviewID,
+		AuthorID:     t.AuthorID,
+		Description:  t.Description,
 		CreatedTime:  t.CreatedTime,
 		ModifiedTime: t.ModifiedTime,
 		Version:      t.Version,
 	}
 	return m
 }
+
+func ToRestockTransport(m model.Restock) transport.RestockTransport {
+	t := transport.RestockTransport{
+		ID:           m.ID,
+		BookID:       m.BookID,
+		Quantity:     m.Quantity,
+		Status:       m.Status,
+		CreatedTime:  m.CreatedTime,
+		ModifiedTime: m.ModifiedTime,
+		Version:      m.Version,
+	}
+	return t
+}
+
+func ToReportRunTransport(m model.ReportRun) transport.ReportRunTransport {
+	t := transport.ReportRunTransport{
+		ID:            m.ID,
+		Job:           m.Job,
+		Report:        m.Report,
+		Format:        m.Format,
+		ScheduledTime: m.ScheduledTime,
+		StartedTime:   m.StartedTime,
+		FinishedTime:  m.FinishedTime,
+		Status:        m.Status,
+		Error:         m.Error,
+	}
+	if m.Status == model.RunDone {
+		t.Download = "/v1/report-runs/" + m.ID + "/download"
+	}
+	return t
+}
+
+func ToBestsellerListTransport(m model.BestsellerList) transport.BestsellerListTransport {
+	t := transport.BestsellerListTransport{Category: m.Category, Books: []transport.BestsellerEntryTransport{}}
+	if m.Period != nil {
+		t.Period = m.Period.Format("2006-01-02")
+		t.PeriodEnd = m.Period.AddDate(0, 0, 6).Format("2006-01-02")
+	}
+	for _, e := range m.Entries {
+		t.Books = append(t.Books, transport.BestsellerEntryTransport{
+			Rank:         e.Rank,
+			BookID:       e.BookID,
+			Title:        e.Title,
+			PreviousRank: e.PreviousRank,
+			WeeksOnList:  e.WeeksOnList,
+			PeakRank:     e.PeakRank,
+			Movement:     rankMovement(e),
+		})
+	}
+	return t
+}
+
+// rankMovement describe change of rank since list of previous week
+func rankMovement(e model.BestsellerEntry) string {
+	switch {
+	case e.PreviousRank == nil && e.WeeksOnList > 1:
+		return "returning"
+	case e.PreviousRank == nil:
+		return "new"
+	case *e.PreviousRank > e.Rank:
+		return "up"
+	case *e.PreviousRank < e.Rank:
+		return "down"
+	}
+	return "same"
+}
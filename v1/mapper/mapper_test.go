@@ -124,3 +124,49 @@ func TestToReviewModel(t *testing.T) {
 	assert.Equal(t, &modifiedTime, rev.ModifiedTime)
 	assert.Equal(t, 1, rev.Version)
 }
+
+func TestToReviewTransportWithReplies(t *testing.T) {
+	now := time.Now()
+	rev := model.Review{
+		ID:    "dfa4f5c3-1866-4807-963c-f8d991753769",
+		Score: 2,
+		Replies: []model.Reply{
+			{
+				ID:           "3285919c-1db4-42b8-b8a6-3cd8771dfa52",
+				ReviewID:     "dfa4f5c3-1866-4807-963c-f8d991753769",
+				AuthorID:     "staff-1",
+				Description:  "Sorry to hear that",
+				CreatedTime:  &now,
+				ModifiedTime: &now,
+				Version:      1,
+			},
+		},
+	}
+
+	tsp := ToReviewTransport(rev)
+
+	assert.Equal(t, 1, len(tsp.Replies))
+	assert.Equal(t, "3285919c-1db4-42b8-b8a6-3cd8771dfa52", tsp.Replies[0].ID)
+	assert.Equal(t, "staff-1", tsp.Replies[0].AuthorID)
+	assert.Equal(t, "Sorry to hear that", tsp.Replies[0].Description)
+	assert.Equal(t, 1, tsp.Replies[0].Version)
+}
+
+func TestToBestsellerListTransport(t *testing.T) {
+	period := time.Date(2019, 12, 2, 0, 0, 0, 0, time.UTC)
+	two, four := 2, 4
+	m := model.BestsellerList{Category: "Programming", Period: &period, Entries: []model.BestsellerEntry{
+		{Rank: 1, BookID: "1", PreviousRank: &two, WeeksOnList: 3, PeakRank: 1},
+		{Rank: 2, BookID: "2", WeeksOnList: 1, PeakRank: 2},
+		{Rank: 3, BookID: "3", WeeksOnList: 5, PeakRank: 1},
+		{Rank: 4, BookID: "4", PreviousRank: &four, WeeksOnList: 2, PeakRank: 4},
+	}}
+	tsp := ToBestsellerListTransport(m)
+
+	assert.Equal(t, "2019-12-02", tsp.Period)
+	assert.Equal(t, "2019-12-08", tsp.PeriodEnd)
+	assert.Equal(t, "up", tsp.Books[0].Movement)
+	assert.Equal(t, "new", tsp.Books[1].Movement)
+	assert.Equal(t, "returning", tsp.Books[2].Movement)
+	assert.Equal(t, "same", tsp.Books[3].Movement)
+}
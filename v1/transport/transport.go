@@ -3,33 +3,96 @@ package transport
 import "time"
 
 type ReviewTransport struct {
-	ID           string     `json:"id"`
+	ID               string                `json:"id"`
+	Score            int                   `json:"score"`
+	Description      string                `json:"description"`
+	BookID           string                `json:"-"`
+	ReviewerID       string                `json:"reviewer_id"`
+	VerifiedPurchase bool                  `json:"verified_purchase"`
+	ScreeningStatus  string                `json:"screening_status"`
+	Verdicts         []VerdictTransport    `json:"verdicts,omitempty"`
+	HelpfulCount     int                   `json:"helpful_count"`
+	UnhelpfulCount   int                   `json:"unhelpful_count"`
+	HelpfulnessScore float64               `json:"helpfulness_score"`
+	Sentiment        *float64              `json:"sentiment"`
+	Replies          []ReplyTransport      `json:"replies,omitempty"`
+	Book             *BookSummaryTransport `json:"book,omitempty"`
+	CreatedTime      *time.Time            `json:"created_time"`
+	ModifiedTime     *time.Time            `json:"modified_time"`
+	Version          int                   `json:"version"`
+}
+
+type BookSummaryTransport struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Category string `json:"category"`
+}
+
+type ReviewFeedTransport struct {
+	Size       int               `json:"size"`
+	NextCursor string            `json:"next_cursor"`
+	Data       []ReviewTransport `json:"data"`
+}
+
+type VerdictTransport struct {
+	Rule    string `json:"rule"`
+	Verdict string `json:"verdict"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type ReviewRevisionTransport struct {
+	Version      int        `json:"version"`
 	Score        int        `json:"score"`
 	Description  string     `json:"description"`
-	BookID       string     `json:"-"`
+	ModifiedTime *time.Time `json:"modified_time"`
+}
+
+type ReplyTransport struct {
+	ID           string     `json:"id"`
+	ReviewID     string     `json:"review_id"`
+	AuthorID     string     `json:"author_id"`
+	Description  string     `json:"description" validate:"required"`
 	CreatedTime  *time.Time `json:"created_time"`
 	ModifiedTime *time.Time `json:"modified_time"`
 	Version      int        `json:"version"`
 }
 
+type VoteTransport struct {
+	Helpful *bool `json:"helpful"`
+}
+
 type BookTransport struct {
-	ID             string     `json:"id"`
-	Title          string     `json:"title" validate:"required"`
-	Synopsis       string     `json:"synopsis"`
-	ISBN10         string     `json:"isbn10"`
-	ISBN13         string     `json:"isbn13"`
-	Category       string     `json:"category"`
-	Language       string     `json:"language" validate:"required"`
-	Publisher      string     `json:"publisher" validate:"required"`
-	Edition        string     `json:"edition"`
-	SoldAmount     int        `json:"sold_amount" validate:"gte=0"`
-	CurrentAmount  int        `json:"current_amount" validate:"gte=0"`
-	PaperbackPrice *float64   `json:"paperback_price" validate:"gte=0"`
-	EbookPrice     *float64   `json:"ebook_price" validate:"gte=0"`
-	AverageScore   *float64   `json:"average_score"`
-	CreatedTime    *time.Time `json:"created_time"`
-	ModifiedTime   *time.Time `json:"modified_time"`
-	Version        int        `json:"version"`
+	ID                       string                  `json:"id"`
+	WorkID                   string                  `json:"work_id"`
+	Title                    string                  `json:"title" validate:"required"`
+	Synopsis                 string                  `json:"synopsis"`
+	ISBN10                   string                  `json:"isbn10"`
+	ISBN13                   string                  `json:"isbn13"`
+	Category                 string                  `json:"category"` //main category name, used to find or create it when categories are not given
+	Categories               []BookCategoryTransport `json:"categories" validate:"dive"`
+	Language                 string                  `json:"language" validate:"required"`
+	PublisherID              string                  `json:"publisher_id"`
+	Publisher                string                  `json:"publisher"` //name, used to find or create publisher when publisher_id is not given
+	SeriesID                 string                  `json:"series_id"`
+	Series                   string                  `json:"series"` //read only name of the series
+	SeriesVolume             *float64                `json:"series_volume" validate:"omitempty,gte=0"`
+	PreviousInSeries         *SeriesBookTransport    `json:"previous_in_series"`
+	NextInSeries             *SeriesBookTransport    `json:"next_in_series"`
+	Edition                  string                  `json:"edition"`
+	SoldAmount               int                     `json:"sold_amount" validate:"gte=0"`
+	CurrentAmount            int                     `json:"current_amount" validate:"gte=0"`
+	PaperbackPrice           *float64                `json:"paperback_price" validate:"gte=0"`
+	EbookPrice               *float64                `json:"ebook_price" validate:"gte=0"`
+	CostPrice                *float64                `json:"cost_price" validate:"omitempty,gte=0"`
+	AverageScore             *float64                `json:"average_score"`
+	VerifiedAverageScore     *float64                `json:"verified_average_score"`
+	WorkAverageScore         *float64                `json:"work_average_score"`
+	WorkVerifiedAverageScore *float64                `json:"work_verified_average_score"`
+	Authors                  []BookAuthorTransport   `json:"authors" validate:"dive"`
+	Tags                     []string                `json:"tags"` //read only, changed by attaching and detaching tags
+	CreatedTime              *time.Time              `json:"created_time"`
+	ModifiedTime             *time.Time              `json:"modified_time"`
+	Version                  int                     `json:"version"`
 }
 
 type ResponseTransport struct {
@@ -38,10 +101,197 @@ type ResponseTransport struct {
 	Data  []BookTransport `json:"data"`
 }
 
+type BookAuthorTransport struct {
+	AuthorID string `json:"author_id" validate:"required"`
+	Name     string `json:"name"`
+	SortName string `json:"sort_name"`
+	Role     string `json:"role"` //author, translator, illustrator or editor, author when not given
+}
+
+type BookCategoryTransport struct {
+	CategoryID string `json:"category_id" validate:"required"`
+	Slug       string `json:"slug"`
+	Name       string `json:"name"`
+}
+
+type CategoryTransport struct {
+	ID           string            `json:"id"`
+	ParentID     string            `json:"parent_id"`
+	Slug         string            `json:"slug"`
+	Name         string            `json:"name" validate:"required"`
+	Names        map[string]string `json:"names"` //localized names by locale
+	Path         string            `json:"path"`
+	Depth        int               `json:"depth"`
+	BookCount    int               `json:"book_count"`
+	CreatedTime  *time.Time        `json:"created_time"`
+	ModifiedTime *time.Time        `json:"modified_time"`
+	Version      int               `json:"version"`
+}
+
+type CategoryResponseTransport struct {
+	Total int                 `json:"total"`
+	Size  int                 `json:"size"`
+	Data  []CategoryTransport `json:"data"`
+}
+
+type MoveCategoryTransport struct {
+	ParentID string `json:"parent_id"` //empty to move to top level
+}
+
+type MergeCategoryTransport struct {
+	CategoryIDs []string `json:"category_ids" validate:"required"`
+}
+
+type TagTransport struct {
+	Name      string `json:"name"`
+	BookCount int    `json:"book_count"`
+}
+
+type AttachTagTransport struct {
+	Tags []string `json:"tags" validate:"required"`
+}
+
+type AuthorTransport struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name" validate:"required"`
+	SortName     string     `json:"sort_name"`
+	Bio          string     `json:"bio"`
+	CreatedTime  *time.Time `json:"created_time"`
+	ModifiedTime *time.Time `json:"modified_time"`
+	Version      int        `json:"version"`
+}
+
+type AuthorResponseTransport struct {
+	Total int               `json:"total"`
+	Size  int               `json:"size"`
+	Data  []AuthorTransport `json:"data"`
+}
+
+type WorkTransport struct {
+	ID                   string          `json:"id"`
+	Title                string          `json:"title" validate:"required"`
+	EditionCount         int             `json:"edition_count"`
+	ReviewCount          int             `json:"review_count"`
+	AverageScore         *float64        `json:"average_score"`
+	VerifiedAverageScore *float64        `json:"verified_average_score"`
+	Editions             []BookTransport `json:"editions,omitempty"`
+	EditionsTruncated    bool            `json:"editions_truncated,omitempty"`
+	CreatedTime          *time.Time      `json:"created_time"`
+	ModifiedTime         *time.Time      `json:"modified_time"`
+	Version              int             `json:"version"`
+}
+
+type WorkResponseTransport struct {
+	Total int             `json:"total"`
+	Size  int             `json:"size"`
+	Data  []WorkTransport `json:"data"`
+}
+
+type SeriesTransport struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name" validate:"required"`
+	BookCount    int        `json:"book_count"`
+	CreatedTime  *time.Time `json:"created_time"`
+	ModifiedTime *time.Time `json:"modified_time"`
+	Version      int        `json:"version"`
+}
+
+type SeriesResponseTransport struct {
+	Total int               `json:"total"`
+	Size  int               `json:"size"`
+	Data  []SeriesTransport `json:"data"`
+}
+
+// SeriesBookTransport is link to another book of the same series
+type SeriesBookTransport struct {
+	ID     string   `json:"id"`
+	Title  string   `json:"title"`
+	Volume *float64 `json:"series_volume"`
+	Href   string   `json:"href"`
+}
+
+type PublisherTransport struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name" validate:"required"`
+	BookCount    int        `json:"book_count"`
+	CreatedTime  *time.Time `json:"created_time"`
+	ModifiedTime *time.Time `json:"modified_time"`
+	Version      int        `json:"version"`
+}
+
+type PublisherResponseTransport struct {
+	Total int                  `json:"total"`
+	Size  int                  `json:"size"`
+	Data  []PublisherTransport `json:"data"`
+}
+
+type MergePublisherTransport struct {
+	PublisherIDs []string `json:"publisher_ids" validate:"required"`
+}
+
+// MergeDuplicatePublishersTransport is groups of publisher ids to merge, each into
+// its first publisher
+type MergeDuplicatePublishersTransport struct {
+	Groups [][]string `json:"groups" validate:"required"`
+}
+
+// DuplicatePublisherTransport is group of publishers with same name, publisher to
+// keep comes first
+type DuplicatePublisherTransport struct {
+	Publishers []PublisherTransport `json:"publishers"`
+}
+
 type FillBookTransport struct {
 	Amount int `json:"amount"`
 }
 
 type SaleBookTransport struct {
-	Amount int `json:"amount"`
+	Amount     int    `json:"amount"`
+	CustomerID string `json:"customer_id"`
+	Format     string `json:"format"`
+}
+
+type RestockTransport struct {
+	ID           string     `json:"id"`
+	BookID       string     `json:"book_id" validate:"required"`
+	Quantity     int        `json:"quantity" validate:"gte=0"`
+	Status       string     `json:"status"`
+	CreatedTime  *time.Time `json:"created_time"`
+	ModifiedTime *time.Time `json:"modified_time"`
+	Version      int        `json:"version"`
+}
+
+type RestockStatusTransport struct {
+	Status  string `json:"status" validate:"required"`
+	Version int    `json:"version"`
+}
+
+type ReportRunTransport struct {
+	ID            string     `json:"id"`
+	Job           string     `json:"job"`
+	Report        string     `json:"report"`
+	Format        string     `json:"format"`
+	ScheduledTime *time.Time `json:"scheduled_time"`
+	StartedTime   *time.Time `json:"started_time"`
+	FinishedTime  *time.Time `json:"finished_time"`
+	Status        string     `json:"status"`
+	Error         string     `json:"error,omitempty"`
+	Download      string     `json:"download,omitempty"`
+}
+
+type BestsellerListTransport struct {
+	Category  string                     `json:"category"`
+	Period    string                     `json:"period"`     //Monday the week starts
+	PeriodEnd string                     `json:"period_end"` //Sunday the week ends
+	Books     []BestsellerEntryTransport `json:"books"`
+}
+
+type BestsellerEntryTransport struct {
+	Rank         int    `json:"rank"`
+	BookID       string `json:"book_id"`
+	Title        string `json:"title"`
+	PreviousRank *int   `json:"previous_rank"`
+	WeeksOnList  int    `json:"weeks_on_list"`
+	PeakRank     int    `json:"peak_rank"`
+	Movement     string `json:"movement"` //new, returning, up, down or same
 }